@@ -0,0 +1,136 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package brewcmd provides Homebrew related commands, such as managing the
+// offline Homebrew metadata index.
+package brewcmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/anvil/internal/network"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+	"github.com/0xjuanma/palantir"
+	"github.com/spf13/cobra"
+)
+
+// BrewCmd represents the brew command.
+var BrewCmd = &cobra.Command{
+	Use:   "brew",
+	Short: "Manage anvil's Homebrew integration",
+	Long:  constants.BREW_COMMAND_LONG_DESCRIPTION,
+}
+
+// indexCmd shows the offline Homebrew index.
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Show the offline Homebrew metadata index",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runIndexCommand()
+	},
+}
+
+// indexUpdateCmd downloads the Homebrew index.
+var indexUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Download the latest Homebrew formula and cask metadata",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runIndexUpdateCommand(cmd)
+	},
+	Example: `  anvil brew index update
+  anvil brew index update --api-url https://mirror.example.com/api`,
+}
+
+func init() {
+	indexUpdateCmd.Flags().String("api-url", "", "Homebrew API base URL (defaults to $HOMEBREW_API_DOMAIN or formulae.brew.sh)")
+	indexUpdateCmd.Flags().Duration("timeout", 2*time.Minute, "Timeout for downloading the index")
+
+	indexCmd.AddCommand(indexUpdateCmd)
+	BrewCmd.AddCommand(indexCmd)
+}
+
+// runIndexCommand prints where the index lives and what it contains
+func runIndexCommand() error {
+	o := palantir.GetGlobalOutputHandler()
+	o.PrintHeader("Homebrew Index")
+
+	indexPath, err := brew.IndexPath()
+	if err != nil {
+		return errors.NewFileSystemError(constants.OpBrew, "index-path", err)
+	}
+
+	index := brew.CurrentIndex()
+	if index.Partial {
+		o.PrintWarning("Using the built-in index of %d popular packages", index.Len())
+		o.PrintInfo("Run 'anvil brew index update' to download the full Homebrew index to %s", indexPath)
+		return nil
+	}
+
+	o.PrintInfo("Path:     %s", indexPath)
+	o.PrintInfo("Source:   %s", index.Source)
+	o.PrintInfo("Updated:  %s", index.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	o.PrintInfo("Packages: %d formulae, %d casks", len(index.Formulae), len(index.Casks))
+	return nil
+}
+
+// runIndexUpdateCommand downloads the Homebrew index and saves it for offline lookups
+func runIndexUpdateCommand(cmd *cobra.Command) error {
+	o := palantir.GetGlobalOutputHandler()
+	o.PrintHeader("Updating Homebrew Index")
+
+	apiURL, _ := cmd.Flags().GetString("api-url")
+	if apiURL == "" {
+		apiURL = brew.HomebrewAPIURL()
+	}
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	indexPath, err := brew.IndexPath()
+	if err != nil {
+		return errors.NewFileSystemError(constants.OpBrew, "index-path", err)
+	}
+
+	httpClient, err := network.Client()
+	if err != nil {
+		return errors.NewConfigurationError(constants.OpBrew, "network", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Downloading formula and cask metadata from %s", apiURL))
+	spinner.Start()
+	index, err := brew.FetchIndex(ctx, httpClient, apiURL)
+	if err != nil {
+		spinner.Error("Failed to download Homebrew metadata")
+		return errors.NewInstallationError(constants.OpBrew, "index-update", err)
+	}
+	spinner.Success("Homebrew metadata downloaded")
+
+	if err := index.Save(indexPath); err != nil {
+		return errors.NewFileSystemError(constants.OpBrew, "index-save", err)
+	}
+	brew.UseIndex(index)
+
+	o.PrintSuccess(fmt.Sprintf("Indexed %d formulae and %d casks in %s", len(index.Formulae), len(index.Casks), indexPath))
+	return nil
+}
@@ -125,8 +125,8 @@ func getItemsToClean(anvilDir string) ([]string, error) {
 
 	var itemsToClean []string
 	for _, item := range items {
-		// Skip Anvil config file
-		if item.Name() == constants.ANVIL_CONFIG_FILE {
+		// Skip Anvil config file and install manifests, which uninstalls depend on
+		if item.Name() == constants.ANVIL_CONFIG_FILE || item.Name() == constants.MANIFESTS_DIR {
 			continue
 		}
 
@@ -18,11 +18,14 @@ limitations under the License.
 package config
 
 import (
+	"github.com/0xjuanma/anvil/cmd/config/export"
 	importcmd "github.com/0xjuanma/anvil/cmd/config/import"
 	"github.com/0xjuanma/anvil/cmd/config/pull"
 	"github.com/0xjuanma/anvil/cmd/config/push"
+	"github.com/0xjuanma/anvil/cmd/config/schema"
 	"github.com/0xjuanma/anvil/cmd/config/show"
 	"github.com/0xjuanma/anvil/cmd/config/sync"
+	"github.com/0xjuanma/anvil/cmd/config/validate"
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/spf13/cobra"
 )
@@ -37,10 +40,13 @@ var ConfigCmd = &cobra.Command{
 }
 
 func init() {
-	// Add pull, push, show, sync, and import as sub-commands of config
+	// Add pull, push, show, sync, import, export, validate, and schema as sub-commands of config
 	ConfigCmd.AddCommand(pull.PullCmd)
 	ConfigCmd.AddCommand(push.PushCmd)
 	ConfigCmd.AddCommand(show.ShowCmd)
 	ConfigCmd.AddCommand(sync.SyncCmd)
 	ConfigCmd.AddCommand(importcmd.ImportCmd)
+	ConfigCmd.AddCommand(export.ExportCmd)
+	ConfigCmd.AddCommand(validate.ValidateCmd)
+	ConfigCmd.AddCommand(schema.SchemaCmd)
 }
@@ -0,0 +1,76 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+// Export file formats
+const (
+	formatYAML     = "yaml"
+	formatBrewfile = "brewfile"
+)
+
+// catalogBrewfile converts exported groups into a Brewfile usable by 'brew bundle'.
+// Apps are listed once in group name order; apps installed from a source are not
+// Homebrew packages and are skipped.
+func catalogBrewfile(catalog *config.ImportConfig, sources map[string]config.AppSource, isCask func(string) bool) (*brew.Brewfile, []config.ExportSkip) {
+	groupNames := make([]string, 0, len(catalog.Groups))
+	for groupName := range catalog.Groups {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+
+	var packages []string
+	var skipped []config.ExportSkip
+	for _, groupName := range groupNames {
+		for _, app := range catalog.Groups[groupName] {
+			if _, fromSource := sources[app]; fromSource {
+				skipped = append(skipped, config.ExportSkip{Key: fmt.Sprintf("groups.%s.%s", groupName, app), Reason: "installed from a source, not Homebrew"})
+				continue
+			}
+			packages = append(packages, app)
+		}
+	}
+
+	taps := make([]brew.BrewfileTap, 0, len(catalog.Taps))
+	for _, tap := range catalog.Taps {
+		taps = append(taps, brew.BrewfileTap{Name: tap.Name, URL: tap.URL})
+	}
+
+	return brew.NewBrewfile(config.MergeGroupTools(nil, packages), taps, isCask), skipped
+}
+
+// brewfileHeader returns the comment lines written at the top of an exported Brewfile
+func brewfileHeader(metadata config.ImportMetadata) []string {
+	var header []string
+	if metadata.Name != "" {
+		header = append(header, metadata.Name)
+	}
+	if metadata.Description != "" {
+		header = append(header, metadata.Description)
+	}
+	if metadata.AnvilVersion != "" {
+		header = append(header, fmt.Sprintf("Exported by anvil %s on %s", metadata.AnvilVersion, metadata.ExportedAt))
+	}
+	return append(header, "Install with 'brew bundle --file <this file>' or 'anvil config import <this file>'")
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+func TestCatalogBrewfile(t *testing.T) {
+	catalog := &config.ImportConfig{
+		Groups: config.AnvilGroups{
+			"frontend": {"node", "visual-studio-code", "internal-cli"},
+			"dev":      {"git", "hashicorp/tap/terraform", "iterm2", "node"},
+		},
+		Taps: []config.BrewTap{{Name: "hashicorp/tap"}},
+	}
+	sources := map[string]config.AppSource{"internal-cli": config.NewAppSource("https://example.com/cli.tar.gz")}
+	casks := map[string]bool{"iterm2": true, "visual-studio-code": true}
+
+	brewfile, skipped := catalogBrewfile(catalog, sources, func(name string) bool { return casks[name] })
+
+	expected := `# Team tools
+# Install with 'brew bundle --file <this file>' or 'anvil config import <this file>'
+tap "hashicorp/tap"
+brew "git"
+brew "hashicorp/tap/terraform"
+brew "node"
+cask "iterm2"
+cask "visual-studio-code"
+`
+	if rendered := string(brewfile.Render(brewfileHeader(config.ImportMetadata{Name: "Team tools"})...)); rendered != expected {
+		t.Errorf("Expected Brewfile:\n%s\ngot:\n%s", expected, rendered)
+	}
+
+	expectedSkipped := []config.ExportSkip{{Key: "groups.frontend.internal-cli", Reason: "installed from a source, not Homebrew"}}
+	if !reflect.DeepEqual(skipped, expectedSkipped) {
+		t.Errorf("Expected skipped %v, got %v", expectedSkipped, skipped)
+	}
+}
+
+func TestCatalogBrewfileRoundTrip(t *testing.T) {
+	catalog := &config.ImportConfig{
+		Groups: config.AnvilGroups{"workstation": {"git", "jq", "firefox"}},
+		Taps:   []config.BrewTap{{Name: "company/private", URL: "https://git.example.com/company/homebrew-private.git"}},
+	}
+	isCask := func(name string) bool { return name == "firefox" }
+
+	exported, _ := catalogBrewfile(catalog, nil, isCask)
+	parsed, err := brew.ParseBrewfile(exported.Render(brewfileHeader(config.ImportMetadata{})...))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(parsed.Notes) != 0 {
+		t.Errorf("Expected exported Brewfile to parse cleanly, got %+v", parsed.Notes)
+	}
+	if !reflect.DeepEqual(parsed.Packages(), catalog.Groups["workstation"]) {
+		t.Errorf("Expected packages %v, got %v", catalog.Groups["workstation"], parsed.Packages())
+	}
+	if !reflect.DeepEqual(parsed.Taps, exported.Taps) {
+		t.Errorf("Expected taps %v, got %v", exported.Taps, parsed.Taps)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package export provides functionality to export groups from the anvil
+// configuration as a shareable file accepted by 'anvil config import', or as a
+// Brewfile for 'brew bundle'.
+package export
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/anvil/internal/version"
+	"github.com/0xjuanma/palantir"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var ExportCmd = &cobra.Command{
+	Use:   "export [group...]",
+	Short: "Export groups as a shareable import file or Brewfile",
+	Long:  constants.EXPORT_COMMAND_LONG_DESCRIPTION,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExportCommand(cmd, args)
+	},
+	Example: `  anvil config export                                   # Print all groups
+  anvil config export --groups dev,frontend -o team.yaml  # Export selected groups to a file
+  anvil config export --with-sources --name "Platform team" -o team.yaml
+  anvil config export --format brewfile dev -o Brewfile   # Export a group for 'brew bundle'`,
+}
+
+func init() {
+	ExportCmd.Flags().StringSlice("groups", nil, "Comma-separated groups to export (default: all groups)")
+	ExportCmd.Flags().String("format", formatYAML, "Output format: yaml or brewfile")
+	ExportCmd.Flags().Bool("with-sources", false, "Include installation sources of the exported apps")
+	ExportCmd.Flags().StringP("output", "o", "", "Write the file to a path instead of stdout")
+	ExportCmd.Flags().String("name", "", "Catalog name recorded in the file's metadata")
+	ExportCmd.Flags().String("description", "", "Catalog description recorded in the file's metadata")
+}
+
+// runExportCommand writes the selected groups as an import file or Brewfile to stdout or a file
+func runExportCommand(cmd *cobra.Command, args []string) error {
+	output := palantir.GetGlobalOutputHandler()
+
+	format, _ := cmd.Flags().GetString("format")
+	if format != formatYAML && format != formatBrewfile {
+		return errors.NewValidationError(constants.OpConfig, "format",
+			fmt.Errorf("unknown format '%s', use yaml or brewfile", format))
+	}
+
+	opts := config.ExportOptions{}
+	opts.Groups, _ = cmd.Flags().GetStringSlice("groups")
+	opts.Groups = append(opts.Groups, args...)
+	opts.WithSources, _ = cmd.Flags().GetBool("with-sources")
+	if opts.WithSources && format == formatBrewfile {
+		return errors.NewValidationError(constants.OpConfig, "format",
+			fmt.Errorf("--with-sources is not supported with --format brewfile, Brewfiles only list Homebrew packages"))
+	}
+	opts.Metadata.Name, _ = cmd.Flags().GetString("name")
+	opts.Metadata.Description, _ = cmd.Flags().GetString("description")
+	if opts.Metadata.Name != "" || opts.Metadata.Description != "" {
+		opts.Metadata.ExportedAt = time.Now().UTC().Format(time.RFC3339)
+		opts.Metadata.AnvilVersion = version.Version()
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return errors.NewConfigurationError(constants.OpConfig, "load-config", err)
+	}
+
+	catalog, skipped, err := config.ExportCatalog(cfg, opts)
+	if err != nil {
+		return errors.NewConfigurationError(constants.OpConfig, "export", err)
+	}
+
+	var data []byte
+	summary := fmt.Sprintf("Exported %d groups and %d sources", len(catalog.Groups), len(catalog.Sources))
+	if format == formatBrewfile {
+		brewfile, brewSkipped := catalogBrewfile(catalog, cfg.Sources, func(name string) bool {
+			return cfg.Brew.IsCask(name, brew.IsCask)
+		})
+		skipped = append(skipped, brewSkipped...)
+		data = brewfile.Render(brewfileHeader(opts.Metadata)...)
+		summary = fmt.Sprintf("Exported %d taps, %d formulae and %d casks", len(brewfile.Taps), len(brewfile.Formulae), len(brewfile.Casks))
+	} else if data, err = yaml.Marshal(catalog); err != nil {
+		return errors.NewConfigurationError(constants.OpConfig, "export", err)
+	}
+
+	outputPath, _ := cmd.Flags().GetString("output")
+	if outputPath == "" {
+		// Keep stdout a valid import file, skipped entries are reported on stderr
+		fmt.Print(string(data))
+		for _, skip := range skipped {
+			fmt.Fprintf(os.Stderr, "skipped %s: %s\n", skip.Key, skip.Reason)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, data, constants.FilePerm); err != nil {
+		return errors.NewFileSystemError(constants.OpConfig, "export", err)
+	}
+
+	for _, skip := range skipped {
+		output.PrintWarning("Skipped %s: %s", skip.Key, skip.Reason)
+	}
+	output.PrintSuccess(fmt.Sprintf("%s to %s", summary, outputPath))
+	if format == formatBrewfile {
+		output.PrintInfo("Install it with 'brew bundle --file %s' or 'anvil config import %s'", outputPath, outputPath)
+	} else {
+		output.PrintInfo("Share it with 'anvil config import %s'", outputPath)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+// Import file formats
+const (
+	formatYAML     = "yaml"
+	formatBrewfile = "brewfile"
+)
+
+// defaultBrewfileGroup is the group a Brewfile's packages are imported into
+const defaultBrewfileGroup = "brewfile"
+
+// resolveFormat returns the import format from the --format flag, or detects it from the
+// file name when the flag is not given: Brewfile, *.brewfile and Brewfile.* are Brewfiles.
+func resolveFormat(flagFormat, source string) (string, error) {
+	switch strings.ToLower(flagFormat) {
+	case formatYAML:
+		return formatYAML, nil
+	case formatBrewfile:
+		return formatBrewfile, nil
+	case "":
+		if isBrewfileName(sourceBaseName(source)) {
+			return formatBrewfile, nil
+		}
+		return formatYAML, nil
+	default:
+		return "", fmt.Errorf("unknown format '%s', use yaml or brewfile", flagFormat)
+	}
+}
+
+// isBrewfileName reports whether a file name follows Homebrew Bundle naming
+func isBrewfileName(name string) bool {
+	lower := strings.ToLower(name)
+	return lower == "brewfile" || strings.HasSuffix(lower, ".brewfile") || strings.HasPrefix(lower, "brewfile.")
+}
+
+// sourceBaseName returns the file name of a local, URL or git import source
+func sourceBaseName(source string) string {
+	if isGitSource(source) {
+		if src, err := parseGitSource(source); err == nil {
+			return path.Base(src.Path)
+		}
+		return ""
+	}
+	if isRemoteSource(source) {
+		if parsed, err := url.Parse(source); err == nil {
+			return path.Base(parsed.Path)
+		}
+		return ""
+	}
+	return filepath.Base(source)
+}
+
+// originFormat returns the format an origin was imported with, YAML unless recorded otherwise
+func originFormat(origin config.ImportOrigin) string {
+	if origin.Format == "" {
+		return formatYAML
+	}
+	return origin.Format
+}
+
+// originBrewfileGroup returns the group name a Brewfile origin was imported as
+func originBrewfileGroup(origin config.ImportOrigin) string {
+	names := make([]string, 0, len(origin.Groups))
+	for name := range origin.Groups {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return defaultBrewfileGroup
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// parseBrewfileImport converts a Brewfile into an import with a single group holding its
+// formulae and casks, and its taps so they are tapped before installing the group. Casks
+// the index would not detect as casks get a brew_options type, so they are installed and
+// exported as casks.
+func parseBrewfileImport(filePath, groupName string) (*config.ImportConfig, []brew.BrewfileNote, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Brewfile: %w", err)
+	}
+
+	brewfile, err := brew.ParseBrewfile(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	importConfig := &config.ImportConfig{Groups: make(config.AnvilGroups)}
+	if packages := brewfile.Packages(); len(packages) > 0 {
+		importConfig.Groups[groupName] = packages
+	}
+	for _, tap := range brewfile.Taps {
+		importConfig.Taps = append(importConfig.Taps, config.BrewTap{Name: tap.Name, URL: tap.URL})
+	}
+	for name, packageType := range brewfile.PackageTypes(brew.CurrentIndex()) {
+		if importConfig.Brew == nil {
+			importConfig.Brew = make(map[string]config.ImportBrewOptions)
+		}
+		importConfig.Brew[name] = config.ImportBrewOptions{Type: packageType}
+	}
+
+	return importConfig, brewfile.Notes, nil
+}
@@ -0,0 +1,220 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		flag     string
+		source   string
+		expected string
+	}{
+		{"", "./team.yaml", formatYAML},
+		{"", "./Brewfile", formatBrewfile},
+		{"", "/home/me/work.Brewfile", formatBrewfile},
+		{"", "Brewfile.lock", formatBrewfile},
+		{"", "https://example.com/dotfiles/Brewfile?raw=1", formatBrewfile},
+		{"", "github:acme/dotfiles/mac/Brewfile@main", formatBrewfile},
+		{"", "git+ssh://git@git.example.com/acme/dotfiles.git#Brewfile", formatBrewfile},
+		{"brewfile", "./packages.txt", formatBrewfile},
+		{"YAML", "./Brewfile", formatYAML},
+	}
+
+	for _, tt := range tests {
+		format, err := resolveFormat(tt.flag, tt.source)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.source, err)
+		}
+		if format != tt.expected {
+			t.Errorf("resolveFormat(%q, %q) = %q, expected %q", tt.flag, tt.source, format, tt.expected)
+		}
+	}
+
+	if _, err := resolveFormat("toml", "./team.toml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestParseBrewfileImport(t *testing.T) {
+	brewfile := filepath.Join(t.TempDir(), "Brewfile")
+	content := `tap "hashicorp/tap"
+tap "company/private", "https://git.example.com/company/homebrew-private.git"
+brew "git"
+brew "hashicorp/tap/terraform"
+cask "iterm2"
+mas "Xcode", id: 497799835
+`
+	if err := os.WriteFile(brewfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	importData, notes, err := parseBrewfileImport(brewfile, "workstation")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedGroups := config.AnvilGroups{"workstation": {"git", "hashicorp/tap/terraform", "iterm2"}}
+	if !reflect.DeepEqual(importData.Groups, expectedGroups) {
+		t.Errorf("Expected groups %v, got %v", expectedGroups, importData.Groups)
+	}
+	expectedTaps := []config.BrewTap{
+		{Name: "hashicorp/tap"},
+		{Name: "company/private", URL: "https://git.example.com/company/homebrew-private.git"},
+	}
+	if !reflect.DeepEqual(importData.Taps, expectedTaps) {
+		t.Errorf("Expected taps %v, got %v", expectedTaps, importData.Taps)
+	}
+	if len(notes) != 1 || notes[0].Line != 6 {
+		t.Errorf("Expected the mas line to be reported, got %+v", notes)
+	}
+
+	if err := validateImportConfig(importData); err != nil {
+		t.Errorf("Expected converted Brewfile to match the import schema: %v", err)
+	}
+}
+
+func TestBrewfileRoundTripKeepsCasks(t *testing.T) {
+	// docker is both a formula and a cask; private-app is unknown to the index
+	index, err := brew.ParseHomebrewAPI([]byte(`[{"name": "git"}, {"name": "docker"}]`), []byte(`[{"token": "docker"}, {"token": "iterm2"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	brew.UseIndex(index)
+	t.Cleanup(func() { brew.UseIndex(nil) })
+
+	content := "brew \"git\"\nbrew \"jq\"\ncask \"docker\"\ncask \"iterm2\"\ncask \"private-app\"\n"
+	brewfilePath := filepath.Join(t.TempDir(), "Brewfile")
+	if err := os.WriteFile(brewfilePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	importData, _, err := parseBrewfileImport(brewfilePath, "workstation")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedTypes := map[string]config.ImportBrewOptions{"docker": {Type: "cask"}, "private-app": {Type: "cask"}}
+	if !reflect.DeepEqual(importData.Brew, expectedTypes) {
+		t.Errorf("Expected brew_options %v, got %v", expectedTypes, importData.Brew)
+	}
+	if err := validateImportConfig(importData); err != nil {
+		t.Errorf("Expected brew_options to match the import schema: %v", err)
+	}
+
+	// Import into settings that already set args for docker, then export as a Brewfile
+	cfg := &config.AnvilConfig{Brew: config.AnvilBrewOptions{"docker": {Args: []string{"--no-quarantine"}}}}
+	applyGroupsAndSections(t, importData, cfg)
+	if options := cfg.Brew["docker"]; options.Type != "cask" || len(options.Args) != 1 {
+		t.Errorf("Expected docker to become a cask and keep its args, got %+v", options)
+	}
+
+	catalog, _, err := config.ExportCatalog(cfg, config.ExportOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(catalog.Brew, expectedTypes) {
+		t.Errorf("Expected exported brew_options %v, got %v", expectedTypes, catalog.Brew)
+	}
+	detect := func(name string) bool {
+		kind, _ := index.Kind(name)
+		return kind == brew.PackageTypeCask
+	}
+	exported := brew.NewBrewfile(catalog.Groups["workstation"], nil, func(name string) bool {
+		return cfg.Brew.IsCask(name, detect)
+	})
+	roundTrip, err := brew.ParseBrewfile(exported.Render())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(roundTrip.Formulae, []string{"git", "jq"}) || !reflect.DeepEqual(roundTrip.Casks, []string{"docker", "iterm2", "private-app"}) {
+		t.Errorf("Expected the Brewfile to round-trip, got formulae %v and casks %v", roundTrip.Formulae, roundTrip.Casks)
+	}
+}
+
+// applyGroupsAndSections imports the groups and every optional section into cfg
+func applyGroupsAndSections(t *testing.T, importData *config.ImportConfig, cfg *config.AnvilConfig) {
+	t.Helper()
+	if cfg.Groups == nil {
+		cfg.Groups = make(config.AnvilGroups)
+	}
+	for name, tools := range importData.Groups {
+		cfg.Groups[name] = tools
+	}
+	sections, err := planSections(importData, cfg, StrategyFail)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, section := range sections {
+		section.apply(cfg)
+	}
+}
+
+func TestPlanSectionsTaps(t *testing.T) {
+	importData := &config.ImportConfig{Taps: []config.BrewTap{
+		{Name: "hashicorp/tap"},
+		{Name: "company/private", URL: "https://new.example.com/tap.git"},
+	}}
+	currentConfig := &config.AnvilConfig{Taps: []config.BrewTap{
+		{Name: "company/private", URL: "https://old.example.com/tap.git"},
+	}}
+
+	if _, err := planSections(importData, currentConfig, StrategyFail); err == nil || !strings.Contains(err.Error(), "taps.company/private") {
+		t.Fatalf("Expected conflict on taps.company/private, got %v", err)
+	}
+
+	sections, err := planSections(importData, currentConfig, StrategyOverwrite)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sections) != 1 || sections[0].Name != "taps" || sections[0].Changes != 2 {
+		t.Fatalf("Expected a taps section with 2 changes, got %+v", sections)
+	}
+	if sections[0].Lines[1] != "+ hashicorp/tap" {
+		t.Errorf("Expected tap without URL to be shown by name, got %v", sections[0].Lines)
+	}
+
+	sections[0].apply(currentConfig)
+	expected := []config.BrewTap{
+		{Name: "company/private", URL: "https://new.example.com/tap.git"},
+		{Name: "hashicorp/tap"},
+	}
+	if !reflect.DeepEqual(currentConfig.Taps, expected) {
+		t.Errorf("Expected taps %v, got %v", expected, currentConfig.Taps)
+	}
+}
+
+func TestOriginBrewfileGroup(t *testing.T) {
+	if group := originBrewfileGroup(config.ImportOrigin{}); group != defaultBrewfileGroup {
+		t.Errorf("Expected default group, got %q", group)
+	}
+	origin := config.ImportOrigin{Format: formatBrewfile, Groups: map[string]string{"workstation": "workstation-imported"}}
+	if group := originBrewfileGroup(origin); group != "workstation" {
+		t.Errorf("Expected the recorded file group, got %q", group)
+	}
+	if format := originFormat(config.ImportOrigin{}); format != formatYAML {
+		t.Errorf("Expected origins without format to be YAML, got %q", format)
+	}
+}
@@ -23,19 +23,30 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/0xjuanma/anvil/internal/config"
 	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/network"
 	"gopkg.in/yaml.v2"
 )
 
-// fetchFile downloads a file from URL or copies from local path to a temporary file.
-func fetchFile(sourcePath string) (string, func(), error) {
+// fetchFile downloads a file from a URL or git repository, or copies from local path to a temporary file.
+func fetchFile(sourcePath string, auth sourceAuth) (string, func(), error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if isGitSource(sourcePath) {
+		source, err := parseGitSource(sourcePath)
+		if err != nil {
+			return "", nil, err
+		}
+		return fetchGitFile(ctx, source, auth)
+	}
+
 	if isURL(sourcePath) {
-		return fetchFromURL(ctx, sourcePath)
+		return fetchFromURL(ctx, sourcePath, auth)
 	}
 
 	// Handle local file
@@ -48,19 +59,52 @@ func isURL(str string) bool {
 	return err == nil && u.Scheme != "" && u.Host != ""
 }
 
-// fetchFromURL downloads file from URL to a temporary file.
-func fetchFromURL(ctx context.Context, fileURL string) (string, func(), error) {
-	// Create HTTP request with context
+// isRemoteSource checks if an import source is fetched from a URL or git repository.
+func isRemoteSource(source string) bool {
+	return isGitSource(source) || isURL(source)
+}
+
+// newSourceRequest creates a GET request for an import file. The GitHub token is only
+// sent over HTTPS to GitHub hosts so it never leaks to third-party servers.
+func newSourceRequest(ctx context.Context, fileURL string, auth sourceAuth) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set user agent
 	req.Header.Set("User-Agent", "anvil-cli/1.0")
 
+	if auth.Token != "" && req.URL.Scheme == "https" && isGitHubHost(req.URL.Hostname()) {
+		req.Header.Set("Authorization", "token "+auth.Token)
+	}
+
+	return req, nil
+}
+
+// isGitHubHost reports whether a host serves GitHub content.
+func isGitHubHost(host string) bool {
+	switch strings.ToLower(host) {
+	case githubHost, "api.github.com", "raw.githubusercontent.com", "gist.githubusercontent.com":
+		return true
+	}
+	return false
+}
+
+// fetchFromURL downloads file from URL to a temporary file.
+func fetchFromURL(ctx context.Context, fileURL string, auth sourceAuth) (string, func(), error) {
+	// Create HTTP request with context
+	req, err := newSourceRequest(ctx, fileURL, auth)
+	if err != nil {
+		return "", nil, err
+	}
+
 	// Execute request
-	resp, err := http.DefaultClient.Do(req)
+	client, err := network.Client()
+	if err != nil {
+		return "", nil, err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to download file: %w", err)
 	}
@@ -127,23 +171,47 @@ func fetchFromLocal(filePath string) (string, func(), error) {
 	return tempFile.Name(), cleanup, nil
 }
 
-// parseImportFile parses the import file and extracts only group data.
-func parseImportFile(filePath string) (*ImportConfig, error) {
+// parseImportFile parses the import file and extracts the importable sections.
+// Invalid groups are skipped, while malformed optional sections are reported as errors.
+func parseImportFile(filePath string) (*config.ImportConfig, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read import file: %w", err)
 	}
 
-	// Parse as generic map first to extract only groups
+	// Parse as generic map first to extract groups leniently
 	var rawData map[string]interface{}
 	if err := yaml.Unmarshal(data, &rawData); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
-	// Extract only groups section
+	// Parse the optional sections with their settings types
+	var sections struct {
+		Metadata config.ImportMetadata       `yaml:"metadata"`
+		Sources  map[string]config.AppSource `yaml:"sources"`
+		Configs  map[string]string           `yaml:"configs"`
+		Tools    config.ImportTools          `yaml:"tools"`
+		Taps     []config.BrewTap            `yaml:"taps"`
+		Hooks    config.AnvilHooks           `yaml:"hooks"`
+	}
+	if err := yaml.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("invalid metadata, sources, configs, tools, taps or hooks section: %w", err)
+	}
+
+	importConfig := &config.ImportConfig{
+		Metadata: sections.Metadata,
+		Groups:   make(config.AnvilGroups),
+		Sources:  sections.Sources,
+		Configs:  sections.Configs,
+		Tools:    sections.Tools,
+		Taps:     sections.Taps,
+		Hooks:    sections.Hooks,
+	}
+
+	// Extract groups section
 	groupsData, exists := rawData["groups"]
 	if !exists {
-		return &ImportConfig{Groups: make(map[string][]string)}, nil
+		return importConfig, nil
 	}
 
 	// Convert to proper structure
@@ -152,10 +220,6 @@ func parseImportFile(filePath string) (*ImportConfig, error) {
 		return nil, fmt.Errorf("groups section has invalid format")
 	}
 
-	importConfig := &ImportConfig{
-		Groups: make(map[string][]string),
-	}
-
 	for groupName, groupTools := range groupsMap {
 		groupNameStr, ok := groupName.(string)
 		if !ok {
@@ -0,0 +1,254 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/github"
+	"github.com/0xjuanma/anvil/internal/network"
+)
+
+// Git import source prefixes
+const (
+	githubShorthandPrefix = "github:"
+	gitURLPrefix          = "git+"
+	githubHost            = "github.com"
+)
+
+// errGitFileNotFound is returned when the requested file does not exist at the fetched ref
+var errGitFileNotFound = errors.New("file not found in repository")
+
+// sourceAuth holds the credentials used to fetch private import files.
+type sourceAuth struct {
+	Token      string // GitHub token, only sent to GitHub hosts
+	SSHKeyPath string // SSH private key used for git over SSH
+}
+
+// gitSource is an import file stored in a git repository.
+type gitSource struct {
+	Remote string // Repository URL, or "owner/repo" for GitHub shorthands
+	Host   string
+	Scheme string // https, ssh or file
+	Path   string // File path inside the repository
+	Ref    string // Branch, tag or commit, empty for the default branch
+}
+
+// authFromConfig returns the GitHub token and SSH key configured in settings.
+// The token is read from github.token_env_var first, then from github.token.
+func authFromConfig(cfg *config.AnvilConfig) sourceAuth {
+	return sourceAuth{SSHKeyPath: cfg.Git.SSHKeyPath, Token: cfg.GitHub.AccessToken()}
+}
+
+// isGitSource checks if the given string is a git import source.
+func isGitSource(source string) bool {
+	return strings.HasPrefix(source, githubShorthandPrefix) || strings.HasPrefix(source, gitURLPrefix)
+}
+
+// parseGitSource parses "github:owner/repo/path/to/file.yaml@ref" shorthands and
+// "git+ssh://host/repo.git#path/to/file.yaml@ref" URLs (also git+https and git+file).
+// The ref is optional and defaults to the repository's default branch.
+func parseGitSource(source string) (*gitSource, error) {
+	if shorthand, ok := strings.CutPrefix(source, githubShorthandPrefix); ok {
+		location, ref, err := splitRef(source, shorthand)
+		if err != nil {
+			return nil, err
+		}
+		parts := strings.SplitN(location, "/", 3)
+		if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid GitHub source %q (expected github:owner/repo/path/to/file.yaml[@ref])", source)
+		}
+		return &gitSource{
+			Remote: parts[0] + "/" + parts[1],
+			Host:   githubHost,
+			Scheme: "https",
+			Path:   parts[2],
+			Ref:    ref,
+		}, nil
+	}
+
+	remote, fragment, _ := strings.Cut(strings.TrimPrefix(source, gitURLPrefix), "#")
+	location, ref, err := splitRef(source, fragment)
+	if err != nil {
+		return nil, err
+	}
+	if location == "" {
+		return nil, fmt.Errorf("invalid git source %q (expected git+ssh://host/repo.git#path/to/file.yaml[@ref])", source)
+	}
+
+	parsed, err := url.Parse(remote)
+	if err != nil {
+		return nil, fmt.Errorf("invalid git source %q: %w", source, err)
+	}
+	switch parsed.Scheme {
+	case "ssh", "https", "http", "file":
+	default:
+		return nil, fmt.Errorf("unsupported git source scheme %q (expected git+ssh, git+https or git+file)", parsed.Scheme)
+	}
+
+	return &gitSource{
+		Remote: remote,
+		Host:   parsed.Hostname(),
+		Scheme: parsed.Scheme,
+		Path:   strings.TrimPrefix(location, "/"),
+		Ref:    ref,
+	}, nil
+}
+
+// splitRef splits an optional "@ref" suffix from a repository file location. Refs
+// starting with "-" are rejected so git can never read them as options.
+func splitRef(source, location string) (string, string, error) {
+	index := strings.LastIndex(location, "@")
+	if index < 0 || index < strings.LastIndex(location, "/") {
+		return location, "", nil
+	}
+	ref := location[index+1:]
+	if strings.HasPrefix(ref, "-") {
+		return "", "", fmt.Errorf("invalid ref %q in git source %q: refs cannot start with '-'", ref, source)
+	}
+	return location[:index], ref, nil
+}
+
+// String returns the source in the form it was given in.
+func (s *gitSource) String() string {
+	source := gitURLPrefix + s.Remote + "#" + s.Path
+	if !strings.Contains(s.Remote, "://") {
+		source = githubShorthandPrefix + s.Remote + "/" + s.Path
+	}
+	if s.Ref != "" {
+		source += "@" + s.Ref
+	}
+	return source
+}
+
+// cloneURL returns the URL git fetches from. Without a token, GitHub HTTPS remotes are
+// fetched over SSH when a key is configured, like 'anvil config pull'. The token is never
+// embedded in the URL; see authEnv.
+func (s *gitSource) cloneURL(auth sourceAuth) string {
+	if s.Host == githubHost && s.Scheme == "https" {
+		sshKeyPath := auth.SSHKeyPath
+		if auth.Token != "" {
+			sshKeyPath = ""
+		}
+		return github.BuildAuthenticatedURL(s.Remote, "", sshKeyPath)
+	}
+	return s.Remote
+}
+
+// authEnv returns the environment that makes git send the token as an HTTP header to
+// GitHub itself, so it never appears in URLs, process arguments or git output.
+func (s *gitSource) authEnv(auth sourceAuth) []string {
+	if auth.Token == "" || s.Host != githubHost || s.Scheme != "https" {
+		return nil
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + auth.Token))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.https://" + githubHost + "/.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + credentials,
+	}
+}
+
+// fetchGitFile fetches a single file from a git repository into a temporary file.
+func fetchGitFile(ctx context.Context, source *gitSource, auth sourceAuth) (string, func(), error) {
+	repoDir, err := os.MkdirTemp("", "anvil-import-repo-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(repoDir)
+
+	ref := source.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	if _, err := runGit(ctx, auth, nil, repoDir, "init", "--quiet"); err != nil {
+		return "", nil, err
+	}
+	if _, err := runGit(ctx, auth, source.authEnv(auth), repoDir, "fetch", "--quiet", "--depth", "1", "--", source.cloneURL(auth), ref); err != nil {
+		return "", nil, fmt.Errorf("failed to fetch %s from %s: %w", ref, source.Remote, err)
+	}
+
+	content, err := runGit(ctx, auth, nil, repoDir, "show", "FETCH_HEAD:"+source.Path)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") || strings.Contains(err.Error(), "exists on disk, but not in") {
+			return "", nil, fmt.Errorf("%s at %s: %w", source.Path, ref, errGitFileNotFound)
+		}
+		return "", nil, fmt.Errorf("failed to read %s: %w", source.Path, err)
+	}
+
+	tempFile, err := os.CreateTemp("", "anvil-import-*.yaml")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	_, err = tempFile.Write(content)
+	tempFile.Close()
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return "", nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	cleanup := func() {
+		os.Remove(tempFile.Name())
+	}
+
+	return tempFile.Name(), cleanup, nil
+}
+
+// runGit runs a non-interactive git command with the extra environment and returns its
+// standard output. The configured SSH key is offered to SSH remotes, unknown hosts are
+// only accepted on first use and the token is redacted from errors.
+func runGit(ctx context.Context, auth sourceAuth, env []string, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, constants.GitCommand, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), constants.GitNonInteractiveEnvVars()...)
+	cmd.Env = append(cmd.Env, network.Environment(network.Settings())...)
+	cmd.Env = append(cmd.Env, env...)
+	if auth.SSHKeyPath != "" {
+		if _, err := os.Stat(auth.SSHKeyPath); err == nil {
+			keyPath := "'" + strings.ReplaceAll(auth.SSHKeyPath, "'", `'\''`) + "'"
+			cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes -o StrictHostKeyChecking=accept-new -i "+keyPath)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if auth.Token != "" {
+			message = strings.ReplaceAll(message, auth.Token, "***")
+		}
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, errors.New(message)
+	}
+
+	return stdout.Bytes(), nil
+}
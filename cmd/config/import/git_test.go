@@ -0,0 +1,212 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected *gitSource
+		wantErr  bool
+	}{
+		{
+			source:   "github:acme/catalog/teams/platform.yaml",
+			expected: &gitSource{Remote: "acme/catalog", Host: "github.com", Scheme: "https", Path: "teams/platform.yaml"},
+		},
+		{
+			source:   "github:acme/catalog/platform.yaml@v1.2.0",
+			expected: &gitSource{Remote: "acme/catalog", Host: "github.com", Scheme: "https", Path: "platform.yaml", Ref: "v1.2.0"},
+		},
+		{
+			source:   "git+ssh://git@git.example.com/acme/catalog.git#teams/platform.yaml@main",
+			expected: &gitSource{Remote: "ssh://git@git.example.com/acme/catalog.git", Host: "git.example.com", Scheme: "ssh", Path: "teams/platform.yaml", Ref: "main"},
+		},
+		{
+			source:   "git+https://github.com/acme/catalog.git#platform.yaml",
+			expected: &gitSource{Remote: "https://github.com/acme/catalog.git", Host: "github.com", Scheme: "https", Path: "platform.yaml"},
+		},
+		{source: "github:acme/catalog", wantErr: true},
+		{source: "git+ssh://git@git.example.com/acme/catalog.git", wantErr: true},
+		{source: "git+ftp://example.com/catalog.git#platform.yaml", wantErr: true},
+		{source: "github:acme/catalog/platform.yaml@--upload-pack=evil", wantErr: true},
+		{source: "git+ssh://git@git.example.com/acme/catalog.git#platform.yaml@-oProxyCommand=x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := parseGitSource(tt.source)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+			if got.String() != tt.source {
+				t.Errorf("Expected String() to round-trip to %q, got %q", tt.source, got.String())
+			}
+		})
+	}
+}
+
+func TestSourceAuthentication(t *testing.T) {
+	auth := sourceAuth{Token: "secret-token"}
+
+	t.Run("token is sent as a header to GitHub clones only", func(t *testing.T) {
+		github, _ := parseGitSource("github:acme/catalog/platform.yaml")
+		if url := github.cloneURL(auth); url != "https://github.com/acme/catalog.git" {
+			t.Errorf("Expected the token to stay out of the clone URL, got %s", url)
+		}
+		env := strings.Join(github.authEnv(auth), "\n")
+		if !strings.Contains(env, "GIT_CONFIG_KEY_0=http.https://github.com/.extraHeader") ||
+			!strings.Contains(env, "Authorization: Basic "+base64.StdEncoding.EncodeToString([]byte("x-access-token:secret-token"))) {
+			t.Errorf("Expected an authorization header for GitHub, got %q", env)
+		}
+
+		other, _ := parseGitSource("git+https://git.example.com/acme/catalog.git#platform.yaml")
+		if url := other.cloneURL(auth); strings.Contains(url, "secret-token") {
+			t.Errorf("Token leaked to third-party host: %s", url)
+		}
+		if env := other.authEnv(auth); env != nil {
+			t.Errorf("Token leaked to third-party host: %q", env)
+		}
+	})
+
+	t.Run("token is sent to GitHub hosts over https only", func(t *testing.T) {
+		tests := []struct {
+			url      string
+			expected string
+		}{
+			{"https://raw.githubusercontent.com/acme/catalog/main/platform.yaml", "token secret-token"},
+			{"http://raw.githubusercontent.com/acme/catalog/main/platform.yaml", ""},
+			{"https://example.com/platform.yaml", ""},
+		}
+		for _, tt := range tests {
+			req, err := newSourceRequest(context.Background(), tt.url, auth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.expected {
+				t.Errorf("%s: expected Authorization %q, got %q", tt.url, tt.expected, got)
+			}
+		}
+	})
+}
+
+func TestSignatureLocation(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/team.yaml":                   "https://example.com/team.yaml.minisig",
+		"github:acme/catalog/team.yaml@v1":                "github:acme/catalog/team.yaml.minisig@v1",
+		"git+ssh://git@example.com/catalog.git#team.yaml": "git+ssh://git@example.com/catalog.git#team.yaml.minisig",
+	}
+
+	for source, expected := range tests {
+		if got := signatureLocation(source); got != expected {
+			t.Errorf("signatureLocation(%q) = %q, expected %q", source, got, expected)
+		}
+	}
+}
+
+func TestFetchGitFile(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repoDir := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(repoDir, "teams"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	gitRun("init", "--quiet")
+	if err := os.WriteFile(filepath.Join(repoDir, "teams", "platform.yaml"), []byte("groups:\n  platform: [git]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun("add", ".")
+	gitRun("commit", "--quiet", "-m", "v1")
+	gitRun("tag", "v1")
+	if err := os.WriteFile(filepath.Join(repoDir, "teams", "platform.yaml"), []byte("groups:\n  platform: [git, jq]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun("commit", "--quiet", "-am", "v2")
+
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+		notFound bool
+	}{
+		{"default branch", "git+file://" + repoDir + "#teams/platform.yaml", "groups:\n  platform: [git, jq]\n", false},
+		{"tag", "git+file://" + repoDir + "#teams/platform.yaml@v1", "groups:\n  platform: [git]\n", false},
+		{"missing file", "git+file://" + repoDir + "#teams/missing.yaml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, cleanup, err := fetchFile(tt.source, sourceAuth{})
+			if tt.notFound {
+				if !errors.Is(err, errGitFileNotFound) {
+					t.Errorf("Expected file not found, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer cleanup()
+
+			data, err := os.ReadFile(tempFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(data))
+			}
+		})
+	}
+
+	t.Run("local repositories are trusted", func(t *testing.T) {
+		if _, _, remote := sourceHost("git+file://" + repoDir + "#teams/platform.yaml"); remote {
+			t.Error("Expected git+file source to be treated as local")
+		}
+	})
+}
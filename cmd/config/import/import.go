@@ -20,134 +20,393 @@ package importcmd
 
 import (
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
+	"github.com/0xjuanma/anvil/internal/brew"
 	"github.com/0xjuanma/anvil/internal/config"
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/0xjuanma/anvil/internal/errors"
 	"github.com/0xjuanma/anvil/internal/terminal/charm"
 	"github.com/0xjuanma/palantir"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
 var ImportCmd = &cobra.Command{
 	Use:   "import [file-or-url]",
 	Short: "Import groups from a local file or URL",
-	Long:  "Import tool groups from a local YAML file or remote URL into your anvil configuration",
-	Args:  cobra.ExactArgs(1),
+	Long:  "Import tool groups from a local YAML file or Brewfile, remote URL or git repository into your anvil configuration",
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		refresh, _ := cmd.Flags().GetBool("refresh")
+		if refresh {
+			if len(args) > 0 {
+				return errors.NewValidationError(constants.OpConfig, "refresh",
+					fmt.Errorf("--refresh re-fetches all recorded origins and does not take a file or URL"))
+			}
+			if cmd.Flags().Changed("sha256") || cmd.Flags().Changed("signature") ||
+				cmd.Flags().Changed("format") || cmd.Flags().Changed("group") {
+				return errors.NewValidationError(constants.OpConfig, "refresh",
+					fmt.Errorf("--sha256, --signature, --format and --group apply to a single import and cannot be used with --refresh"))
+			}
+			return runRefreshCommand(cmd)
+		}
+		if len(args) == 0 {
+			return errors.NewValidationError(constants.OpConfig, "import",
+				fmt.Errorf("a file or URL to import is required (or use --refresh)"))
+		}
 		return runImportCommand(cmd, args[0])
 	},
+	Example: `  anvil config import ./team-groups.yaml                    # Fail if any group already exists
+  anvil config import ./team-groups.yaml --strategy merge   # Add new tools to existing groups
+  anvil config import ./team-groups.yaml --strategy rename  # Import conflicting groups as <name>-imported
+  anvil config import https://example.com/team.yaml --sha256 <digest>  # Only import this exact content
+  anvil config import github:acme/catalog/team.yaml@v1.2.0  # Import from a (private) GitHub repository
+  anvil config import git+ssh://git@git.example.com/acme/catalog.git#team.yaml  # Import from any git repository
+  anvil config import ./Brewfile --group workstation        # Import a Homebrew Bundle file as a group
+  anvil config import --refresh                             # Re-fetch all previously imported files`,
 }
 
-// ImportConfig represents the structure for importing configurations.
-type ImportConfig struct {
-	Groups map[string][]string `yaml:"groups"`
+func init() {
+	ImportCmd.Flags().String("strategy", "", "How to handle groups that already exist: skip, overwrite, merge or rename")
+	ImportCmd.Flags().Bool("refresh", false, "Re-fetch every recorded import origin and apply upstream changes")
+	ImportCmd.Flags().String("sha256", "", "Expected SHA-256 digest of the import file, the import fails on mismatch")
+	ImportCmd.Flags().String("signature", "", "Path or URL of a detached signature (defaults to <file-or-url>.minisig)")
+	ImportCmd.Flags().String("format", "", "Import file format: yaml or brewfile (default: detected from the file name)")
+	ImportCmd.Flags().String("group", defaultBrewfileGroup, "Group that receives the packages of an imported Brewfile")
 }
 
 // runImportCommand executes the group import process.
 func runImportCommand(cmd *cobra.Command, importPath string) error {
 	output := palantir.GetGlobalOutputHandler()
-	output.PrintHeader("Import Groups from File")
 
-	// Stage 1: Fetch and validate source file
-	output.PrintStage("Stage 1: Fetching source file...")
-	spinner := charm.NewCircleSpinner("Fetching import file")
-	spinner.Start()
-	tempFile, cleanup, err := fetchFile(importPath)
+	strategyFlag, _ := cmd.Flags().GetString("strategy")
+	strategy, err := parseStrategy(strategyFlag)
 	if err != nil {
-		spinner.Error("Failed to fetch source file")
-		return errors.NewFileSystemError(constants.OpConfig, "fetch-file", err)
+		return errors.NewValidationError(constants.OpConfig, "strategy", err)
 	}
-	defer cleanup()
-	spinner.Success("Source file fetched successfully")
 
-	// Stage 2: Parse and validate import data
-	output.PrintStage("Parsing import file...")
-	importData, err := parseImportFile(tempFile)
-	if err != nil {
-		return errors.NewConfigurationError(constants.OpConfig, "parse-import", err)
+	var loadOpts loadOptions
+	loadOpts.Trust.SHA256, _ = cmd.Flags().GetString("sha256")
+	loadOpts.Trust.Signature, _ = cmd.Flags().GetString("signature")
+	loadOpts.Group, _ = cmd.Flags().GetString("group")
+	formatFlag, _ := cmd.Flags().GetString("format")
+	if loadOpts.Format, err = resolveFormat(formatFlag, importPath); err != nil {
+		return errors.NewValidationError(constants.OpConfig, "format", err)
 	}
-
-	if len(importData.Groups) == 0 {
-		return errors.NewConfigurationError(constants.OpConfig, "no-groups",
-			fmt.Errorf("no valid groups found in import file"))
+	if cmd.Flags().Changed("group") && loadOpts.Format != formatBrewfile {
+		return errors.NewValidationError(constants.OpConfig, "group",
+			fmt.Errorf("--group only applies to Brewfile imports"))
 	}
-	output.PrintSuccess("Import file parsed successfully")
 
-	// Stage 3: Validate group structure
-	output.PrintStage("Validating group structure...")
-	if err := validateImportGroups(importData.Groups); err != nil {
-		return errors.NewConfigurationError(constants.OpConfig, "validate-groups", err)
-	}
-	output.PrintSuccess("Group structure validation passed")
+	output.PrintHeader("Import Groups from File")
 
-	// Stage 4: Check for conflicts with existing groups
-	output.PrintStage("Checking for conflicts...")
 	currentConfig, err := config.LoadConfig()
 	if err != nil {
 		return errors.NewConfigurationError(constants.OpConfig, "load-config", err)
 	}
 
-	conflicts := checkGroupConflicts(importData.Groups, currentConfig.Groups)
-	if len(conflicts) > 0 {
-		return errors.NewConfigurationError(constants.OpConfig, "group-conflicts",
-			fmt.Errorf("groups already exist: %s", strings.Join(conflicts, ", ")))
+	// Stages 1-3: Fetch, verify, parse and validate source file
+	importData, digest, err := loadImportFile(importPath, loadOpts, currentConfig)
+	if err != nil {
+		return err
+	}
+
+	// Stage 4: Check for conflicts with existing settings
+	output.PrintStage("Checking for conflicts...")
+
+	changes, err := planImport(importData.Groups, currentConfig.Groups, strategy)
+	if err != nil {
+		return errors.NewConfigurationError(constants.OpConfig, "group-conflicts", err)
+	}
+	sections, err := planSections(importData, currentConfig, strategy)
+	if err != nil {
+		return errors.NewConfigurationError(constants.OpConfig, "section-conflicts", err)
+	}
+	if conflicts := checkGroupConflicts(importData.Groups, currentConfig.Groups); len(conflicts) > 0 {
+		output.PrintInfo("Resolving %d existing group(s) with strategy '%s': %s", len(conflicts), strategy, strings.Join(conflicts, ", "))
+	} else {
+		output.PrintSuccess("No conflicts detected")
 	}
-	output.PrintSuccess("No conflicts detected")
 
-	// Stage 5: Display import summary
-	output.PrintStage("Preparing import summary...")
-	displayImportSummary(importData.Groups)
+	// Stages 5-6: Display import summary and confirm each section
+	if countChangedGroups(changes) == 0 && len(sections) == 0 {
+		if len(changes) > 0 {
+			displayImportSummary(changes)
+		}
+		output.PrintInfo("Nothing to import, your settings are already up to date")
+		return nil
+	}
 
-	// Stage 6: Confirm import
-	if !output.Confirm("Proceed with importing these groups?") {
+	changes, sections, proceed := reviewImport(changes, sections)
+	changedGroups := countChangedGroups(changes)
+	if !proceed || (changedGroups == 0 && len(sections) == 0) {
 		output.PrintInfo("Import cancelled by user")
 		return nil
 	}
 
-	// Stage 7: Import groups
-	output.PrintStage("Stage 7: Importing groups...")
-	spinner = charm.NewDotsSpinner(fmt.Sprintf("Importing %d groups", len(importData.Groups)))
+	// Stage 7: Apply import
+	output.PrintStage("Stage 7: Importing settings...")
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Importing %d groups and %d sections", changedGroups, len(sections)))
 	spinner.Start()
-	if err := importGroups(currentConfig, importData.Groups); err != nil {
-		spinner.Error("Failed to import groups")
+	recordOrigin(currentConfig, resolveSource(importPath), digest, strategy, changes, true)
+	if index := findOrigin(currentConfig.Imports, resolveSource(importPath)); index >= 0 {
+		if loadOpts.Format == formatBrewfile {
+			currentConfig.Imports[index].Format = formatBrewfile
+		}
+		if loadOpts.Trust.SHA256 != "" {
+			currentConfig.Imports[index].Pinned = strings.ToLower(loadOpts.Trust.SHA256)
+		}
+	}
+	if err := applyImport(currentConfig, changes, sections); err != nil {
+		spinner.Error("Failed to import settings")
 		return errors.NewConfigurationError(constants.OpConfig, "import-groups", err)
 	}
-	spinner.Success(fmt.Sprintf("Successfully imported %d groups", len(importData.Groups)))
+	spinner.Success(fmt.Sprintf("Successfully imported %d groups and %d sections", changedGroups, len(sections)))
 
-	output.PrintInfo("\n✨ Import completed! %d groups have been added to your configuration.", len(importData.Groups))
+	output.PrintInfo("\n✨ Import completed! %d groups and %d sections have been updated in your configuration.", changedGroups, len(sections))
 	return nil
 }
 
-// validateImportGroups validates the structure of imported groups.
-func validateImportGroups(groups map[string][]string) error {
-	if len(groups) == 0 {
-		return fmt.Errorf("no groups found to import")
+// reviewImport shows the group summary and each optional section, asking for confirmation of each.
+// Rejected groups are cleared from the changes and only accepted sections are returned.
+// It reports false if the user cancelled the whole import.
+func reviewImport(changes []GroupChange, sections []ImportSection) ([]GroupChange, []ImportSection, bool) {
+	output := palantir.GetGlobalOutputHandler()
+
+	if countChangedGroups(changes) > 0 {
+		output.PrintStage("Preparing import summary...")
+		displayImportSummary(changes)
+
+		if !output.Confirm("Proceed with importing these groups?") {
+			if len(sections) == 0 {
+				return nil, nil, false
+			}
+			output.PrintInfo("Skipped groups")
+			changes = nil
+		}
+	}
+
+	return changes, reviewSections(sections), true
+}
+
+// runRefreshCommand re-fetches every recorded import origin and applies upstream changes.
+func runRefreshCommand(cmd *cobra.Command) error {
+	output := palantir.GetGlobalOutputHandler()
+
+	strategyFlag, _ := cmd.Flags().GetString("strategy")
+	flagStrategy, err := parseStrategy(strategyFlag)
+	if err != nil {
+		return errors.NewValidationError(constants.OpConfig, "strategy", err)
 	}
 
-	validator := config.NewConfigValidator(nil)
+	output.PrintHeader("Refresh Imported Groups")
 
-	for groupName, tools := range groups {
-		// Validate group name
-		if err := validator.ValidateGroupName(groupName); err != nil {
-			return fmt.Errorf("invalid group name '%s': %w", groupName, err)
+	currentConfig, err := config.LoadConfig()
+	if err != nil {
+		return errors.NewConfigurationError(constants.OpConfig, "load-config", err)
+	}
+
+	if len(currentConfig.Imports) == 0 {
+		output.PrintInfo("No import origins recorded. Import a file with 'anvil config import <file-or-url>' first")
+		return nil
+	}
+
+	var failed []string
+	updated := 0
+	for i := range currentConfig.Imports {
+		origin := currentConfig.Imports[i]
+		output.PrintStage(fmt.Sprintf("Refreshing %s...", origin.Source))
+
+		applied, err := refreshOrigin(currentConfig, origin, flagStrategy)
+		if err != nil {
+			output.PrintError("Failed to refresh %s: %v", origin.Source, err)
+			failed = append(failed, origin.Source)
+			continue
+		}
+		if applied {
+			updated++
 		}
+	}
+
+	if len(failed) > 0 {
+		return errors.NewConfigurationError(constants.OpConfig, "refresh",
+			fmt.Errorf("failed to refresh %d origin(s): %s", len(failed), strings.Join(failed, ", ")))
+	}
 
-		// Validate group is not empty
-		if len(tools) == 0 {
-			return fmt.Errorf("group '%s' cannot be empty", groupName)
+	output.PrintInfo("\n✨ Refresh completed! %d of %d origin(s) updated.", updated, len(currentConfig.Imports))
+	return nil
+}
+
+// refreshOrigin re-fetches a single origin, shows upstream changes and applies them once confirmed.
+// It reports whether any group changes were applied.
+func refreshOrigin(currentConfig *config.AnvilConfig, origin config.ImportOrigin, flagStrategy ImportStrategy) (bool, error) {
+	output := palantir.GetGlobalOutputHandler()
+
+	// A digest pinned at import time keeps applying, so changed upstream content is refused
+	loadOpts := loadOptions{Format: originFormat(origin), Group: originBrewfileGroup(origin), Trust: TrustOptions{SHA256: origin.Pinned}}
+	importData, digest, err := loadImportFile(origin.Source, loadOpts, currentConfig)
+	if err != nil {
+		if origin.Pinned != "" {
+			return false, fmt.Errorf("%w (pinned with --sha256; re-import with the new digest once reviewed)", err)
 		}
+		return false, err
+	}
 
-		// Validate each tool name
-		for _, tool := range tools {
-			if err := validator.ValidateAppName(tool); err != nil {
-				return fmt.Errorf("invalid tool '%s' in group '%s': %w", tool, groupName, err)
-			}
+	if digest == origin.SHA256 {
+		output.PrintAlreadyAvailable("%s is unchanged since %s", origin.Source, origin.FetchedAt)
+		return false, nil
+	}
+
+	strategy := refreshStrategy(origin, flagStrategy)
+	changes, removed, err := planRefresh(origin, importData.Groups, currentConfig.Groups, strategy)
+	if err != nil {
+		return false, err
+	}
+	sections, err := planSections(importData, currentConfig, strategy)
+	if err != nil {
+		return false, err
+	}
+
+	output.PrintInfo("Upstream content changed, applying with strategy '%s'", strategy)
+	for _, groupName := range removed {
+		output.PrintWarning("Group '%s' was removed upstream, keeping local copy '%s'", groupName, origin.Groups[groupName])
+	}
+	changes, sections, proceed := reviewImport(changes, sections)
+	if !proceed {
+		output.PrintInfo("Skipped %s", origin.Source)
+		return false, nil
+	}
+	applied := countChangedGroups(changes) > 0 || len(sections) > 0
+
+	// The new digest is only recorded once a change was applied
+	recordOrigin(currentConfig, origin.Source, digest, StrategyFail, changes, applied)
+	if index := findOrigin(currentConfig.Imports, origin.Source); index >= 0 {
+		for _, groupName := range removed {
+			delete(currentConfig.Imports[index].Groups, groupName)
 		}
 	}
 
+	if err := applyImport(currentConfig, changes, sections); err != nil {
+		return false, err
+	}
+	if applied {
+		output.PrintSuccess(fmt.Sprintf("Applied upstream changes from %s", origin.Source))
+	}
+	return applied, nil
+}
+
+// loadOptions controls how an import file is verified and parsed
+type loadOptions struct {
+	Trust  TrustOptions
+	Format string // yaml or brewfile
+	Group  string // Group receiving a Brewfile's packages
+}
+
+// loadImportFile fetches, verifies, parses and validates an import file, returning its content digest.
+// Private repositories and URLs are fetched with the GitHub token and SSH key from settings.
+func loadImportFile(importPath string, opts loadOptions, cfg *config.AnvilConfig) (*config.ImportConfig, string, error) {
+	output := palantir.GetGlobalOutputHandler()
+	auth := authFromConfig(cfg)
+
+	// Stage 1: Fetch and validate source file
+	output.PrintStage("Stage 1: Fetching source file...")
+	spinner := charm.NewCircleSpinner("Fetching import file")
+	spinner.Start()
+	tempFile, cleanup, err := fetchFile(importPath, auth)
+	if err != nil {
+		spinner.Error("Failed to fetch source file")
+		return nil, "", errors.NewFileSystemError(constants.OpConfig, "fetch-file", err)
+	}
+	defer cleanup()
+	spinner.Success("Source file fetched successfully")
+
+	content, err := os.ReadFile(tempFile)
+	if err != nil {
+		return nil, "", errors.NewFileSystemError(constants.OpConfig, "read-file", err)
+	}
+	digest := contentSHA256(content)
+
+	// Verify the file against the pinned digest, signatures and trusted hosts
+	output.PrintStage("Verifying import source...")
+	trustResult, err := evaluateTrust(importPath, content, digest, opts.Trust, cfg.Trust, auth)
+	if err != nil {
+		return nil, "", errors.NewValidationError(constants.OpConfig, "verify-import", err)
+	}
+	if err := enforceTrust(importPath, trustResult, cfg.Trust.Policy); err != nil {
+		return nil, "", errors.NewValidationError(constants.OpConfig, "untrusted-import", err)
+	}
+
+	// Stage 2: Parse and validate import data
+	output.PrintStage("Parsing import file...")
+	var importData *config.ImportConfig
+	if opts.Format == formatBrewfile {
+		var notes []brew.BrewfileNote
+		importData, notes, err = parseBrewfileImport(tempFile, opts.Group)
+		for _, note := range notes {
+			output.PrintWarning("Brewfile line %d (%s): %s", note.Line, note.Text, note.Message)
+		}
+	} else {
+		importData, err = parseImportFile(tempFile)
+	}
+	if err != nil {
+		return nil, "", errors.NewConfigurationError(constants.OpConfig, "parse-import", err)
+	}
+
+	if importData.IsEmpty() {
+		return nil, "", errors.NewConfigurationError(constants.OpConfig, "no-groups",
+			fmt.Errorf("no valid groups, sources, configs, required tools, taps, brew options or hooks found in import file"))
+	}
+	output.PrintSuccess("Import file parsed successfully")
+	if metadata := importData.Metadata; metadata.Name != "" || metadata.Description != "" {
+		output.PrintInfo("📦 %s", strings.TrimSpace(strings.Join([]string{metadata.Name, metadata.Description}, " - ")))
+	}
+
+	// Stage 3: Validate import structure
+	output.PrintStage("Validating import structure...")
+	if err := validateImportConfig(importData); err != nil {
+		return nil, "", errors.NewConfigurationError(constants.OpConfig, "validate-import", err)
+	}
+	output.PrintSuccess("Import structure validation passed")
+
+	// Names can only be checked against the full index, the built-in one is too small
+	if index := brew.CurrentIndex(); !index.Partial {
+		for _, app := range unknownPackages(importData, index) {
+			output.PrintWarning("'%s' is not a known Homebrew formula or cask, it will fail to install unless a source is added", app)
+		}
+	}
+
+	return importData, digest, nil
+}
+
+// validateImportConfig validates every imported section against the import file JSON Schema,
+// so the CLI accepts exactly what editors using 'anvil config schema import' accept.
+func validateImportConfig(importData *config.ImportConfig) error {
+	if importData.IsEmpty() {
+		return fmt.Errorf("nothing found to import")
+	}
+
+	// Round-trip through YAML to validate the same generic data editors see
+	encoded, err := yaml.Marshal(importData)
+	if err != nil {
+		return fmt.Errorf("failed to encode import data: %w", err)
+	}
+	var data interface{}
+	if err := yaml.Unmarshal(encoded, &data); err != nil {
+		return fmt.Errorf("failed to decode import data: %w", err)
+	}
+
+	schemaErrs := config.ImportSchema().Validate(data)
+	if len(schemaErrs) > 0 {
+		messages := make([]string, len(schemaErrs))
+		for i, schemaErr := range schemaErrs {
+			messages[i] = schemaErr.Error()
+		}
+		return fmt.Errorf("import file does not match schema: %s", strings.Join(messages, "; "))
+	}
+
 	return nil
 }
 
@@ -163,54 +422,88 @@ func checkGroupConflicts(importGroups map[string][]string, existingGroups config
 	return conflicts
 }
 
-// displayImportSummary shows a tree view of groups that will be imported.
-func displayImportSummary(groups map[string][]string) {
+// displayImportSummary shows a tree view of the per-group changes that will be imported.
+func displayImportSummary(changes []GroupChange) {
 	output := palantir.GetGlobalOutputHandler()
 	fmt.Println("")
 	output.PrintInfo("📋 Import Summary:")
 	output.PrintInfo("═══════════════════")
 
-	// Sort group names for consistent output
-	var groupNames []string
-	for groupName := range groups {
-		groupNames = append(groupNames, groupName)
-	}
-	sort.Strings(groupNames)
-
-	totalGroups := len(groups)
-	totalApps := 0
+	totalAdded, totalRemoved := 0, 0
 
-	for _, groupName := range groupNames {
-		tools := groups[groupName]
-		totalApps += len(tools)
+	for _, change := range changes {
+		totalAdded += len(change.Added)
+		totalRemoved += len(change.Removed)
 
 		// Display group with tree structure
-		output.PrintInfo("├── 📁 %s (%d tools)", groupName, len(tools))
+		label := change.Name
+		if change.Name != change.SourceName {
+			label = fmt.Sprintf("%s → %s", change.SourceName, change.Name)
+		}
+		output.PrintInfo("├── 📁 %s (%s, %d tools)", label, change.Action, len(change.Tools))
+
+		if !change.HasChanges() {
+			output.PrintInfo("│   └── no changes")
+			output.PrintInfo("│")
+			continue
+		}
 
 		// Sort tools for consistent output
-		sortedTools := make([]string, len(tools))
-		copy(sortedTools, tools)
-		sort.Strings(sortedTools)
+		var lines []string
+		for _, tool := range sortedCopy(change.Added) {
+			lines = append(lines, "+ "+tool)
+		}
+		for _, tool := range sortedCopy(change.Removed) {
+			lines = append(lines, "- "+tool)
+		}
 
-		for i, tool := range sortedTools {
-			if i == len(sortedTools)-1 {
-				output.PrintInfo("│   └── 🔧 %s", tool)
+		for i, line := range lines {
+			if i == len(lines)-1 {
+				output.PrintInfo("│   └── %s", line)
 			} else {
-				output.PrintInfo("│   ├── 🔧 %s", tool)
+				output.PrintInfo("│   ├── %s", line)
 			}
 		}
 		output.PrintInfo("│")
 	}
 
-	output.PrintInfo("Total: %d groups, %d applications", totalGroups, totalApps)
+	output.PrintInfo("Total: %d groups, %d tools added, %d tools removed", countChangedGroups(changes), totalAdded, totalRemoved)
 	fmt.Println("")
 }
 
-// importGroups adds the imported groups to the current configuration.
-func importGroups(currentConfig *config.AnvilConfig, importGroups map[string][]string) error {
-	// Add new groups to existing configuration
-	for groupName, tools := range importGroups {
-		currentConfig.Groups[groupName] = tools
+// countChangedGroups returns the number of groups the import modifies.
+func countChangedGroups(changes []GroupChange) int {
+	count := 0
+	for _, change := range changes {
+		if change.HasChanges() {
+			count++
+		}
+	}
+	return count
+}
+
+// sortedCopy returns a sorted copy of a tool list.
+func sortedCopy(tools []string) []string {
+	sorted := make([]string, len(tools))
+	copy(sorted, tools)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// applyImport applies the planned group changes and accepted sections to the current configuration.
+func applyImport(currentConfig *config.AnvilConfig, changes []GroupChange, sections []ImportSection) error {
+	if currentConfig.Groups == nil {
+		currentConfig.Groups = make(config.AnvilGroups)
+	}
+
+	for _, change := range changes {
+		if change.HasChanges() {
+			currentConfig.Groups[change.Name] = change.Tools
+		}
+	}
+
+	for _, section := range sections {
+		section.apply(currentConfig)
 	}
 
 	// Save updated configuration
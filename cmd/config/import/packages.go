@@ -0,0 +1,57 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+// unknownPackages returns the imported group apps that are not Homebrew formulae or casks.
+// Apps installed from a source and apps from third-party taps cannot be checked and are skipped.
+func unknownPackages(importData *config.ImportConfig, index *brew.Index) []string {
+	seen := make(map[string]bool)
+	var unknown []string
+	for _, apps := range importData.Groups {
+		for _, app := range apps {
+			if seen[app] {
+				continue
+			}
+			seen[app] = true
+
+			if _, fromSource := importData.Sources[app]; fromSource {
+				continue
+			}
+			if tap, qualified := brew.TapFromPackage(app); qualified && !isDefaultTap(tap) {
+				continue
+			}
+			if !index.Has(app) {
+				unknown = append(unknown, app)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// isDefaultTap reports whether a tap is one of Homebrew's built-in taps covered by the index
+func isDefaultTap(tap string) bool {
+	return strings.EqualFold(tap, "homebrew/core") || strings.EqualFold(tap, "homebrew/cask")
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+func TestUnknownPackages(t *testing.T) {
+	index, err := brew.ParseHomebrewAPI(
+		[]byte(`[{"name": "git"}, {"name": "node", "aliases": ["node@22"]}]`),
+		[]byte(`[{"token": "iterm2"}]`),
+	)
+	if err != nil {
+		t.Fatalf("Failed to build index: %v", err)
+	}
+
+	importData := &config.ImportConfig{
+		Groups: config.AnvilGroups{
+			"dev":  {"git", "node@22", "gti", "homebrew/cask/iterm2", "homebrew/core/nodejs"},
+			"apps": {"iterm2", "gti", "internal-tool", "hashicorp/tap/terraform"},
+		},
+		Sources: map[string]config.AppSource{"internal-tool": config.NewAppSource("https://example.com/internal-tool.zip")},
+	}
+
+	expected := []string{"gti", "homebrew/core/nodejs"}
+	if got := unknownPackages(importData, index); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+// resolveSource returns the canonical origin of an import: URLs and git sources as given,
+// local files as absolute paths.
+func resolveSource(importPath string) string {
+	if isRemoteSource(importPath) {
+		return importPath
+	}
+	if absPath, err := filepath.Abs(importPath); err == nil {
+		return absPath
+	}
+	return importPath
+}
+
+// contentSHA256 returns the hex encoded SHA-256 digest of file content.
+func contentSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// findOrigin returns the index of the recorded origin for a source, or -1.
+func findOrigin(origins []config.ImportOrigin, source string) int {
+	for i, origin := range origins {
+		if origin.Source == source {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordOrigin stores the provenance of applied group changes in the configuration.
+// Skipped groups are not recorded since they remain owned by the local settings. The
+// digest and fetch time only move forward when applied is set, so upstream content that
+// was reviewed but not applied is reported again on the next refresh.
+func recordOrigin(currentConfig *config.AnvilConfig, source, digest string, strategy ImportStrategy, changes []GroupChange, applied bool) {
+	origin := config.ImportOrigin{
+		Source:   source,
+		Strategy: string(strategy),
+		Groups:   make(map[string]string),
+	}
+
+	index := findOrigin(currentConfig.Imports, source)
+	if index >= 0 {
+		origin = currentConfig.Imports[index]
+		if origin.Groups == nil {
+			origin.Groups = make(map[string]string)
+		}
+		if strategy != StrategyFail {
+			origin.Strategy = string(strategy)
+		}
+	}
+
+	if applied || index < 0 {
+		origin.FetchedAt = time.Now().UTC().Format(time.RFC3339)
+		origin.SHA256 = digest
+	}
+	for _, change := range changes {
+		if change.Action != string(StrategySkip) {
+			origin.Groups[change.SourceName] = change.Name
+		}
+	}
+
+	if index >= 0 {
+		currentConfig.Imports[index] = origin
+	} else {
+		currentConfig.Imports = append(currentConfig.Imports, origin)
+	}
+}
+
+// refreshStrategy returns the strategy used to refresh an origin: the flag if given,
+// otherwise the strategy recorded at import time, falling back to merge.
+func refreshStrategy(origin config.ImportOrigin, flagStrategy ImportStrategy) ImportStrategy {
+	if flagStrategy != StrategyFail {
+		return flagStrategy
+	}
+	if strategy, err := parseStrategy(origin.Strategy); err == nil && strategy != StrategyFail {
+		return strategy
+	}
+	return StrategyMerge
+}
+
+// planRefresh computes the changes of re-importing an origin's upstream groups.
+// Groups previously imported from the origin are updated in place under their local names
+// (a rename strategy overwrites them rather than creating another copy), while new upstream
+// groups are planned like a regular import. It also returns the upstream groups that were removed.
+func planRefresh(origin config.ImportOrigin, upstream map[string][]string, existing config.AnvilGroups, strategy ImportStrategy) ([]GroupChange, []string, error) {
+	owned := make(map[string][]string)
+	ownedSources := make(map[string]string)
+	unowned := make(map[string][]string)
+
+	for sourceName, tools := range upstream {
+		localName, tracked := origin.Groups[sourceName]
+		if _, exists := existing[localName]; tracked && exists {
+			owned[localName] = tools
+			ownedSources[localName] = sourceName
+			continue
+		}
+		unowned[sourceName] = tools
+	}
+
+	ownedStrategy := strategy
+	if ownedStrategy == StrategyRename {
+		ownedStrategy = StrategyOverwrite
+	}
+
+	ownedChanges, err := planImport(owned, existing, ownedStrategy)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range ownedChanges {
+		ownedChanges[i].SourceName = ownedSources[ownedChanges[i].Name]
+	}
+
+	newChanges, err := planImport(unowned, existing, strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := append(ownedChanges, newChanges...)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].SourceName < changes[j].SourceName
+	})
+
+	var removed []string
+	for sourceName := range origin.Groups {
+		if _, exists := upstream[sourceName]; !exists {
+			removed = append(removed, sourceName)
+		}
+	}
+	sort.Strings(removed)
+
+	return changes, removed, nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+func TestRecordOrigin(t *testing.T) {
+	cfg := &config.AnvilConfig{}
+	changes := []GroupChange{
+		{Name: "frontend-imported", SourceName: "frontend", Action: "rename"},
+		{Name: "backend", SourceName: "backend", Action: "skip"},
+		{Name: "mobile", SourceName: "mobile", Action: "new"},
+	}
+
+	recordOrigin(cfg, "https://example.com/team.yaml", "abc", StrategyRename, changes, true)
+
+	if len(cfg.Imports) != 1 {
+		t.Fatalf("Expected 1 origin, got %d", len(cfg.Imports))
+	}
+	origin := cfg.Imports[0]
+	if origin.Source != "https://example.com/team.yaml" || origin.SHA256 != "abc" || origin.Strategy != "rename" {
+		t.Errorf("Unexpected origin: %+v", origin)
+	}
+	if origin.FetchedAt == "" {
+		t.Error("Expected fetch time to be recorded")
+	}
+	expectedGroups := map[string]string{"frontend": "frontend-imported", "mobile": "mobile"}
+	if !reflect.DeepEqual(origin.Groups, expectedGroups) {
+		t.Errorf("Expected groups %v, got %v", expectedGroups, origin.Groups)
+	}
+
+	// Recording the same source again updates the existing origin
+	recordOrigin(cfg, "https://example.com/team.yaml", "def", StrategyFail, []GroupChange{
+		{Name: "data", SourceName: "data", Action: "new"},
+	}, true)
+
+	if len(cfg.Imports) != 1 {
+		t.Fatalf("Expected origin to be updated in place, got %d origins", len(cfg.Imports))
+	}
+	origin = cfg.Imports[0]
+	if origin.SHA256 != "def" || origin.Strategy != "rename" {
+		t.Errorf("Expected digest update and kept strategy, got %+v", origin)
+	}
+	if len(origin.Groups) != 3 {
+		t.Errorf("Expected 3 tracked groups, got %v", origin.Groups)
+	}
+
+	// Content that was reviewed but not applied keeps the last applied digest
+	fetchedAt := origin.FetchedAt
+	recordOrigin(cfg, "https://example.com/team.yaml", "ghi", StrategyFail, nil, false)
+	origin = cfg.Imports[0]
+	if origin.SHA256 != "def" || origin.FetchedAt != fetchedAt {
+		t.Errorf("Expected digest and fetch time to be kept without applied changes, got %+v", origin)
+	}
+}
+
+func TestRefreshStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		recorded string
+		flag     ImportStrategy
+		expected ImportStrategy
+	}{
+		{"flag wins", "overwrite", StrategySkip, StrategySkip},
+		{"recorded strategy", "overwrite", StrategyFail, StrategyOverwrite},
+		{"defaults to merge", "", StrategyFail, StrategyMerge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refreshStrategy(config.ImportOrigin{Strategy: tt.recorded}, tt.flag)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPlanRefresh(t *testing.T) {
+	origin := config.ImportOrigin{
+		Source: "https://example.com/team.yaml",
+		Groups: map[string]string{"frontend": "frontend-imported", "legacy": "legacy"},
+	}
+	existing := config.AnvilGroups{
+		"frontend":          {"node"},
+		"frontend-imported": {"node", "npm"},
+		"legacy":            {"perl"},
+	}
+	upstream := map[string][]string{
+		"frontend": {"node", "yarn"},
+		"mobile":   {"flutter"},
+	}
+
+	changes, removed, err := planRefresh(origin, upstream, existing, StrategyRename)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []GroupChange{
+		{Name: "frontend-imported", SourceName: "frontend", Action: "overwrite", Tools: []string{"node", "yarn"}, Added: []string{"yarn"}, Removed: []string{"npm"}},
+		{Name: "mobile", SourceName: "mobile", Action: "new", Tools: []string{"flutter"}, Added: []string{"flutter"}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %+v, got %+v", expected, changes)
+	}
+	if !reflect.DeepEqual(removed, []string{"legacy"}) {
+		t.Errorf("Expected removed [legacy], got %v", removed)
+	}
+}
+
+func TestContentSHA256(t *testing.T) {
+	digest := contentSHA256([]byte("groups: {}\n"))
+	if len(digest) != 64 {
+		t.Errorf("Expected 64 hex characters, got %q", digest)
+	}
+	if digest != contentSHA256([]byte("groups: {}\n")) {
+		t.Error("Expected identical content to produce identical digests")
+	}
+}
+
+func TestRefreshOriginEnforcesPinnedDigest(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "team.yaml")
+	content := []byte("groups:\n  dev: [git]\n")
+	if err := os.WriteFile(source, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.AnvilConfig{Trust: config.ImportTrustConfig{AllowLocal: true}}
+
+	unchanged := config.ImportOrigin{Source: source, SHA256: contentSHA256(content), Pinned: contentSHA256(content)}
+	if applied, err := refreshOrigin(cfg, unchanged, StrategyFail); err != nil || applied {
+		t.Errorf("Expected unchanged pinned content to refresh cleanly, got %v, %v", applied, err)
+	}
+
+	changed := config.ImportOrigin{Source: source, SHA256: "old", Pinned: strings.Repeat("0", 64)}
+	_, err := refreshOrigin(cfg, changed, StrategyFail)
+	if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Errorf("Expected the pinned digest to be enforced, got %v", err)
+	}
+}
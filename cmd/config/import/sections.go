@@ -0,0 +1,335 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/palantir"
+)
+
+// Entry actions for keyed sections
+const (
+	entryNew       = "new"
+	entryUpdate    = "update"
+	entryKeep      = "keep"
+	entryUnchanged = "unchanged"
+)
+
+// EntryChange describes what importing a single keyed entry does to a settings section.
+type EntryChange struct {
+	Key    string
+	Action string // new, update, keep or unchanged
+	Old    []string
+	New    []string // Resulting value after import
+}
+
+// ImportSection is an optional part of an import file that is reviewed and accepted on its own.
+type ImportSection struct {
+	Name    string   // Settings key, e.g. "sources"
+	Title   string   // Human readable title
+	Lines   []string // Summary lines shown before confirmation
+	Changes int      // Number of entries the section modifies
+	Warning string   // Shown above the confirmation prompt
+	apply   func(cfg *config.AnvilConfig)
+}
+
+// planSections computes the changes of the optional sections of an import file.
+// Sections without changes are omitted. Under the default strategy, entries that
+// already exist with a different value are reported as conflicts.
+func planSections(importData *config.ImportConfig, currentConfig *config.AnvilConfig, strategy ImportStrategy) ([]ImportSection, error) {
+	var sections []ImportSection
+	var conflicts []string
+
+	keyedSections := []struct {
+		name     string
+		title    string
+		imported map[string][]string
+		existing map[string][]string
+		set      func(cfg *config.AnvilConfig, change EntryChange)
+	}{
+		{"sources", "Installation Sources", sourcesListMap(importData.Sources), sourcesListMap(currentConfig.Sources),
+			func(cfg *config.AnvilConfig, change EntryChange) {
+				if cfg.Sources == nil {
+					cfg.Sources = make(map[string]config.AppSource)
+				}
+				cfg.Sources[change.Key] = importData.Sources[change.Key]
+			}},
+		{"configs", "Config Paths", toListMap(importData.Configs), toListMap(currentConfig.Configs),
+			func(cfg *config.AnvilConfig, change EntryChange) {
+				if cfg.Configs == nil {
+					cfg.Configs = make(map[string]string)
+				}
+				cfg.Configs[change.Key] = change.New[0]
+			}},
+	}
+
+	for _, keyed := range keyedSections {
+		changes := planKeyedSection(keyed.imported, keyed.existing, strategy, false)
+		conflicts = append(conflicts, sectionConflicts(keyed.name, changes, strategy)...)
+
+		section := newKeyedSection(keyed.name, keyed.title, changes, keyed.set)
+		if section.Changes > 0 {
+			sections = append(sections, section)
+		}
+	}
+
+	// Required tools are unioned, there is nothing to conflict with
+	addedTools := difference(config.MergeGroupTools(nil, importData.Tools.RequiredTools), currentConfig.Tools.RequiredTools)
+	if len(addedTools) > 0 {
+		lines := make([]string, len(addedTools))
+		for i, tool := range addedTools {
+			lines[i] = "+ " + tool
+		}
+		sections = append(sections, ImportSection{
+			Name:    "tools.required_tools",
+			Title:   "Required Tools",
+			Lines:   lines,
+			Changes: len(addedTools),
+			apply: func(cfg *config.AnvilConfig) {
+				cfg.Tools.RequiredTools = config.MergeGroupTools(cfg.Tools.RequiredTools, addedTools)
+			},
+		})
+	}
+
+	// Taps are keyed by name, a differing URL is a conflict like any other keyed entry
+	tapChanges := planKeyedSection(tapsByName(importData.Taps), tapsByName(currentConfig.Taps), strategy, false)
+	conflicts = append(conflicts, sectionConflicts("taps", tapChanges, strategy)...)
+	taps := newKeyedSection("taps", "Homebrew Taps", tapChanges, func(cfg *config.AnvilConfig, change EntryChange) {
+		setTap(cfg, config.BrewTap{Name: change.Key, URL: change.New[0]})
+	})
+	if taps.Changes > 0 {
+		// Taps without a URL have nothing to show after the name
+		for i, line := range taps.Lines {
+			taps.Lines[i] = strings.TrimSuffix(line, ": ")
+		}
+		sections = append(sections, taps)
+	}
+
+	// Only package types are imported; existing args and env are kept
+	brewChanges := planKeyedSection(importBrewTypes(importData.Brew), brewTypes(currentConfig.Brew), strategy, false)
+	conflicts = append(conflicts, sectionConflicts("brew_options", brewChanges, strategy)...)
+	brewOptions := newKeyedSection("brew_options", "Homebrew Package Types", brewChanges, func(cfg *config.AnvilConfig, change EntryChange) {
+		if cfg.Brew == nil {
+			cfg.Brew = make(config.AnvilBrewOptions)
+		}
+		options := cfg.Brew[change.Key]
+		options.Type = change.New[0]
+		cfg.Brew[change.Key] = options
+	})
+	if brewOptions.Changes > 0 {
+		sections = append(sections, brewOptions)
+	}
+
+	hookChanges := planKeyedSection(importData.Hooks.PostInstall, currentConfig.Hooks.PostInstall, strategy, true)
+	conflicts = append(conflicts, sectionConflicts("hooks.post_install", hookChanges, strategy)...)
+	hooks := newKeyedSection("hooks.post_install", "Post-install Hooks", hookChanges, func(cfg *config.AnvilConfig, change EntryChange) {
+		if cfg.Hooks.PostInstall == nil {
+			cfg.Hooks.PostInstall = make(map[string][]string)
+		}
+		cfg.Hooks.PostInstall[change.Key] = change.New
+	})
+	if hooks.Changes > 0 {
+		hooks.Warning = "Post-install hooks run shell commands on your machine after installs. Only accept hooks you have reviewed."
+		sections = append(sections, hooks)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("entries already exist with different values: %s (use --strategy skip|overwrite|merge|rename)", strings.Join(conflicts, ", "))
+	}
+
+	return sections, nil
+}
+
+// tapsByName keys taps by name with their URL as single value, for planKeyedSection
+func tapsByName(taps []config.BrewTap) map[string][]string {
+	if len(taps) == 0 {
+		return nil
+	}
+	byName := make(map[string][]string, len(taps))
+	for _, tap := range taps {
+		byName[tap.Name] = []string{tap.URL}
+	}
+	return byName
+}
+
+// importBrewTypes keys imported brew_options by app with their type as single value
+func importBrewTypes(options map[string]config.ImportBrewOptions) map[string][]string {
+	types := make(map[string][]string, len(options))
+	for appName, option := range options {
+		types[appName] = []string{option.Type}
+	}
+	return types
+}
+
+// brewTypes keys the configured brew_options that set a type by app, for planKeyedSection
+func brewTypes(options config.AnvilBrewOptions) map[string][]string {
+	types := make(map[string][]string)
+	for appName, option := range options {
+		if option.Type != "" {
+			types[appName] = []string{option.Type}
+		}
+	}
+	return types
+}
+
+// setTap adds a tap to settings, or updates the URL of an already configured tap
+func setTap(cfg *config.AnvilConfig, tap config.BrewTap) {
+	for i := range cfg.Taps {
+		if strings.EqualFold(cfg.Taps[i].Name, tap.Name) {
+			cfg.Taps[i] = tap
+			return
+		}
+	}
+	cfg.Taps = append(cfg.Taps, tap)
+}
+
+// planKeyedSection plans the import of a keyed section such as sources, configs or hooks.
+// Overwrite replaces differing values; merge keeps existing scalar values and unions lists
+// when mergeLists is set; skip and rename keep existing values.
+func planKeyedSection(imported, existing map[string][]string, strategy ImportStrategy, mergeLists bool) []EntryChange {
+	keys := make([]string, 0, len(imported))
+	for key := range imported {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changes := make([]EntryChange, 0, len(keys))
+	for _, key := range keys {
+		value := imported[key]
+		current, exists := existing[key]
+
+		change := EntryChange{Key: key, Old: current, New: value}
+		switch {
+		case !exists:
+			change.Action = entryNew
+		case reflect.DeepEqual(current, value):
+			change.Action = entryUnchanged
+		case strategy == StrategyOverwrite:
+			change.Action = entryUpdate
+		case strategy == StrategyMerge && mergeLists:
+			change.Action = entryUpdate
+			change.New = config.MergeGroupTools(current, value)
+			if reflect.DeepEqual(change.New, current) {
+				change.Action = entryUnchanged
+			}
+		default:
+			change.Action = entryKeep
+			change.New = current
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// sectionConflicts lists entries that differ from existing values under the default strategy.
+func sectionConflicts(sectionName string, changes []EntryChange, strategy ImportStrategy) []string {
+	if strategy != StrategyFail {
+		return nil
+	}
+
+	var conflicts []string
+	for _, change := range changes {
+		if change.Action == entryKeep {
+			conflicts = append(conflicts, fmt.Sprintf("%s.%s", sectionName, change.Key))
+		}
+	}
+	return conflicts
+}
+
+// newKeyedSection builds the reviewable section for planned keyed entries.
+func newKeyedSection(name, title string, changes []EntryChange, set func(cfg *config.AnvilConfig, change EntryChange)) ImportSection {
+	section := ImportSection{Name: name, Title: title}
+
+	var applied []EntryChange
+	for _, change := range changes {
+		switch change.Action {
+		case entryNew:
+			section.Lines = append(section.Lines, fmt.Sprintf("+ %s: %s", change.Key, strings.Join(change.New, "; ")))
+		case entryUpdate:
+			section.Lines = append(section.Lines, fmt.Sprintf("~ %s: %s → %s", change.Key, strings.Join(change.Old, "; "), strings.Join(change.New, "; ")))
+		case entryKeep:
+			section.Lines = append(section.Lines, fmt.Sprintf("= %s: keeping %s", change.Key, strings.Join(change.Old, "; ")))
+			continue
+		default:
+			continue
+		}
+		applied = append(applied, change)
+	}
+
+	section.Changes = len(applied)
+	section.apply = func(cfg *config.AnvilConfig) {
+		for _, change := range applied {
+			set(cfg, change)
+		}
+	}
+	return section
+}
+
+// reviewSections shows each section's summary and asks whether to accept it.
+// It returns the accepted sections.
+func reviewSections(sections []ImportSection) []ImportSection {
+	output := palantir.GetGlobalOutputHandler()
+
+	var accepted []ImportSection
+	for _, section := range sections {
+		fmt.Println("")
+		output.PrintInfo("📋 %s (%s):", section.Title, section.Name)
+		for i, line := range section.Lines {
+			if i == len(section.Lines)-1 {
+				output.PrintInfo("└── %s", line)
+			} else {
+				output.PrintInfo("├── %s", line)
+			}
+		}
+		fmt.Println("")
+
+		if section.Warning != "" {
+			output.PrintWarning("%s", section.Warning)
+		}
+		if output.Confirm(fmt.Sprintf("Import %s (%d change(s))?", section.Name, section.Changes)) {
+			accepted = append(accepted, section)
+		} else {
+			output.PrintInfo("Skipped %s", section.Name)
+		}
+	}
+	return accepted
+}
+
+// sourcesListMap converts sources to single-value lists of their one-line rendering,
+// so per-platform sources are compared and shown as a whole
+func sourcesListMap(sources map[string]config.AppSource) map[string][]string {
+	result := make(map[string][]string, len(sources))
+	for appName, source := range sources {
+		result[appName] = []string{source.String()}
+	}
+	return result
+}
+
+// toListMap converts a string map to a map of single-value lists for uniform planning.
+func toListMap(values map[string]string) map[string][]string {
+	result := make(map[string][]string, len(values))
+	for key, value := range values {
+		result[key] = []string{value}
+	}
+	return result
+}
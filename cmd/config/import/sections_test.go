@@ -0,0 +1,242 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+func TestPlanKeyedSection(t *testing.T) {
+	existing := map[string][]string{
+		"same":    {"a"},
+		"differs": {"old"},
+	}
+	imported := map[string][]string{
+		"same":    {"a"},
+		"differs": {"new"},
+		"added":   {"x"},
+	}
+
+	tests := []struct {
+		name       string
+		strategy   ImportStrategy
+		mergeLists bool
+		expected   map[string]EntryChange
+	}{
+		{
+			name:     "overwrite replaces differing values",
+			strategy: StrategyOverwrite,
+			expected: map[string]EntryChange{
+				"added":   {Key: "added", Action: entryNew, New: []string{"x"}},
+				"differs": {Key: "differs", Action: entryUpdate, Old: []string{"old"}, New: []string{"new"}},
+				"same":    {Key: "same", Action: entryUnchanged, Old: []string{"a"}, New: []string{"a"}},
+			},
+		},
+		{
+			name:     "merge keeps existing scalar values",
+			strategy: StrategyMerge,
+			expected: map[string]EntryChange{
+				"added":   {Key: "added", Action: entryNew, New: []string{"x"}},
+				"differs": {Key: "differs", Action: entryKeep, Old: []string{"old"}, New: []string{"old"}},
+				"same":    {Key: "same", Action: entryUnchanged, Old: []string{"a"}, New: []string{"a"}},
+			},
+		},
+		{
+			name:       "merge unions lists",
+			strategy:   StrategyMerge,
+			mergeLists: true,
+			expected: map[string]EntryChange{
+				"added":   {Key: "added", Action: entryNew, New: []string{"x"}},
+				"differs": {Key: "differs", Action: entryUpdate, Old: []string{"old"}, New: []string{"old", "new"}},
+				"same":    {Key: "same", Action: entryUnchanged, Old: []string{"a"}, New: []string{"a"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := planKeyedSection(imported, existing, tt.strategy, tt.mergeLists)
+
+			if len(changes) != len(tt.expected) {
+				t.Fatalf("Expected %d changes, got %d", len(tt.expected), len(changes))
+			}
+			for _, change := range changes {
+				if !reflect.DeepEqual(change, tt.expected[change.Key]) {
+					t.Errorf("Expected %+v, got %+v", tt.expected[change.Key], change)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanSections(t *testing.T) {
+	importData := &config.ImportConfig{
+		Sources: map[string]config.AppSource{"internal-cli": config.NewAppSource("https://example.com/cli.tar.gz"), "tool": config.NewAppSource("https://new")},
+		Configs: map[string]string{"nvim": "~/.config/nvim"},
+		Tools:   config.ImportTools{RequiredTools: []string{"git", "jq"}},
+		Hooks:   config.AnvilHooks{PostInstall: map[string][]string{"nvim": {"nvim --headless +qa"}}},
+	}
+	currentConfig := &config.AnvilConfig{
+		Sources: map[string]config.AppSource{"tool": config.NewAppSource("https://old")},
+		Tools:   config.AnvilTools{RequiredTools: []string{"git", "curl"}},
+	}
+
+	t.Run("default strategy reports conflicts", func(t *testing.T) {
+		_, err := planSections(importData, currentConfig, StrategyFail)
+		if err == nil || !strings.Contains(err.Error(), "sources.tool") {
+			t.Fatalf("Expected conflict on sources.tool, got %v", err)
+		}
+	})
+
+	t.Run("overwrite plans and applies every section", func(t *testing.T) {
+		sections, err := planSections(importData, currentConfig, StrategyOverwrite)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var names []string
+		for _, section := range sections {
+			names = append(names, section.Name)
+		}
+		expectedNames := []string{"sources", "configs", "tools.required_tools", "hooks.post_install"}
+		if !reflect.DeepEqual(names, expectedNames) {
+			t.Fatalf("Expected sections %v, got %v", expectedNames, names)
+		}
+		if sections[0].Changes != 2 || sections[2].Changes != 1 {
+			t.Errorf("Unexpected change counts: sources=%d tools=%d", sections[0].Changes, sections[2].Changes)
+		}
+		if sections[3].Warning == "" {
+			t.Error("Expected hooks section to carry a warning")
+		}
+
+		cfg := &config.AnvilConfig{
+			Sources: map[string]config.AppSource{"tool": config.NewAppSource("https://old")},
+			Tools:   config.AnvilTools{RequiredTools: []string{"git", "curl"}},
+		}
+		for _, section := range sections {
+			section.apply(cfg)
+		}
+
+		if cfg.Sources["tool"].URL != "https://new" || cfg.Sources["internal-cli"].IsZero() {
+			t.Errorf("Unexpected sources: %v", cfg.Sources)
+		}
+		if cfg.Configs["nvim"] != "~/.config/nvim" {
+			t.Errorf("Unexpected configs: %v", cfg.Configs)
+		}
+		if !reflect.DeepEqual(cfg.Tools.RequiredTools, []string{"git", "curl", "jq"}) {
+			t.Errorf("Unexpected required tools: %v", cfg.Tools.RequiredTools)
+		}
+		if !reflect.DeepEqual(cfg.Hooks.PostInstall["nvim"], []string{"nvim --headless +qa"}) {
+			t.Errorf("Unexpected hooks: %v", cfg.Hooks.PostInstall)
+		}
+	})
+
+	t.Run("sections without changes are omitted", func(t *testing.T) {
+		sections, err := planSections(&config.ImportConfig{Tools: config.ImportTools{RequiredTools: []string{"git"}}}, currentConfig, StrategyFail)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(sections) != 0 {
+			t.Errorf("Expected no sections, got %d", len(sections))
+		}
+	})
+}
+
+func TestParseImportFileSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "team.yaml")
+	content := `groups:
+  frontend: [node]
+sources:
+  internal-cli: https://example.com/cli.tar.gz
+configs:
+  nvim: ~/.config/nvim
+tools:
+  required_tools: [jq]
+  installed_apps: [ignored]
+hooks:
+  post_install:
+    nvim:
+      - nvim --headless +qa
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	importData, err := parseImportFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(importData.Groups) != 1 || importData.Sources["internal-cli"].IsZero() || importData.Configs["nvim"] == "" {
+		t.Errorf("Unexpected import data: %+v", importData)
+	}
+	if !reflect.DeepEqual(importData.Tools.RequiredTools, []string{"jq"}) {
+		t.Errorf("Unexpected required tools: %v", importData.Tools.RequiredTools)
+	}
+	if len(importData.Hooks.PostInstall["nvim"]) != 1 {
+		t.Errorf("Unexpected hooks: %v", importData.Hooks.PostInstall)
+	}
+	if err := validateImportConfig(importData); err != nil {
+		t.Errorf("Expected valid import, got %v", err)
+	}
+
+	importData.Sources["bad name"] = config.NewAppSource("https://example.com")
+	if err := validateImportConfig(importData); err == nil {
+		t.Error("Expected invalid source name to fail validation")
+	}
+}
+
+func TestParseImportFileRoundTripsExport(t *testing.T) {
+	settings := &config.AnvilConfig{
+		Groups:  config.AnvilGroups{"dev": {"git", "jq"}, "frontend": {"node"}},
+		Sources: map[string]config.AppSource{"jq": config.NewAppSource("https://example.com/jq")},
+	}
+	catalog, _, err := config.ExportCatalog(settings, config.ExportOptions{
+		WithSources: true,
+		Metadata:    config.ImportMetadata{Name: "Team"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := yaml.Marshal(catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "team.yaml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	importData, err := parseImportFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := validateImportConfig(importData); err != nil {
+		t.Errorf("Expected exported file to validate, got %v", err)
+	}
+	if !reflect.DeepEqual(importData, catalog) {
+		t.Errorf("Expected export to import unchanged, got %+v, want %+v", importData, catalog)
+	}
+}
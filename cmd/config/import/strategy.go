@@ -0,0 +1,177 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+// ImportStrategy controls how imported groups that already exist are handled.
+type ImportStrategy string
+
+const (
+	// StrategyFail aborts the import when any group already exists (default)
+	StrategyFail ImportStrategy = ""
+	// StrategySkip keeps existing groups untouched
+	StrategySkip ImportStrategy = "skip"
+	// StrategyOverwrite replaces existing groups with the imported tools
+	StrategyOverwrite ImportStrategy = "overwrite"
+	// StrategyMerge unions existing and imported tools
+	StrategyMerge ImportStrategy = "merge"
+	// StrategyRename imports conflicting groups under a new name
+	StrategyRename ImportStrategy = "rename"
+)
+
+// renameSuffix is appended to conflicting group names by the rename strategy
+const renameSuffix = "-imported"
+
+// validStrategies lists the strategies accepted by --strategy
+var validStrategies = []ImportStrategy{StrategySkip, StrategyOverwrite, StrategyMerge, StrategyRename}
+
+// GroupChange describes what importing a single group does to the settings.
+type GroupChange struct {
+	Name       string // Group name in settings after import
+	SourceName string // Group name in the import file
+	Action     string // new, skip, overwrite, merge or rename
+	Tools      []string
+	Added      []string
+	Removed    []string
+}
+
+// HasChanges reports whether applying the change modifies the settings.
+func (c GroupChange) HasChanges() bool {
+	return len(c.Added) > 0 || len(c.Removed) > 0
+}
+
+// parseStrategy converts a --strategy flag value into an ImportStrategy.
+func parseStrategy(value string) (ImportStrategy, error) {
+	if value == "" {
+		return StrategyFail, nil
+	}
+
+	names := make([]string, len(validStrategies))
+	for i, strategy := range validStrategies {
+		if string(strategy) == value {
+			return strategy, nil
+		}
+		names[i] = string(strategy)
+	}
+
+	return StrategyFail, fmt.Errorf("invalid strategy '%s'. Valid strategies are: %s", value, strings.Join(names, ", "))
+}
+
+// planImport computes the per-group changes of importing groups with the given strategy.
+// Changes are sorted by imported group name.
+func planImport(importGroups map[string][]string, existingGroups config.AnvilGroups, strategy ImportStrategy) ([]GroupChange, error) {
+	if strategy == StrategyFail {
+		if conflicts := checkGroupConflicts(importGroups, existingGroups); len(conflicts) > 0 {
+			return nil, fmt.Errorf("groups already exist: %s (use --strategy skip|overwrite|merge|rename)", strings.Join(conflicts, ", "))
+		}
+	}
+
+	var groupNames []string
+	for groupName := range importGroups {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+
+	// Track names taken by settings and earlier renames
+	taken := make(map[string]struct{}, len(existingGroups)+len(importGroups))
+	for groupName := range existingGroups {
+		taken[groupName] = struct{}{}
+	}
+	for groupName := range importGroups {
+		taken[groupName] = struct{}{}
+	}
+
+	changes := make([]GroupChange, 0, len(groupNames))
+	for _, groupName := range groupNames {
+		tools := config.MergeGroupTools(nil, importGroups[groupName])
+		existing, exists := existingGroups[groupName]
+
+		change := GroupChange{Name: groupName, SourceName: groupName}
+		switch {
+		case !exists:
+			change.Action = "new"
+			change.Tools = tools
+			change.Added = tools
+		case strategy == StrategySkip:
+			change.Action = string(StrategySkip)
+			change.Tools = existing
+		case strategy == StrategyOverwrite:
+			change.Action = string(StrategyOverwrite)
+			change.Tools = tools
+			change.Added = difference(tools, existing)
+			change.Removed = difference(existing, tools)
+		case strategy == StrategyMerge:
+			change.Action = string(StrategyMerge)
+			change.Tools = config.MergeGroupTools(existing, tools)
+			change.Added = difference(tools, existing)
+		case strategy == StrategyRename:
+			newName, err := renameGroup(groupName, taken)
+			if err != nil {
+				return nil, err
+			}
+			taken[newName] = struct{}{}
+			change.Name = newName
+			change.Action = string(StrategyRename)
+			change.Tools = tools
+			change.Added = tools
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// renameGroup finds an unused, valid name for a conflicting group.
+func renameGroup(groupName string, taken map[string]struct{}) (string, error) {
+	validator := config.NewConfigValidator(nil)
+	candidate := groupName + renameSuffix
+	for i := 2; ; i++ {
+		if _, exists := taken[candidate]; !exists {
+			break
+		}
+		candidate = fmt.Sprintf("%s%s-%d", groupName, renameSuffix, i)
+	}
+
+	if err := validator.ValidateGroupName(candidate); err != nil {
+		return "", fmt.Errorf("cannot rename group '%s': %w", groupName, err)
+	}
+	return candidate, nil
+}
+
+// difference returns the tools in a that are not in b, preserving order.
+func difference(a, b []string) []string {
+	exclude := make(map[string]struct{}, len(b))
+	for _, tool := range b {
+		exclude[tool] = struct{}{}
+	}
+
+	var result []string
+	for _, tool := range a {
+		if _, exists := exclude[tool]; !exists {
+			result = append(result, tool)
+		}
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+func TestParseStrategy(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    ImportStrategy
+		expectError bool
+	}{
+		{"", StrategyFail, false},
+		{"skip", StrategySkip, false},
+		{"overwrite", StrategyOverwrite, false},
+		{"merge", StrategyMerge, false},
+		{"rename", StrategyRename, false},
+		{"replace", StrategyFail, true},
+	}
+
+	for _, tt := range tests {
+		strategy, err := parseStrategy(tt.value)
+		if (err != nil) != tt.expectError {
+			t.Errorf("parseStrategy(%q) error = %v, expectError %v", tt.value, err, tt.expectError)
+		}
+		if strategy != tt.expected {
+			t.Errorf("parseStrategy(%q) = %q, expected %q", tt.value, strategy, tt.expected)
+		}
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	existing := config.AnvilGroups{
+		"frontend":          {"node", "npm"},
+		"backend":           {"go"},
+		"frontend-imported": {"deno"},
+	}
+	imported := map[string][]string{
+		"frontend": {"node", "yarn", "yarn"},
+		"mobile":   {"flutter"},
+	}
+
+	tests := []struct {
+		name        string
+		strategy    ImportStrategy
+		expected    []GroupChange
+		expectError string
+	}{
+		{
+			name:        "default strategy fails on conflicts",
+			strategy:    StrategyFail,
+			expectError: "groups already exist: frontend",
+		},
+		{
+			name:     "skip keeps existing groups",
+			strategy: StrategySkip,
+			expected: []GroupChange{
+				{Name: "frontend", SourceName: "frontend", Action: "skip", Tools: []string{"node", "npm"}},
+				{Name: "mobile", SourceName: "mobile", Action: "new", Tools: []string{"flutter"}, Added: []string{"flutter"}},
+			},
+		},
+		{
+			name:     "overwrite replaces tools",
+			strategy: StrategyOverwrite,
+			expected: []GroupChange{
+				{Name: "frontend", SourceName: "frontend", Action: "overwrite", Tools: []string{"node", "yarn"}, Added: []string{"yarn"}, Removed: []string{"npm"}},
+				{Name: "mobile", SourceName: "mobile", Action: "new", Tools: []string{"flutter"}, Added: []string{"flutter"}},
+			},
+		},
+		{
+			name:     "merge unions tools without duplicates",
+			strategy: StrategyMerge,
+			expected: []GroupChange{
+				{Name: "frontend", SourceName: "frontend", Action: "merge", Tools: []string{"node", "npm", "yarn"}, Added: []string{"yarn"}},
+				{Name: "mobile", SourceName: "mobile", Action: "new", Tools: []string{"flutter"}, Added: []string{"flutter"}},
+			},
+		},
+		{
+			name:     "rename picks an unused name",
+			strategy: StrategyRename,
+			expected: []GroupChange{
+				{Name: "frontend-imported-2", SourceName: "frontend", Action: "rename", Tools: []string{"node", "yarn"}, Added: []string{"node", "yarn"}},
+				{Name: "mobile", SourceName: "mobile", Action: "new", Tools: []string{"flutter"}, Added: []string{"flutter"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := planImport(imported, existing, tt.strategy)
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("Expected changes %+v, got %+v", tt.expected, changes)
+			}
+		})
+	}
+}
+
+func TestCountChangedGroups(t *testing.T) {
+	changes := []GroupChange{
+		{Name: "a", Added: []string{"git"}},
+		{Name: "b", Removed: []string{"npm"}},
+		{Name: "c"},
+	}
+
+	if got := countChangedGroups(changes); got != 2 {
+		t.Errorf("Expected 2 changed groups, got %d", got)
+	}
+}
@@ -0,0 +1,404 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/network"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+	"github.com/0xjuanma/palantir"
+	"golang.org/x/crypto/blake2b"
+)
+
+// signatureSuffix is appended to an import source to locate its detached signature
+const signatureSuffix = ".minisig"
+
+// Minisign signature algorithms
+var (
+	minisignAlgLegacy    = []byte("Ed") // Signs the message directly
+	minisignAlgPrehashed = []byte("ED") // Signs the BLAKE2b-512 digest of the message
+)
+
+// TrustOptions carries the per-import trust requirements given on the command line.
+type TrustOptions struct {
+	SHA256    string // Expected hex digest of the import file
+	Signature string // Path or URL of a detached signature, defaults to <source>.minisig
+}
+
+// TrustResult describes why an import is, or is not, trusted.
+type TrustResult struct {
+	Trusted bool
+	Reasons []string // Why the import is trusted
+	Issues  []string // Why the import is not trusted
+}
+
+// publicKey is a parsed Ed25519 key with its optional minisign key ID.
+type publicKey struct {
+	keyID []byte
+	key   ed25519.PublicKey
+}
+
+// evaluateTrust checks an import file against the digest pin, signatures and host allowlist.
+// A digest mismatch or an invalid signature is always an error; otherwise the result reports
+// whether the import is trusted so the caller can apply the configured policy.
+func evaluateTrust(source string, content []byte, digest string, opts TrustOptions, trust config.ImportTrustConfig, auth sourceAuth) (*TrustResult, error) {
+	result := &TrustResult{}
+
+	if opts.SHA256 != "" {
+		if !strings.EqualFold(opts.SHA256, digest) {
+			return nil, fmt.Errorf("sha256 mismatch: expected %s, got %s", strings.ToLower(opts.SHA256), digest)
+		}
+		result.Reasons = append(result.Reasons, "content matches the pinned sha256 digest")
+	}
+
+	if len(trust.PublicKeys) > 0 || opts.Signature != "" {
+		signed, err := verifyImportSignature(source, content, opts.Signature, trust.PublicKeys, auth)
+		if err != nil {
+			return nil, err
+		}
+		if signed {
+			result.Reasons = append(result.Reasons, "signature verified with a trusted public key")
+		} else {
+			result.Issues = append(result.Issues, "no signature found at "+signatureLocation(source))
+		}
+	}
+
+	if host, scheme, remote := sourceHost(source); remote {
+		switch {
+		case scheme != "https" && scheme != "ssh":
+			result.Issues = append(result.Issues, "fetched over insecure "+scheme)
+		case hostAllowed(host, trust.AllowedHosts):
+			result.Reasons = append(result.Reasons, "host "+host+" is in import_trust.allowed_hosts")
+		default:
+			result.Issues = append(result.Issues, "host "+host+" is not in import_trust.allowed_hosts")
+		}
+	} else if trust.AllowLocal {
+		result.Reasons = append(result.Reasons, "local file allowed by import_trust.allow_local")
+	} else {
+		result.Issues = append(result.Issues, "local file is not allowed by import_trust.allow_local")
+	}
+
+	result.Trusted = len(result.Reasons) > 0
+	return result, nil
+}
+
+// enforceTrust applies the configured policy to an evaluated import. Untrusted imports are
+// refused under the strict policy and shown with a prominent warning otherwise.
+func enforceTrust(source string, result *TrustResult, policy string) error {
+	output := palantir.GetGlobalOutputHandler()
+
+	if result.Trusted {
+		output.PrintSuccess(fmt.Sprintf("Import trusted: %s", strings.Join(result.Reasons, ", ")))
+		return nil
+	}
+
+	if policy == config.ImportPolicyStrict {
+		return fmt.Errorf("refusing untrusted import %s under strict policy: %s", source, strings.Join(result.Issues, ", "))
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("%s is not trusted:\n", source))
+	for _, issue := range result.Issues {
+		content.WriteString(fmt.Sprintf("  • %s\n", issue))
+	}
+	content.WriteString("\nPin it with --sha256, sign it, or add its host to import_trust.allowed_hosts (import_trust.allow_local for local files).\n")
+	content.WriteString("Review every change carefully before accepting it.")
+
+	fmt.Println("")
+	fmt.Println(charm.RenderBox("⚠ Untrusted Import", content.String(), "#FF5F87", false))
+	output.PrintWarning("Importing from an untrusted source")
+	return nil
+}
+
+// sourceHost returns the host and transport scheme a remote import is fetched from.
+// It reports false for local files, including git+file repositories.
+func sourceHost(source string) (string, string, bool) {
+	if isGitSource(source) {
+		gitSrc, err := parseGitSource(source)
+		if err != nil || gitSrc.Scheme == "file" {
+			return "", "", false
+		}
+		return gitSrc.Host, gitSrc.Scheme, true
+	}
+	if isURL(source) {
+		parsed, _ := url.Parse(source)
+		return parsed.Hostname(), parsed.Scheme, true
+	}
+	return "", "", false
+}
+
+// signatureLocation returns where the detached signature of an import is looked up by default.
+// For git sources it is the file next to the import file, at the same ref.
+func signatureLocation(source string) string {
+	if isGitSource(source) {
+		if gitSrc, err := parseGitSource(source); err == nil {
+			gitSrc.Path += signatureSuffix
+			return gitSrc.String()
+		}
+	}
+	return source + signatureSuffix
+}
+
+// hostAllowed reports whether a host matches the allowlist. Entries starting with "*."
+// match any subdomain.
+func hostAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if strings.HasPrefix(entry, "*.") {
+			if strings.HasSuffix(host, entry[1:]) {
+				return true
+			}
+			continue
+		}
+		if host == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyImportSignature locates and verifies the detached signature of an import file.
+// It returns false if no signature was found and an error if one was found but is invalid.
+func verifyImportSignature(source string, content []byte, signaturePath string, encodedKeys []string, auth sourceAuth) (bool, error) {
+	if len(encodedKeys) == 0 {
+		return false, fmt.Errorf("a signature was given but no import_trust.public_keys are configured")
+	}
+
+	keys := make([]publicKey, 0, len(encodedKeys))
+	for _, encoded := range encodedKeys {
+		key, err := parsePublicKey(encoded)
+		if err != nil {
+			return false, err
+		}
+		keys = append(keys, key)
+	}
+
+	explicit := signaturePath != ""
+	if !explicit {
+		signaturePath = signatureLocation(source)
+	}
+
+	signature, found, err := readSignature(signaturePath, auth)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		if explicit {
+			return false, fmt.Errorf("signature not found: %s", signaturePath)
+		}
+		return false, nil
+	}
+
+	if err := verifySignature(content, signature, keys); err != nil {
+		return false, fmt.Errorf("signature verification failed for %s: %w", source, err)
+	}
+	return true, nil
+}
+
+// readSignature reads a signature from a URL, git repository or local path, reporting whether it exists.
+func readSignature(location string, auth sourceAuth) ([]byte, bool, error) {
+	if isGitSource(location) {
+		tempFile, cleanup, err := fetchFile(location, auth)
+		if errors.Is(err, errGitFileNotFound) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to fetch signature: %w", err)
+		}
+		defer cleanup()
+
+		data, err := os.ReadFile(tempFile)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read signature: %w", err)
+		}
+		return data, true, nil
+	}
+
+	if !isURL(location) {
+		data, err := os.ReadFile(location)
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read signature: %w", err)
+		}
+		return data, true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := newSourceRequest(ctx, location, auth)
+	if err != nil {
+		return nil, false, err
+	}
+
+	client, err := network.Client()
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to download signature: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("HTTP error %d downloading signature: %s", resp.StatusCode, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read signature: %w", err)
+	}
+	return data, true, nil
+}
+
+// parsePublicKey decodes a minisign public key (with or without its comment line)
+// or a raw base64 encoded 32-byte Ed25519 key.
+func parsePublicKey(encoded string) (publicKey, error) {
+	lines := nonCommentLines(encoded)
+	if len(lines) == 0 {
+		return publicKey{}, fmt.Errorf("empty public key")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return publicKey{}, fmt.Errorf("invalid public key encoding: %w", err)
+	}
+
+	switch {
+	case len(raw) == ed25519.PublicKeySize:
+		return publicKey{key: ed25519.PublicKey(raw)}, nil
+	case len(raw) == 2+8+ed25519.PublicKeySize && bytes.Equal(raw[:2], minisignAlgLegacy):
+		return publicKey{keyID: raw[2:10], key: ed25519.PublicKey(raw[10:])}, nil
+	default:
+		return publicKey{}, fmt.Errorf("unsupported public key format (expected minisign or raw Ed25519)")
+	}
+}
+
+// verifySignature verifies a minisign signature file or a raw base64 Ed25519 signature
+// against any of the trusted keys.
+func verifySignature(content, signature []byte, keys []publicKey) error {
+	lines := nonCommentLines(string(signature))
+	if len(lines) == 0 {
+		return fmt.Errorf("empty signature")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	// Raw Ed25519 signature over the file content
+	if len(raw) == ed25519.SignatureSize {
+		for _, key := range keys {
+			if ed25519.Verify(key.key, content, raw) {
+				return nil
+			}
+		}
+		return fmt.Errorf("signature does not match any trusted public key")
+	}
+
+	return verifyMinisign(content, string(signature), raw, keys)
+}
+
+// verifyMinisign verifies a minisign signature, including its trusted comment.
+func verifyMinisign(content []byte, signatureFile string, raw []byte, keys []publicKey) error {
+	if len(raw) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("unsupported signature format")
+	}
+	algorithm, keyID, sig := raw[:2], raw[2:10], raw[10:]
+
+	message := content
+	switch {
+	case bytes.Equal(algorithm, minisignAlgPrehashed):
+		digest := blake2b.Sum512(content)
+		message = digest[:]
+	case !bytes.Equal(algorithm, minisignAlgLegacy):
+		return fmt.Errorf("unsupported signature algorithm %q", algorithm)
+	}
+
+	trustedComment, globalSig, err := parseTrustedComment(signatureFile)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.keyID != nil && !bytes.Equal(key.keyID, keyID) {
+			continue
+		}
+		if !ed25519.Verify(key.key, message, sig) {
+			continue
+		}
+		if !ed25519.Verify(key.key, append(append([]byte{}, sig...), trustedComment...), globalSig) {
+			return fmt.Errorf("trusted comment signature is invalid")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("signature does not match any trusted public key")
+}
+
+// parseTrustedComment extracts the trusted comment and its global signature from a minisign file.
+func parseTrustedComment(signatureFile string) ([]byte, []byte, error) {
+	const prefix = "trusted comment: "
+
+	lines := strings.Split(strings.ReplaceAll(signatureFile, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, prefix) || i+1 >= len(lines) {
+			continue
+		}
+		globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[i+1]))
+		if err != nil || len(globalSig) != ed25519.SignatureSize {
+			return nil, nil, fmt.Errorf("invalid trusted comment signature")
+		}
+		return []byte(strings.TrimPrefix(line, prefix)), globalSig, nil
+	}
+
+	return nil, nil, fmt.Errorf("signature is missing its trusted comment")
+}
+
+// nonCommentLines returns the trimmed lines that are not minisign comments.
+func nonCommentLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") || strings.HasPrefix(line, "trusted comment:") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
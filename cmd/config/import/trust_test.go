@@ -0,0 +1,275 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importcmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"golang.org/x/crypto/blake2b"
+)
+
+// testSigner produces minisign keys and signatures for tests.
+type testSigner struct {
+	keyID   []byte
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+func newTestSigner(t *testing.T) testSigner {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{keyID: []byte("12345678"), public: public, private: private}
+}
+
+// minisignPublicKey returns the key in minisign format, including its comment line.
+func (s testSigner) minisignPublicKey() string {
+	raw := append(append([]byte("Ed"), s.keyID...), s.public...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw)
+}
+
+// minisignSignature signs content with the given algorithm ("Ed" or "ED").
+func (s testSigner) minisignSignature(content []byte, algorithm string) string {
+	message := content
+	if algorithm == "ED" {
+		digest := blake2b.Sum512(content)
+		message = digest[:]
+	}
+	sig := ed25519.Sign(s.private, message)
+	trustedComment := "timestamp:1700000000\tfile:team.yaml"
+	globalSig := ed25519.Sign(s.private, append(append([]byte{}, sig...), trustedComment...))
+
+	raw := append(append([]byte(algorithm), s.keyID...), sig...)
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSig) + "\n"
+}
+
+func TestVerifySignature(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+	content := []byte("groups:\n  dev: [git]\n")
+
+	key, err := parsePublicKey(signer.minisignPublicKey())
+	if err != nil {
+		t.Fatalf("Failed to parse minisign key: %v", err)
+	}
+	rawKey, err := parsePublicKey(base64.StdEncoding.EncodeToString(signer.public))
+	if err != nil {
+		t.Fatalf("Failed to parse raw key: %v", err)
+	}
+	otherKey, err := parsePublicKey(other.minisignPublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		content   []byte
+		signature string
+		keys      []publicKey
+		wantErr   string
+	}{
+		{"minisign legacy", content, signer.minisignSignature(content, "Ed"), []publicKey{key}, ""},
+		{"minisign prehashed", content, signer.minisignSignature(content, "ED"), []publicKey{key}, ""},
+		{"raw signature", content, base64.StdEncoding.EncodeToString(ed25519.Sign(signer.private, content)), []publicKey{rawKey}, ""},
+		{"any trusted key", content, signer.minisignSignature(content, "ED"), []publicKey{otherKey, key}, ""},
+		{"tampered content", []byte("groups:\n  dev: [curl]\n"), signer.minisignSignature(content, "ED"), []publicKey{key}, "does not match"},
+		{"untrusted key", content, signer.minisignSignature(content, "ED"), []publicKey{otherKey}, "does not match"},
+		{"tampered trusted comment", content, strings.Replace(signer.minisignSignature(content, "ED"), "team.yaml", "evil.yaml", 1), []publicKey{key}, "trusted comment"},
+		{"garbage", content, "not a signature", []publicKey{key}, "encoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.content, []byte(tt.signature), tt.keys)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid signature, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"raw.githubusercontent.com", "*.example.com"}
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"raw.githubusercontent.com", true},
+		{"RAW.githubusercontent.com", true},
+		{"configs.example.com", true},
+		{"a.b.example.com", true},
+		{"example.com", false},
+		{"evilexample.com", false},
+		{"githubusercontent.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := hostAllowed(tt.host, allowed); got != tt.expected {
+			t.Errorf("hostAllowed(%q) = %v, expected %v", tt.host, got, tt.expected)
+		}
+	}
+}
+
+func TestEvaluateTrust(t *testing.T) {
+	signer := newTestSigner(t)
+	content := []byte("groups:\n  dev: [git]\n")
+	digest := contentSHA256(content)
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "team.yaml")
+	if err := os.WriteFile(source, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("unlisted remote host is untrusted", func(t *testing.T) {
+		result, err := evaluateTrust("https://example.com/team.yaml", content, digest, TrustOptions{}, config.ImportTrustConfig{}, sourceAuth{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Trusted || len(result.Issues) == 0 {
+			t.Errorf("Expected untrusted result with issues, got %+v", result)
+		}
+	})
+
+	t.Run("allowlisted host is trusted", func(t *testing.T) {
+		trust := config.ImportTrustConfig{AllowedHosts: []string{"example.com"}}
+		result, err := evaluateTrust("https://example.com/team.yaml", content, digest, TrustOptions{}, trust, sourceAuth{})
+		if err != nil || !result.Trusted {
+			t.Errorf("Expected trusted result, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("allowlist requires https", func(t *testing.T) {
+		trust := config.ImportTrustConfig{AllowedHosts: []string{"example.com"}}
+		result, err := evaluateTrust("http://example.com/team.yaml", content, digest, TrustOptions{}, trust, sourceAuth{})
+		if err != nil || result.Trusted {
+			t.Errorf("Expected untrusted result, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("local files are trusted only when allowed", func(t *testing.T) {
+		for _, local := range []string{source, "git+file://" + dir + "#team.yaml"} {
+			result, err := evaluateTrust(local, content, digest, TrustOptions{}, config.ImportTrustConfig{}, sourceAuth{})
+			if err != nil || result.Trusted {
+				t.Errorf("%s: expected untrusted result, got %+v, %v", local, result, err)
+			}
+			if err := enforceTrust(local, result, config.ImportPolicyStrict); err == nil {
+				t.Errorf("%s: expected strict policy to refuse an unlisted local file", local)
+			}
+
+			result, err = evaluateTrust(local, content, digest, TrustOptions{}, config.ImportTrustConfig{AllowLocal: true}, sourceAuth{})
+			if err != nil || !result.Trusted {
+				t.Errorf("%s: expected trusted result with allow_local, got %+v, %v", local, result, err)
+			}
+		}
+	})
+
+	t.Run("pinned digest is trusted", func(t *testing.T) {
+		result, err := evaluateTrust("http://example.com/team.yaml", content, digest, TrustOptions{SHA256: strings.ToUpper(digest)}, config.ImportTrustConfig{}, sourceAuth{})
+		if err != nil || !result.Trusted {
+			t.Errorf("Expected trusted result, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("digest mismatch fails", func(t *testing.T) {
+		_, err := evaluateTrust(source, content, digest, TrustOptions{SHA256: strings.Repeat("0", 64)}, config.ImportTrustConfig{}, sourceAuth{})
+		if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+			t.Errorf("Expected sha256 mismatch, got %v", err)
+		}
+	})
+
+	t.Run("signature without keys fails", func(t *testing.T) {
+		_, err := evaluateTrust(source, content, digest, TrustOptions{Signature: source + ".minisig"}, config.ImportTrustConfig{}, sourceAuth{})
+		if err == nil || !strings.Contains(err.Error(), "public_keys") {
+			t.Errorf("Expected missing keys error, got %v", err)
+		}
+	})
+
+	trust := config.ImportTrustConfig{PublicKeys: []string{signer.minisignPublicKey()}}
+
+	t.Run("missing default signature is not an error", func(t *testing.T) {
+		result, err := evaluateTrust(source, content, digest, TrustOptions{}, trust, sourceAuth{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Trusted || !strings.Contains(strings.Join(result.Issues, ","), "no signature found") {
+			t.Errorf("Expected missing signature issue, got %+v", result)
+		}
+	})
+
+	t.Run("missing explicit signature fails", func(t *testing.T) {
+		_, err := evaluateTrust(source, content, digest, TrustOptions{Signature: filepath.Join(dir, "missing.minisig")}, trust, sourceAuth{})
+		if err == nil || !strings.Contains(err.Error(), "signature not found") {
+			t.Errorf("Expected signature not found, got %v", err)
+		}
+	})
+
+	if err := os.WriteFile(source+signatureSuffix, []byte(signer.minisignSignature(content, "ED")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("detached signature is verified", func(t *testing.T) {
+		result, err := evaluateTrust(source, content, digest, TrustOptions{}, trust, sourceAuth{})
+		if err != nil || !result.Trusted {
+			t.Errorf("Expected trusted result, got %+v, %v", result, err)
+		}
+		if !strings.Contains(strings.Join(result.Reasons, ","), "signature verified") {
+			t.Errorf("Expected signature reason, got %v", result.Reasons)
+		}
+	})
+
+	t.Run("invalid signature fails", func(t *testing.T) {
+		tampered := []byte("groups:\n  dev: [curl]\n")
+		_, err := evaluateTrust(source, tampered, contentSHA256(tampered), TrustOptions{}, trust, sourceAuth{})
+		if err == nil || !strings.Contains(err.Error(), "signature verification failed") {
+			t.Errorf("Expected verification failure, got %v", err)
+		}
+	})
+}
+
+func TestEnforceTrust(t *testing.T) {
+	untrusted := &TrustResult{Issues: []string{"host example.com is not in import_trust.allowed_hosts"}}
+
+	if err := enforceTrust("https://example.com/team.yaml", untrusted, config.ImportPolicyStrict); err == nil {
+		t.Error("Expected strict policy to refuse untrusted import")
+	}
+	if err := enforceTrust("https://example.com/team.yaml", untrusted, ""); err != nil {
+		t.Errorf("Expected default policy to warn, got %v", err)
+	}
+	if err := enforceTrust("team.yaml", &TrustResult{Trusted: true, Reasons: []string{"local file"}}, config.ImportPolicyStrict); err != nil {
+		t.Errorf("Expected trusted import to pass, got %v", err)
+	}
+}
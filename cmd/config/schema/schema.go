@@ -0,0 +1,73 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schema provides functionality to print the JSON Schema for
+// anvil settings and import files for editor completion and validation.
+package schema
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/palantir"
+	"github.com/spf13/cobra"
+)
+
+var SchemaCmd = &cobra.Command{
+	Use:       "schema [settings|import]",
+	Short:     "Print the JSON Schema for settings.yaml or import files",
+	Long:      constants.SCHEMA_COMMAND_LONG_DESCRIPTION,
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"settings", "import"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSchemaCommand(cmd, args)
+	},
+	Example: `  anvil config schema                                   # Print the settings.yaml schema
+  anvil config schema import                            # Print the import file schema
+  anvil config schema -o ~/.anvil/settings.schema.json  # Write the settings schema to a file`,
+}
+
+func init() {
+	SchemaCmd.Flags().StringP("output", "o", "", "Write the schema to a file instead of stdout")
+}
+
+// runSchemaCommand renders the requested schema to stdout or a file
+func runSchemaCommand(cmd *cobra.Command, args []string) error {
+	schema := config.SettingsSchema()
+	if len(args) > 0 && args[0] == "import" {
+		schema = config.ImportSchema()
+	}
+
+	data, err := schema.JSON()
+	if err != nil {
+		return errors.NewConfigurationError(constants.OpConfig, "schema", err)
+	}
+
+	outputPath, _ := cmd.Flags().GetString("output")
+	if outputPath == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, data, constants.FilePerm); err != nil {
+		return errors.NewFileSystemError(constants.OpConfig, "schema", err)
+	}
+	palantir.GetGlobalOutputHandler().PrintSuccess(fmt.Sprintf("Schema written to %s", outputPath))
+	return nil
+}
@@ -120,14 +120,21 @@ func showSourcesSection(anvilConfig *config.AnvilConfig) error {
 		boxContent.WriteString("  No installation sources configured.\n")
 		boxContent.WriteString("  Add sources to your settings.yaml to configure installation URLs or commands.\n")
 	} else {
-		for appName, url := range anvilConfig.Sources {
-			boxContent.WriteString(fmt.Sprintf("    %s: %s\n", utils.ColorAppName(appName), url))
+		for appName, source := range anvilConfig.Sources {
+			if !source.IsPerPlatform() {
+				boxContent.WriteString(fmt.Sprintf("    %s: %s\n", utils.ColorAppName(appName), source.URL))
+				continue
+			}
+			boxContent.WriteString(fmt.Sprintf("    %s:\n", utils.ColorAppName(appName)))
+			for _, platform := range source.PlatformKeys() {
+				boxContent.WriteString(fmt.Sprintf("      %s: %s\n", platform, source.Platforms[platform]))
+			}
 		}
 	}
 
 	fmt.Println(charm.RenderBox("Installation Sources", boxContent.String(), "#E0C867", false))
 	fmt.Println()
-	fmt.Println("  💡 Sources map app names to their installation URLs or commands")
+	fmt.Println("  💡 Sources map app names to their installation URLs or commands, per platform if needed")
 	fmt.Println()
 
 	return nil
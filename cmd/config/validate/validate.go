@@ -0,0 +1,71 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validate provides functionality to check settings files for
+// problems and report them with their line numbers.
+package validate
+
+import (
+	"fmt"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/palantir"
+	"github.com/spf13/cobra"
+)
+
+var ValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate a settings file and report problems with line numbers",
+	Long:  constants.VALIDATE_COMMAND_LONG_DESCRIPTION,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runValidateCommand(cmd, args)
+	},
+	Example: `  anvil config validate                      # Validate ~/.anvil/settings.yaml
+  anvil config validate ./team-settings.yaml  # Validate another settings file`,
+}
+
+// runValidateCommand executes the settings validation process
+func runValidateCommand(cmd *cobra.Command, args []string) error {
+	o := palantir.GetGlobalOutputHandler()
+
+	path := config.AnvilConfigPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	o.PrintHeader("Validate Settings")
+	o.PrintStage(fmt.Sprintf("Checking %s...", path))
+
+	diagnostics, err := config.DiagnoseSettingsFile(path)
+	if err != nil {
+		return errors.NewFileSystemError(constants.OpConfig, "validate", err)
+	}
+
+	if len(diagnostics) == 0 {
+		o.PrintSuccess(fmt.Sprintf("%s is valid", path))
+		return nil
+	}
+
+	for _, diagnostic := range diagnostics {
+		o.PrintError("%s", diagnostic)
+	}
+
+	return errors.NewValidationError(constants.OpConfig, "validate",
+		fmt.Errorf("found %d problem(s) in %s", len(diagnostics), path))
+}
@@ -27,7 +27,7 @@ import (
 // toolStatus represents the status of a tool installation.
 type toolStatus struct {
 	name   string
-	status string // pending, installing, done, failed
+	status string // pending, installing, done, failed, skipped
 	emoji  string
 }
 
@@ -36,6 +36,7 @@ const (
 	toolStatusInstalling = "installing"
 	toolStatusDone       = "done"
 	toolStatusFailed     = "failed"
+	toolStatusSkipped    = "skipped"
 )
 
 // printInstallDashboard displays the current installation progress.
@@ -51,6 +52,8 @@ func printInstallDashboard(groupName string, statuses []toolStatus, current, tot
 			statusText = fmt.Sprintf("%-20s %s %-15s", status.name, status.emoji, constants.StatusInstalled)
 		case toolStatusFailed:
 			statusText = fmt.Sprintf("%-20s %s %-15s", status.name, status.emoji, constants.StatusFailed)
+		case toolStatusSkipped:
+			statusText = fmt.Sprintf("%-20s %s %-15s", status.name, status.emoji, constants.StatusSkipped)
 		case toolStatusInstalling:
 			statusText = fmt.Sprintf("%-20s %s %-15s", status.name, status.emoji, constants.StatusInstalling)
 		default:
@@ -21,10 +21,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/0xjuanma/anvil/internal/brew"
 	"github.com/0xjuanma/anvil/internal/config"
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/0xjuanma/anvil/internal/errors"
 	"github.com/0xjuanma/anvil/internal/installer"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
 	"github.com/0xjuanma/palantir"
 )
 
@@ -38,8 +40,11 @@ func installGroup(opts InstallGroupOptions) error {
 			fmt.Errorf("group '%s' has no tools defined", opts.GroupName))
 	}
 
+	// Snapshot installed apps once so the whole group is checked in memory
+	availability := loadAvailability()
+
 	// Deduplicate tools within the group and update settings if needed
-	deduplicatedTools, err := deduplicateGroupTools(opts.GroupName, opts.Tools)
+	deduplicatedTools, err := deduplicateGroupTools(opts.GroupName, opts.Tools, availability)
 	if err != nil {
 		o.PrintWarning("Failed to deduplicate group tools: %v", err)
 	} else {
@@ -47,24 +52,49 @@ func installGroup(opts InstallGroupOptions) error {
 	}
 
 	o.PrintInfo("Installing %d tools: %s", len(opts.Tools), strings.Join(opts.Tools, ", "))
+	if availability != nil {
+		if available, _ := availability.Partition(opts.Tools); len(available) > 0 {
+			o.PrintInfo("%d of %d tools already available", len(available), len(opts.Tools))
+		}
+	}
 
 	if opts.Concurrent {
-		return installGroupConcurrent(opts)
+		return installGroupConcurrent(opts, availability)
 	}
 
-	return installGroupSerial(opts.GroupName, opts.Tools, opts.DryRun)
+	return installGroupSerial(opts.GroupName, opts.Tools, opts.DryRun, availability)
+}
+
+// loadAvailability snapshots installed apps, returning nil to fall back to
+// checking each tool when the snapshot cannot be taken
+func loadAvailability() *brew.AvailabilitySnapshot {
+	spinner := charm.NewDotsSpinner("Checking installed apps")
+	spinner.Start()
+	availability, err := brew.LoadAvailabilitySnapshot(brew.AvailabilityCacheTTL)
+	if err != nil {
+		spinner.Warning(fmt.Sprintf("Checking apps one by one: %v", err))
+		return nil
+	}
+	spinner.Success("Checked installed apps")
+	return availability
 }
 
 // deduplicateGroupTools removes duplicate tools within a group and updates the settings file.
-func deduplicateGroupTools(groupName string, tools []string) ([]string, error) {
+// Names that resolve to the same package, such as "code" aliased to "visual-studio-code"
+// or "terraform" and an installed "hashicorp/tap/terraform", are duplicates too.
+func deduplicateGroupTools(groupName string, tools []string, availability *brew.AvailabilitySnapshot) ([]string, error) {
 	seen := make(map[string]struct{}, len(tools))
 	deduplicatedTools := make([]string, 0, len(tools))
 	var duplicatesFound []string
 
 	// Deduplicate
 	for _, tool := range tools {
-		if _, exists := seen[tool]; !exists {
-			seen[tool] = struct{}{}
+		key := config.ResolveAppName(tool)
+		if installedName, installed := availability.InstalledName(key); installed {
+			key = installedName
+		}
+		if _, exists := seen[key]; !exists {
+			seen[key] = struct{}{}
 			deduplicatedTools = append(deduplicatedTools, tool)
 		} else {
 			duplicatesFound = append(duplicatesFound, tool)
@@ -90,7 +120,7 @@ func deduplicateGroupTools(groupName string, tools []string) ([]string, error) {
 }
 
 // installGroupConcurrent installs tools concurrently.
-func installGroupConcurrent(opts InstallGroupOptions) error {
+func installGroupConcurrent(opts InstallGroupOptions, availability *brew.AvailabilitySnapshot) error {
 	o := palantir.GetGlobalOutputHandler()
 
 	// Create new output handler to send into concurrent installer
@@ -100,6 +130,7 @@ func installGroupConcurrent(opts InstallGroupOptions) error {
 	if opts.Timeout > 0 {
 		concurrentInstaller.SetTimeout(opts.Timeout)
 	}
+	concurrentInstaller.SetAvailability(availability)
 
 	// Create context with potential cancellation
 	ctx := context.Background()
@@ -115,11 +146,11 @@ func installGroupConcurrent(opts InstallGroupOptions) error {
 }
 
 // installGroupSerial installs tools serially using unified installation logic.
-func installGroupSerial(groupName string, tools []string, dryRun bool) error {
+func installGroupSerial(groupName string, tools []string, dryRun bool, availability *brew.AvailabilitySnapshot) error {
 	o := palantir.GetGlobalOutputHandler()
 
 	successCount := 0
-	var installErrors []string
+	var installErrors, skipped []string
 
 	// Initialize tool statuses
 	toolStatuses := make([]toolStatus, len(tools))
@@ -140,9 +171,13 @@ func installGroupSerial(groupName string, tools []string, dryRun bool) error {
 		printInstallDashboard(groupName, toolStatuses, i+1, len(tools))
 
 		// Use unified installation logic
-		_, err := installSingleToolUnified(tool, dryRun)
+		_, err := installSingleToolUnified(tool, dryRun, availability)
 
-		if err != nil {
+		if installer.IsSourceSkip(err) {
+			toolStatuses[i].status = toolStatusSkipped
+			toolStatuses[i].emoji = "⊘"
+			skipped = append(skipped, fmt.Sprintf("%s: %v", tool, err))
+		} else if err != nil {
 			toolStatuses[i].status = toolStatusFailed
 			toolStatuses[i].emoji = "✗"
 			errorMsg := fmt.Sprintf("%s: %v", tool, err)
@@ -158,5 +193,5 @@ func installGroupSerial(groupName string, tools []string, dryRun bool) error {
 		printInstallDashboard(groupName, toolStatuses, i+1, len(tools))
 	}
 
-	return reportGroupInstallationResults(groupName, successCount, len(tools), installErrors)
+	return reportGroupInstallationResults(groupName, successCount, len(tools), installErrors, skipped)
 }
@@ -39,7 +39,24 @@ func installIndividualApp(appName string, dryRun bool, cmd *cobra.Command) error
 			fmt.Errorf("application name cannot be empty"))
 	}
 
-	wasNewlyInstalled, err := installSingleToolUnified(appName, dryRun)
+	// Offer similar packages for unknown names, before installing when the index can tell
+	// and otherwise when the install fails
+	yesFirstMatch, _ := cmd.Flags().GetBool("yes-first-match")
+	offered := false
+	if isUnknownApp(appName) {
+		offered = true
+		resolveUnknownApp(appName, yesFirstMatch, dryRun)
+	}
+
+	wasNewlyInstalled, err := installSingleToolUnified(appName, dryRun, nil)
+	if installer.IsSourceSkip(err) {
+		return nil
+	}
+	if err != nil && !offered && config.ResolveAppName(appName) == appName {
+		if resolveUnknownApp(appName, yesFirstMatch, dryRun) {
+			wasNewlyInstalled, err = installSingleToolUnified(appName, dryRun, nil)
+		}
+	}
 	if err != nil {
 		return errors.NewInstallationError(constants.OpInstall, appName,
 			fmt.Errorf("failed to install '%s'. Please verify the name is correct. You can search for packages using 'brew search %s'", appName, appName))
@@ -76,11 +93,11 @@ func installSingleTool(toolName string) error {
 	if sourceErr != nil {
 		o.PrintWarning("Failed to check source URL for %s: %v", toolName, sourceErr)
 		// Fall back to brew if we can't check source
-		return brew.InstallPackageDirectly(toolName)
-	}
-
-	// If source exists, try it first (user explicitly configured it)
-	if exists && sourceURL != "" {
+		if err := installer.InstallWithBrew(toolName); err != nil {
+			return err
+		}
+	} else if exists && sourceURL != "" {
+		// If source exists, try it first (user explicitly configured it)
 		o.PrintInfo("Installing %s from configured source", toolName)
 		if err := installer.InstallFromSource(toolName, sourceURL); err != nil {
 			// Check if extraction succeeded but installation failed
@@ -90,17 +107,29 @@ func installSingleTool(toolName string) error {
 				return nil
 			}
 			// Source installation failed, fall back to brew
-			o.PrintInfo("Source installation failed, falling back to brew for %s", toolName)
-			return brew.InstallPackageDirectly(toolName)
+			o.PrintWarning("Source installation failed for %s: %v", toolName, err)
+			o.PrintInfo("Falling back to brew for %s", toolName)
+			if err := installer.InstallWithBrew(toolName); err != nil {
+				return err
+			}
 		}
-		// Source installation succeeded, continue with post-install steps
 	} else {
 		// No source configured, use brew (default for majority of apps)
-		if err := brew.InstallPackageDirectly(toolName); err != nil {
+		if err := installer.InstallWithBrew(toolName); err != nil {
 			return err
 		}
 	}
 
+	// The source or brew install succeeded; run user-configured post-install hooks
+	if err := installer.RunPostInstallHooks(toolName); err != nil {
+		o.PrintWarning("%v", err)
+	}
+
+	// Start or restart the app's brew service if configured
+	if err := installer.ApplyServiceAction(toolName); err != nil {
+		o.PrintWarning("%v", err)
+	}
+
 	// Handle config check for git
 	if toolName == "git" {
 		if err := checkToolConfiguration(toolName); err != nil {
@@ -112,18 +141,30 @@ func installSingleTool(toolName string) error {
 }
 
 // installSingleToolUnified provides unified installation logic for all installation modes.
-func installSingleToolUnified(toolName string, dryRun bool) (wasNewlyInstalled bool, err error) {
+// Group installs pass an availability snapshot; a nil snapshot checks the system directly.
+func installSingleToolUnified(toolName string, dryRun bool, availability *brew.AvailabilitySnapshot) (wasNewlyInstalled bool, err error) {
 	o := palantir.GetGlobalOutputHandler()
 
-	// ALWAYS check availability first using the latest IsApplicationAvailable logic
-	if brew.IsApplicationAvailable(toolName) {
+	// ALWAYS check availability first
+	if installer.IsAvailable(toolName, availability) {
 		o.PrintAlreadyAvailable("%s is already available on the system", toolName)
 		return false, nil
 	}
 
+	// Apps whose sources do not cover this platform are skipped rather than failed
+	if _, _, err := installer.SourceURL(toolName); installer.IsSourceSkip(err) {
+		o.PrintWarning("SKIP %s: %v", toolName, err)
+		return false, err
+	}
+
 	// Handle installation based on mode
 	if dryRun {
-		o.PrintInfo("Would install: %s", toolName)
+		if plan := installer.InstallPlan(toolName); plan != "" {
+			o.PrintInfo("Would install: %s (%s)", toolName, plan)
+		} else {
+			o.PrintInfo("Would install: %s", toolName)
+		}
+		installer.PrintPostInstallHooksPlan(o, toolName)
 		return true, nil
 	}
 
@@ -131,6 +172,7 @@ func installSingleToolUnified(toolName string, dryRun bool) (wasNewlyInstalled b
 	if err := installSingleTool(toolName); err != nil {
 		return false, err
 	}
+	brew.InvalidateAvailabilityCache()
 
 	o.PrintSuccess(fmt.Sprintf("%s installed successfully", toolName))
 	return true, nil
@@ -26,6 +26,7 @@ import (
 	"github.com/0xjuanma/anvil/internal/brew"
 	"github.com/0xjuanma/anvil/internal/config"
 	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/installer"
 	"github.com/0xjuanma/anvil/internal/terminal/charm"
 	"github.com/0xjuanma/anvil/internal/tools"
 	"github.com/0xjuanma/anvil/internal/utils"
@@ -103,6 +104,14 @@ func runInstallCommand(cmd *cobra.Command, target string) error {
 		o.PrintInfo("Dry run mode - no actual installations will be performed")
 	}
 
+	// Remote install scripts follow the --trust-scripts policy over the one in settings
+	if cmd.Flags().Changed("trust-scripts") {
+		policy, _ := cmd.Flags().GetString("trust-scripts")
+		if err := installer.SetScriptTrust(policy); err != nil {
+			return fmt.Errorf("install: %w", err)
+		}
+	}
+
 	// Check for concurrent flag
 	concurrent, _ := cmd.Flags().GetBool("concurrent")
 	maxWorkers, _ := cmd.Flags().GetInt("workers")
@@ -115,6 +124,7 @@ func runInstallCommand(cmd *cobra.Command, target string) error {
 
 	// Try to get group tools first
 	if tools, err := config.GroupTools(target); err == nil {
+		ensureTaps(tools, dryRun)
 		opts := InstallGroupOptions{
 			GroupName:  target,
 			Tools:      tools,
@@ -126,16 +136,62 @@ func runInstallCommand(cmd *cobra.Command, target string) error {
 		return installGroup(opts)
 	}
 
+	// Source apps are reinstalled over their previous version on request
+	if upgrade, _ := cmd.Flags().GetBool("upgrade"); upgrade {
+		return upgradeSourceApp(target, dryRun)
+	}
+
 	// If not a group, treat as individual application
+	ensureTaps([]string{target}, dryRun)
 	return installIndividualApp(target, dryRun, cmd)
 }
 
+// ensureTaps adds the Homebrew taps from settings, and those inferred from tap-qualified
+// tools, that are not tapped yet. Failures are reported but do not stop installs that do
+// not need the tap.
+func ensureTaps(tools []string, dryRun bool) {
+	o := palantir.GetGlobalOutputHandler()
+
+	// Without settings, tap-qualified tools still get their taps
+	cfg, _ := config.LoadConfig()
+	taps := config.RequiredTaps(cfg, tools)
+	if len(taps) == 0 {
+		return
+	}
+
+	tapped, err := brew.TappedRepositories()
+	if err != nil {
+		o.PrintWarning("Could not list Homebrew taps: %v", err)
+		return
+	}
+
+	for _, tap := range config.MissingTaps(taps, tapped) {
+		if dryRun {
+			o.PrintInfo("Would tap %s", tap.Name)
+			continue
+		}
+
+		spinner := charm.NewDotsSpinner(fmt.Sprintf("Tapping %s", tap.Name))
+		spinner.Start()
+		if err := brew.Tap(tap.Name, tap.URL); err != nil {
+			spinner.Warning(fmt.Sprintf("Failed to tap %s", tap.Name))
+			o.PrintWarning("%v", err)
+			continue
+		}
+		spinner.Success(fmt.Sprintf("Tapped %s", tap.Name))
+	}
+}
+
 func init() {
 	// Add flags for additional functionality
 	InstallCmd.Flags().Bool("dry-run", false, "Show what would be installed without installing")
 	InstallCmd.Flags().Bool("list", false, "List all available groups")
 	InstallCmd.Flags().Bool("tree", false, "Display all applications in a tree format")
+	InstallCmd.Flags().Bool("yes-first-match", false, "Install the closest match without asking when an app name is unknown")
 	InstallCmd.Flags().Bool("update", false, "Update Homebrew before installation")
+	InstallCmd.Flags().Bool("upgrade", false, "Reinstall an app installed from a source, replacing its previous version")
+	InstallCmd.Flags().String("trust-scripts", "", "Run remote install scripts without review: 'all' runs any script, 'pinned' only those with a pinned sha256 (default when given: all)")
+	InstallCmd.Flags().Lookup("trust-scripts").NoOptDefVal = config.ScriptTrustAll
 	InstallCmd.Flags().String("group-name", "", "Add the installed app to a group (creates group if it doesn't exist)")
 
 	// Add concurrent installation flags
@@ -0,0 +1,104 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"fmt"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/installer"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+	"github.com/0xjuanma/palantir"
+)
+
+// maxSuggestions is how many "did you mean" candidates are offered for an unknown app name
+const maxSuggestions = 5
+
+// isUnknownApp reports whether an app name is known not to be a Homebrew package. Only the
+// full index can tell; aliased, source-installed and third-party tap apps are never unknown.
+func isUnknownApp(appName string) bool {
+	index := brew.CurrentIndex()
+	if index.Partial || index.Has(appName) || config.ResolveAppName(appName) != appName {
+		return false
+	}
+	if _, qualified := brew.TapFromPackage(appName); qualified {
+		return false
+	}
+	if _, hasSource, _ := installer.SourceURL(appName); hasSource {
+		return false
+	}
+	return true
+}
+
+// resolveUnknownApp offers the packages an unknown app name may refer to and remembers the
+// pick as an alias, so the name keeps working in groups and later installs. With
+// yesFirstMatch the closest match is picked without asking. Dry runs only list suggestions.
+func resolveUnknownApp(appName string, yesFirstMatch, dryRun bool) bool {
+	o := palantir.GetGlobalOutputHandler()
+
+	aliases, err := config.UserAliases()
+	if err != nil {
+		o.PrintWarning("Failed to load aliases: %v", err)
+	}
+
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Looking for packages similar to '%s'", appName))
+	spinner.Start()
+	searchResults, err := brew.SearchPackages(appName)
+	if err != nil {
+		spinner.Warning(fmt.Sprintf("Homebrew search failed: %v", err))
+	} else {
+		spinner.Success("Search completed")
+	}
+
+	suggestions := brew.SuggestPackages(appName, aliases, brew.CurrentIndex(), searchResults, maxSuggestions)
+	if len(suggestions) == 0 {
+		o.PrintWarning("No packages similar to '%s' found", appName)
+		return false
+	}
+
+	o.PrintInfo("'%s' is not a known Homebrew package. Did you mean:", appName)
+	for _, suggestion := range suggestions {
+		o.PrintInfo("  • %s (%s)", suggestion.Name, suggestion.Source)
+	}
+	if dryRun {
+		return false
+	}
+
+	choice := ""
+	if yesFirstMatch {
+		choice = suggestions[0].Name
+		o.PrintInfo("Using closest match '%s'", choice)
+	} else {
+		for _, suggestion := range suggestions {
+			if o.Confirm(fmt.Sprintf("Install '%s' for '%s'?", suggestion.Name, appName)) {
+				choice = suggestion.Name
+				break
+			}
+		}
+	}
+	if choice == "" {
+		return false
+	}
+
+	if err := config.SetAppAlias(appName, choice); err != nil {
+		o.PrintWarning("Failed to remember alias '%s': %v", appName, err)
+		return false
+	}
+	o.PrintSuccess(fmt.Sprintf("Remembered '%s' as an alias of '%s' in settings", appName, choice))
+	return true
+}
@@ -51,12 +51,19 @@ func trackAppInSettings(appName string) error {
 }
 
 // reportGroupInstallationResults provides unified error reporting for group installations.
-func reportGroupInstallationResults(groupName string, successCount, totalCount int, installErrors []string) error {
+func reportGroupInstallationResults(groupName string, successCount, totalCount int, installErrors, skipped []string) error {
 	// Print summary
 	o := palantir.GetGlobalOutputHandler()
 	o.PrintHeader("Group Installation Complete")
 	o.PrintInfo("Successfully installed %d of %d tools", successCount, totalCount)
 
+	if len(skipped) > 0 {
+		o.PrintWarning("Skipped installations:")
+		for _, skip := range skipped {
+			o.PrintWarning("  • %s", skip)
+		}
+	}
+
 	if len(installErrors) > 0 {
 		o.PrintWarning("Some installations failed:")
 		for _, err := range installErrors {
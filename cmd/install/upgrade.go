@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"fmt"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/anvil/internal/installer"
+	"github.com/0xjuanma/anvil/internal/manifest"
+	"github.com/0xjuanma/palantir"
+)
+
+// upgradeSourceApp reinstalls an app installed from a source, even though it is already
+// available. Files of the previous install that the new one does not recreate are removed
+// using its manifest.
+func upgradeSourceApp(appName string, dryRun bool) error {
+	o := palantir.GetGlobalOutputHandler()
+	o.PrintHeader(fmt.Sprintf("Upgrading '%s'", appName))
+
+	if err := manifest.ValidateName(appName); err != nil {
+		return errors.NewInstallationError(constants.OpInstall, appName, err)
+	}
+	if !manifest.Exists(appName) {
+		return errors.NewInstallationError(constants.OpInstall, appName,
+			fmt.Errorf("'%s' has no install manifest; --upgrade only applies to apps anvil installed from sources", appName))
+	}
+	previous, err := manifest.Load(appName)
+	if err != nil {
+		return errors.NewInstallationError(constants.OpInstall, appName, err)
+	}
+
+	sourceURL, exists, err := installer.SourceURL(appName)
+	if installer.IsSourceSkip(err) {
+		o.PrintWarning("SKIP %s: %v", appName, err)
+		return nil
+	}
+	if err != nil {
+		return errors.NewInstallationError(constants.OpInstall, appName, err)
+	}
+	if !exists {
+		return errors.NewInstallationError(constants.OpInstall, appName,
+			fmt.Errorf("'%s' no longer has a source in settings.yaml; use 'anvil uninstall %s' to remove it", appName, appName))
+	}
+
+	installed := previous.Version
+	if installed == "" {
+		installed = "installed " + previous.InstalledAt
+	}
+	if dryRun {
+		o.PrintInfo("Would reinstall %s (%s) from %s", appName, installed, sourceURL)
+		return nil
+	}
+
+	o.PrintInfo("Reinstalling %s (%s) from configured source", appName, installed)
+	if err := installer.InstallFromSource(appName, sourceURL); err != nil {
+		return errors.NewInstallationError(constants.OpInstall, appName, err)
+	}
+	if err := installer.RunPostInstallHooks(appName); err != nil {
+		o.PrintWarning("%v", err)
+	}
+
+	o.PrintSuccess(fmt.Sprintf("%s upgraded successfully", appName))
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package outdated provides functionality to check apps installed from sources for
+// newer versions and reinstall the outdated ones.
+package outdated
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/anvil/internal/installer"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+	"github.com/0xjuanma/palantir"
+	"github.com/spf13/cobra"
+)
+
+// OutdatedCmd represents the outdated command.
+var OutdatedCmd = &cobra.Command{
+	Use:   "outdated [app-name...]",
+	Short: "Check apps installed from sources for newer versions",
+	Long:  constants.OUTDATED_COMMAND_LONG_DESCRIPTION,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runOutdatedCommand(cmd, args)
+	},
+	Example: `  anvil outdated               # Check every app installed from a source
+  anvil outdated lazygit       # Check a single app
+  anvil outdated --upgrade     # Reinstall outdated apps without asking`,
+}
+
+// runOutdatedCommand lists source apps with newer versions and offers to reinstall them
+func runOutdatedCommand(cmd *cobra.Command, apps []string) error {
+	upgrade, _ := cmd.Flags().GetBool("upgrade")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	o := palantir.GetGlobalOutputHandler()
+	o.PrintHeader("Source App Updates")
+
+	spinner := charm.NewDotsSpinner("Checking apps installed from sources")
+	spinner.Start()
+	checks, err := installer.CheckForUpdates(apps)
+	if err != nil {
+		spinner.Error("Failed to check for updates")
+		return errors.NewInstallationError(constants.OpOutdated, strings.Join(apps, ","), err)
+	}
+	spinner.Success(fmt.Sprintf("Checked %d app(s)", len(checks)))
+
+	if len(checks) == 0 {
+		o.PrintInfo("No apps installed from URL or GitHub release sources")
+		return nil
+	}
+
+	var outdated []installer.UpdateCheck
+	for i, check := range checks {
+		branch := "├──"
+		if i == len(checks)-1 {
+			branch = "└──"
+		}
+		switch {
+		case check.Err != nil:
+			o.PrintInfo("%s %s: unknown (%v)", branch, check.App, check.Err)
+		case check.Outdated:
+			outdated = append(outdated, check)
+			o.PrintInfo("%s %s: %s → %s ⚠", branch, check.App, check.Installed, check.Latest)
+		default:
+			o.PrintInfo("%s %s: up to date (%s)", branch, check.App, check.Installed)
+		}
+	}
+
+	if len(outdated) == 0 {
+		o.PrintSuccess("Apps installed from sources are up to date")
+		return nil
+	}
+	if dryRun {
+		o.PrintInfo("Dry run: would reinstall %d outdated app(s)", len(outdated))
+		return nil
+	}
+	if !upgrade && !o.Confirm(fmt.Sprintf("Reinstall %d outdated app(s) from their sources?", len(outdated))) {
+		o.PrintInfo("Reinstall later with 'anvil outdated --upgrade' or 'anvil install <app> --upgrade'")
+		return nil
+	}
+
+	return reinstall(outdated)
+}
+
+// reinstall installs outdated apps again from their configured source, which replaces
+// the previous version using its manifest
+func reinstall(outdated []installer.UpdateCheck) error {
+	o := palantir.GetGlobalOutputHandler()
+
+	var failed []string
+	for _, check := range outdated {
+		o.PrintStage(fmt.Sprintf("Reinstalling %s", check.App))
+		if err := installer.InstallFromSource(check.App, check.Source); err != nil {
+			o.PrintError("%s: %v", check.App, err)
+			failed = append(failed, check.App)
+			continue
+		}
+		if err := installer.RunPostInstallHooks(check.App); err != nil {
+			o.PrintWarning("%v", err)
+		}
+		o.PrintSuccess(fmt.Sprintf("%s upgraded successfully", check.App))
+	}
+
+	if len(failed) > 0 {
+		return errors.NewInstallationError(constants.OpOutdated, strings.Join(failed, ","),
+			fmt.Errorf("failed to reinstall %d app(s): %s", len(failed), strings.Join(failed, ", ")))
+	}
+	return nil
+}
+
+func init() {
+	OutdatedCmd.Flags().BoolP("upgrade", "u", false, "Reinstall outdated apps without asking")
+	OutdatedCmd.Flags().BoolP("dry-run", "n", false, "Only report outdated apps")
+}
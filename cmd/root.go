@@ -22,11 +22,15 @@ package cmd
 import (
 	"fmt"
 
+	brewcmd "github.com/0xjuanma/anvil/cmd/brew"
 	"github.com/0xjuanma/anvil/cmd/clean"
 	"github.com/0xjuanma/anvil/cmd/config"
 	"github.com/0xjuanma/anvil/cmd/doctor"
 	"github.com/0xjuanma/anvil/cmd/initcmd"
 	"github.com/0xjuanma/anvil/cmd/install"
+	"github.com/0xjuanma/anvil/cmd/outdated"
+	"github.com/0xjuanma/anvil/cmd/services"
+	"github.com/0xjuanma/anvil/cmd/uninstall"
 	"github.com/0xjuanma/anvil/cmd/update"
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/spf13/cobra"
@@ -61,10 +65,14 @@ func Execute() error {
 func init() {
 	rootCmd.AddCommand(initcmd.InitCmd)
 	rootCmd.AddCommand(install.InstallCmd)
+	rootCmd.AddCommand(uninstall.UninstallCmd)
+	rootCmd.AddCommand(outdated.OutdatedCmd)
 	rootCmd.AddCommand(config.ConfigCmd)
 	rootCmd.AddCommand(doctor.DoctorCmd)
 	rootCmd.AddCommand(clean.CleanCmd)
 	rootCmd.AddCommand(update.UpdateCmd)
+	rootCmd.AddCommand(services.ServicesCmd)
+	rootCmd.AddCommand(brewcmd.BrewCmd)
 
 	// Add version flag
 	rootCmd.Flags().BoolP("version", "v", false, "Show version information")
@@ -0,0 +1,233 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package services provides functionality to list, start, stop and restart the
+// Homebrew services of apps managed by anvil.
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/palantir"
+	"github.com/spf13/cobra"
+)
+
+// ServicesCmd represents the services command.
+var ServicesCmd = &cobra.Command{
+	Use:   "services [group-or-app]",
+	Short: "List and manage Homebrew services of your apps",
+	Long:  constants.SERVICES_COMMAND_LONG_DESCRIPTION,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := ""
+		if len(args) > 0 {
+			target = args[0]
+		}
+		return runListCommand(target)
+	},
+	Example: `  anvil services                  # Services of all apps in settings
+  anvil services dev              # Services of apps in the dev group
+  anvil services start dev        # Start the services of the dev group
+  anvil services restart redis    # Restart a single app's service`,
+}
+
+func init() {
+	for _, action := range []string{brew.ServiceStart, brew.ServiceStop, brew.ServiceRestart} {
+		ServicesCmd.AddCommand(newActionCommand(action))
+	}
+}
+
+// newActionCommand builds the start, stop or restart subcommand
+func newActionCommand(action string) *cobra.Command {
+	return &cobra.Command{
+		Use:   fmt.Sprintf("%s <group-or-app>", action),
+		Short: fmt.Sprintf("%s the Homebrew services of a group or app", capitalize(action)),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runActionCommand(action, args[0])
+		},
+	}
+}
+
+// serviceRow is a single line of the services listing
+type serviceRow struct {
+	App      string
+	Status   string // Status reported by Homebrew, "not installed" when unknown
+	Running  bool
+	Declared string // Action from the services section, "" if not declared
+}
+
+// NeedsAttention reports whether a declared service is not running
+func (r serviceRow) NeedsAttention() bool {
+	return (r.Declared == brew.ServiceStart || r.Declared == brew.ServiceRestart) && !r.Running
+}
+
+// runListCommand shows the service status of the apps of a group, an app, or all configured apps
+func runListCommand(target string) error {
+	o := palantir.GetGlobalOutputHandler()
+	o.PrintHeader("Homebrew Services")
+
+	cfg, apps, err := loadTargetApps(target)
+	if err != nil {
+		return err
+	}
+
+	services, err := brew.Services()
+	if err != nil {
+		return errors.NewInstallationError(constants.OpServices, target, err)
+	}
+
+	rows := serviceRows(apps, services, cfg.Services)
+	if len(rows) == 0 {
+		o.PrintInfo("No Homebrew services found for %s", describeTarget(target))
+		return nil
+	}
+
+	attention := 0
+	for i, row := range rows {
+		branch := "├──"
+		if i == len(rows)-1 {
+			branch = "└──"
+		}
+		line := fmt.Sprintf("%s %s: %s", branch, row.App, row.Status)
+		if row.Declared != "" {
+			line += fmt.Sprintf(" (declared: %s)", row.Declared)
+		}
+		if row.NeedsAttention() {
+			attention++
+			line += " ⚠"
+		}
+		o.PrintInfo("%s", line)
+	}
+
+	if attention > 0 {
+		o.PrintWarning("%d declared service(s) are not running, start them with 'anvil services start %s'", attention, targetArg(target))
+	}
+	return nil
+}
+
+// runActionCommand starts, stops or restarts the services of a group or app
+func runActionCommand(action, target string) error {
+	o := palantir.GetGlobalOutputHandler()
+	o.PrintHeader(fmt.Sprintf("%s Homebrew Services", capitalize(action)))
+
+	_, apps, err := loadTargetApps(target)
+	if err != nil {
+		return err
+	}
+
+	services, err := brew.Services()
+	if err != nil {
+		return errors.NewInstallationError(constants.OpServices, target, err)
+	}
+
+	var targets []string
+	for _, app := range apps {
+		if _, ok := brew.FindService(services, app); ok {
+			targets = append(targets, app)
+		}
+	}
+	if len(targets) == 0 {
+		return errors.NewInstallationError(constants.OpServices, target,
+			fmt.Errorf("no installed Homebrew services found for %s", describeTarget(target)))
+	}
+
+	var failed []string
+	for _, app := range targets {
+		if err := brew.RunServiceAction(action, app); err != nil {
+			o.PrintError("%v", err)
+			failed = append(failed, app)
+			continue
+		}
+		o.PrintSuccess(fmt.Sprintf("%s: %s", app, action))
+	}
+
+	if len(failed) > 0 {
+		return errors.NewInstallationError(constants.OpServices, target,
+			fmt.Errorf("failed to %s %d service(s): %s", action, len(failed), strings.Join(failed, ", ")))
+	}
+	return nil
+}
+
+// loadTargetApps resolves a group or app name to its apps. Without a target, every app
+// in settings and every app with a declared service is returned.
+func loadTargetApps(target string) (*config.AnvilConfig, []string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, errors.NewConfigurationError(constants.OpServices, "load-config", err)
+	}
+
+	if target == "" {
+		declared := make([]string, 0, len(cfg.Services))
+		for app := range cfg.Services {
+			declared = append(declared, app)
+		}
+		sort.Strings(declared)
+		return cfg, config.MergeGroupTools(config.ConfiguredTools(cfg), declared), nil
+	}
+
+	if tools, err := config.GroupTools(target); err == nil {
+		return cfg, tools, nil
+	}
+	return cfg, []string{target}, nil
+}
+
+// serviceRows lists the apps that Homebrew has a service for, or that declare one in settings
+func serviceRows(apps []string, services []brew.ServiceStatus, declared map[string]string) []serviceRow {
+	var rows []serviceRow
+	for _, app := range apps {
+		service, ok := brew.FindService(services, app)
+		action := declared[app]
+		if !ok && action == "" {
+			continue
+		}
+
+		row := serviceRow{App: app, Status: "not installed", Declared: action}
+		if ok {
+			row.Status = service.Status
+			row.Running = service.IsRunning()
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// capitalize returns the action with an upper-case first letter for titles
+func capitalize(action string) string {
+	return strings.ToUpper(action[:1]) + action[1:]
+}
+
+// describeTarget names the listed apps in messages
+func describeTarget(target string) string {
+	if target == "" {
+		return "the apps in your settings"
+	}
+	return fmt.Sprintf("'%s'", target)
+}
+
+// targetArg returns the command argument that selects the same apps again
+func targetArg(target string) string {
+	if target == "" {
+		return "<group-or-app>"
+	}
+	return target
+}
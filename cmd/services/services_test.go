@@ -0,0 +1,55 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+)
+
+func TestServiceRows(t *testing.T) {
+	services := []brew.ServiceStatus{
+		{Name: "postgresql@16", Status: "started", Running: true},
+		{Name: "redis", Status: "none"},
+		{Name: "unbound", Status: "error"},
+	}
+	declared := map[string]string{"redis": brew.ServiceStart, "memcached": brew.ServiceRestart, "postgresql@16": brew.ServiceNone}
+	apps := []string{"git", "postgresql@16", "homebrew/core/redis", "redis", "memcached"}
+
+	expected := []serviceRow{
+		{App: "postgresql@16", Status: "started", Running: true, Declared: brew.ServiceNone},
+		{App: "homebrew/core/redis", Status: "none"},
+		{App: "redis", Status: "none", Declared: brew.ServiceStart},
+		{App: "memcached", Status: "not installed", Declared: brew.ServiceRestart},
+	}
+	rows := serviceRows(apps, services, declared)
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("Expected rows %+v, got %+v", expected, rows)
+	}
+
+	var attention []string
+	for _, row := range rows {
+		if row.NeedsAttention() {
+			attention = append(attention, row.App)
+		}
+	}
+	if !reflect.DeepEqual(attention, []string{"redis", "memcached"}) {
+		t.Errorf("Expected redis and memcached to need attention, got %v", attention)
+	}
+}
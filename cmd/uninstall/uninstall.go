@@ -0,0 +1,98 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package uninstall provides functionality to remove apps installed from sources,
+// using the install manifests anvil records in ~/.anvil/manifests.
+package uninstall
+
+import (
+	"fmt"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/anvil/internal/installer"
+	"github.com/0xjuanma/anvil/internal/manifest"
+	"github.com/0xjuanma/palantir"
+	"github.com/spf13/cobra"
+)
+
+// UninstallCmd represents the uninstall command.
+var UninstallCmd = &cobra.Command{
+	Use:   "uninstall <app-name>",
+	Short: "Remove an app installed from a source",
+	Long:  constants.UNINSTALL_COMMAND_LONG_DESCRIPTION,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUninstallCommand(cmd, args[0])
+	},
+	Example: `  anvil uninstall lazygit           # Remove lazygit and its links
+  anvil uninstall lazygit --dry-run # Show what would be removed`,
+}
+
+// runUninstallCommand removes the files recorded in an app's manifest after confirmation
+func runUninstallCommand(cmd *cobra.Command, appName string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+	o := palantir.GetGlobalOutputHandler()
+	o.PrintHeader(fmt.Sprintf("Uninstalling '%s'", appName))
+
+	if err := manifest.ValidateName(appName); err != nil {
+		return errors.NewInstallationError(constants.OpUninstall, appName, err)
+	}
+	if !manifest.Exists(appName) {
+		return errors.NewInstallationError(constants.OpUninstall, appName,
+			fmt.Errorf("no install manifest for '%s'; only apps anvil installed from sources can be uninstalled (use 'brew uninstall %s' for Homebrew packages)", appName, appName))
+	}
+	record, err := manifest.Load(appName)
+	if err != nil {
+		return errors.NewInstallationError(constants.OpUninstall, appName, err)
+	}
+
+	paths := record.Paths()
+	if len(paths) == 0 {
+		o.PrintWarning("No files were recorded for %s; it was installed by a system package installer", appName)
+	}
+	for _, path := range paths {
+		o.PrintInfo("  %s", path)
+	}
+
+	if dryRun {
+		o.PrintInfo("Dry run: would remove %d path(s) and the manifest of %s", len(paths), appName)
+		return nil
+	}
+	if !force && len(paths) > 0 && !o.Confirm(fmt.Sprintf("Remove these %d path(s) of %s?", len(paths), appName)) {
+		o.PrintInfo("Uninstall cancelled.")
+		return nil
+	}
+
+	if err := installer.Uninstall(appName); err != nil {
+		return errors.NewInstallationError(constants.OpUninstall, appName, err)
+	}
+	if tracked, err := config.IsAppTracked(appName); err == nil && tracked {
+		if err := config.RemoveInstalledApp(appName); err != nil {
+			o.PrintWarning("Failed to remove %s from installed_apps: %v", appName, err)
+		}
+	}
+
+	o.PrintSuccess(fmt.Sprintf("%s uninstalled", appName))
+	return nil
+}
+
+func init() {
+	UninstallCmd.Flags().BoolP("dry-run", "n", false, "Show what would be removed without removing it")
+	UninstallCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
+}
@@ -0,0 +1,44 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUninstallRejectsPathNames(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	settings := filepath.Join(home, ".anvil", "settings.yaml")
+	if err := os.MkdirAll(filepath.Join(home, ".anvil", "manifests"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settings, []byte("version: 1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../settings", "../../.anvil/settings", "/etc/passwd", ".."} {
+		if err := runUninstallCommand(UninstallCmd, name); err == nil {
+			t.Errorf("Expected uninstall of %q to fail", name)
+		}
+	}
+	if _, err := os.Stat(settings); err != nil {
+		t.Errorf("Expected settings.yaml to be left in place: %v", err)
+	}
+}
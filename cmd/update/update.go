@@ -21,6 +21,8 @@ package update
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -28,6 +30,7 @@ import (
 
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/0xjuanma/anvil/internal/errors"
+	"github.com/0xjuanma/anvil/internal/network"
 	"github.com/0xjuanma/anvil/internal/system"
 	"github.com/0xjuanma/palantir"
 	"github.com/spf13/cobra"
@@ -157,40 +160,72 @@ func updateViaScript(ctx context.Context) error {
 			fmt.Errorf("curl is required for updating Anvil but is not available"))
 	}
 
+	settings := network.Settings()
+	client, err := network.NewClient(settings)
+	if err != nil {
+		return err
+	}
+
 	o.PrintInfo("Fetching latest version from GitHub releases...")
 
 	// Try downloading from releases first, fallback to main branch if that fails
 	// This handles cases where the install.sh in releases hasn't been updated yet
-	updateScript := fmt.Sprintf(`set -e
-		if ! curl -sfSL %s -o /tmp/anvil-install.sh 2>/dev/null; then
-			echo "⚠️  Install script not found in releases, trying main branch..."
-			curl -sfSL %s -o /tmp/anvil-install.sh || {
-				echo "❌ Failed to download install script"
-				exit 1
-			}
-		fi
-		bash /tmp/anvil-install.sh
-		rm -f /tmp/anvil-install.sh`, constants.UpdateReleaseScriptURL, constants.UpdateMainScriptURL)
-
-	// Execute the update command using the existing system package
-	result, err := system.RunCommandWithTimeout(
-		ctx,
-		"bash",
-		"-c",
-		updateScript,
-	)
-
+	scriptPath, err := downloadInstallScript(ctx, client, constants.UpdateReleaseScriptURL)
 	if err != nil {
-		return fmt.Errorf("failed to execute update script: %w", err)
+		o.PrintWarning("Install script not found in releases (%v), trying main branch...", err)
+		scriptPath, err = downloadInstallScript(ctx, client, constants.UpdateMainScriptURL)
+		if err != nil {
+			return fmt.Errorf("failed to download install script: %w", err)
+		}
 	}
+	defer os.Remove(scriptPath)
 
-	if !result.Success {
-		return fmt.Errorf("update script failed with exit code %d: %s", result.ExitCode, result.Output)
+	// The script downloads the release itself, so it gets the proxy and CA bundle too
+	cmd := exec.CommandContext(ctx, "bash", scriptPath)
+	cmd.Env = append(os.Environ(), network.Environment(settings)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		exitCode := -1
+		if exitError, ok := err.(*exec.ExitError); ok {
+			exitCode = exitError.ExitCode()
+		}
+		return fmt.Errorf("update script failed with exit code %d: %s", exitCode, strings.TrimSpace(string(output)))
 	}
 
 	return nil
 }
 
+// downloadInstallScript downloads the install script at scriptURL to a temporary file
+func downloadInstallScript(ctx context.Context, client *http.Client, scriptURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", scriptURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "anvil-cli/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	file, err := os.CreateTemp("", "anvil-install-*.sh")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write install script: %w", err)
+	}
+	return file.Name(), nil
+}
+
 func init() {
 	UpdateCmd.Flags().Bool("dry-run", false, "Show what would be updated without actually updating")
 }
@@ -0,0 +1,74 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import "strings"
+
+// AppAliases maps lowercase app bundle and friendly names to Homebrew package names.
+// Some are real package names too (box, teams, edge...), so they are only used to discover
+// apps and suggest packages, never to rewrite names at install time; the aliases section
+// of settings.yaml does that.
+var AppAliases = map[string]string{
+	"iterm":                   "iterm2",
+	"zoom.us":                 "zoom",
+	"1password 7":             "1password",
+	"alfred 5":                "alfred",
+	"alfred 4":                "alfred",
+	"pgadmin 4":               "pgadmin4",
+	"dbeaver":                 "dbeaver-community",
+	"alttab":                  "alt-tab",
+	"adobe acrobat reader dc": "adobe-acrobat-reader",
+	"parallels desktop":       "parallels",
+	"cleanmymac x":            "cleanmymac",
+	"bartender 5":             "bartender",
+	"bartender 4":             "bartender",
+	"logi options+":           "logi-options-plus",
+	"hands off!":              "hands-off",
+	"box":                     "box-drive",
+	"pcloud":                  "pcloud-drive",
+	"superduper!":             "superduper",
+	"vlc media player":        "vlc",
+	"epic games launcher":     "epic-games",
+	"vscode":                  "visual-studio-code",
+	"chrome":                  "google-chrome",
+	"edge":                    "microsoft-edge",
+	"brave":                   "brave-browser",
+	"teams":                   "microsoft-teams",
+	"golang":                  "go",
+	"nodejs":                  "node",
+}
+
+// LookupAlias returns the package a name is an alias of, checking the user's
+// aliases before the built-in ones. Names are matched case-insensitively.
+func LookupAlias(name string, userAliases map[string]string) (string, bool) {
+	if target, ok := LookupUserAlias(name, userAliases); ok {
+		return target, true
+	}
+	target, ok := AppAliases[strings.ToLower(name)]
+	return target, ok
+}
+
+// LookupUserAlias returns the package a name is an alias of in the user's aliases only.
+// Names are matched case-insensitively.
+func LookupUserAlias(name string, userAliases map[string]string) (string, bool) {
+	for alias, target := range userAliases {
+		if strings.EqualFold(alias, name) {
+			return target, true
+		}
+	}
+	return "", false
+}
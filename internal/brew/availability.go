@@ -90,63 +90,11 @@ func searchApplication(appName string) bool {
 	return false
 }
 
-// generateOptimizedAppNames creates optimized app names for known packages.
-// Uses special cases for common apps and fallback generation for others.
+// generateOptimizedAppNames returns the app bundle names to look for in /Applications,
+// taken from the cask's Homebrew index entry with a generated fallback for others.
 func generateOptimizedAppNames(packageName string) []string {
-	// Use special cases first (most common)
-	specialCases := map[string][]string{
-		"visual-studio-code":    {"Visual Studio Code.app"},
-		"google-chrome":         {"Google Chrome.app"},
-		"1password":             {"1Password.app", "1Password 7 - Password Manager.app"},
-		"iterm2":                {"iTerm.app"},
-		"firefox":               {"Firefox.app"},
-		"slack":                 {"Slack.app"},
-		"docker-desktop":        {"Docker.app"},
-		"postman":               {"Postman.app"},
-		"vlc":                   {"VLC.app"},
-		"spotify":               {"Spotify.app"},
-		"discord":               {"Discord.app"},
-		"zoom":                  {"zoom.us.app"},
-		"notion":                {"Notion.app"},
-		"cursor":                {"Cursor.app"},
-		"raycast":               {"Raycast.app"},
-		"alfred":                {"Alfred 5.app", "Alfred 4.app"},
-		"obsidian":              {"Obsidian.app"},
-		"rectangle":             {"Rectangle.app"},
-		"brave-browser":         {"Brave Browser.app"},
-		"microsoft-edge":        {"Microsoft Edge.app"},
-		"arc":                   {"Arc.app"},
-		"steam":                 {"Steam.app"},
-		"telegram":              {"Telegram.app"},
-		"signal":                {"Signal.app"},
-		"whatsapp":              {"WhatsApp.app"},
-		"obs":                   {"OBS.app"},
-		"gimp":                  {"GIMP.app"},
-		"inkscape":              {"Inkscape.app"},
-		"mongodb-compass":       {"MongoDB Compass.app"},
-		"dbeaver-community":     {"DBeaver.app"},
-		"pgadmin4":              {"pgAdmin 4.app"},
-		"db-browser-for-sqlite": {"DB Browser for SQLite.app"},
-		"kitty":                 {"kitty.app"},
-		"alacritty":             {"Alacritty.app"},
-		"wezterm":               {"WezTerm.app"},
-		"iina":                  {"IINA.app"},
-		"stats":                 {"Stats.app"},
-		"betterdisplay":         {"BetterDisplay.app"},
-		"alt-tab":               {"AltTab.app"},
-		"karabiner-elements":    {"Karabiner-Elements.app"},
-		"bitwarden":             {"Bitwarden.app"},
-		"claude":                {"Claude.app"},
-		"utm":                   {"UTM.app"},
-		"adobe-acrobat-reader":  {"Adobe Acrobat Reader DC.app"},
-		"appcleaner":            {"AppCleaner.app"},
-		"vscodium":              {"VSCodium.app"},
-		"insomnia":              {"Insomnia.app"},
-		"claude-code":           {"Claude Code.app"},
-	}
-
-	if special, exists := specialCases[packageName]; exists {
-		return special
+	if apps := CurrentIndex().AppNames(packageName); len(apps) > 0 {
+		return apps
 	}
 
 	// Fallback to generic generation
@@ -18,6 +18,7 @@ package brew
 
 import (
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -379,3 +380,41 @@ func BenchmarkIsPackageInstalled(b *testing.B) {
 		IsPackageInstalled("git")
 	}
 }
+
+func TestInstallArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		pkg      string
+		opts     InstallOptions
+		expected string
+	}{
+		{"explicit cask", "firefox", InstallOptions{Type: PackageTypeCask, Args: []string{"--no-quarantine"}}, "install --cask --no-quarantine firefox"},
+		{"explicit formula", "docker", InstallOptions{Type: PackageTypeFormula}, "install --formula docker"},
+		{"detected formula", "git", InstallOptions{Args: []string{"--HEAD"}}, "install --HEAD git"},
+		{"detected cask", "iterm2", InstallOptions{}, "install --cask iterm2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if args := strings.Join(InstallArgs(tt.pkg, tt.opts), " "); args != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, args)
+			}
+		})
+	}
+}
+
+func TestInstallCommand(t *testing.T) {
+	opts := InstallOptions{
+		Type: PackageTypeCask,
+		Args: []string{"--appdir=~/Applications"},
+		Env:  map[string]string{"HOMEBREW_NO_AUTO_UPDATE": "1", "HOMEBREW_CASK_OPTS": "--require-sha"},
+	}
+	expected := "HOMEBREW_CASK_OPTS=*** HOMEBREW_NO_AUTO_UPDATE=*** brew install --cask --appdir=~/Applications firefox"
+	if command := InstallCommand("firefox", opts); command != expected {
+		t.Errorf("Expected %q, got %q", expected, command)
+	}
+
+	if !(InstallOptions{}).IsEmpty() || opts.IsEmpty() {
+		t.Error("Expected only zero options to be empty")
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Brewfile entry kinds understood by anvil
+const (
+	BrewfileTapKind  = "tap"
+	BrewfileBrewKind = "brew"
+	BrewfileCaskKind = "cask"
+)
+
+// brewfileEntryPattern matches `kind "name"` with an optional comma-separated remainder
+var brewfileEntryPattern = regexp.MustCompile(`^([a-z_]+)\s+(?:"([^"]*)"|'([^']*)')\s*(?:,\s*(.*))?$`)
+
+// brewfileStringPattern matches a quoted string at the start of the remainder of an entry
+var brewfileStringPattern = regexp.MustCompile(`^(?:"([^"]*)"|'([^']*)')\s*(?:,|$)`)
+
+// BrewfileTap is a tap declared in a Brewfile
+type BrewfileTap struct {
+	Name string
+	URL  string // Optional clone URL for taps not hosted on GitHub
+}
+
+// BrewfileNote describes a Brewfile line that was skipped or only partially converted
+type BrewfileNote struct {
+	Line    int
+	Text    string
+	Message string
+}
+
+// Brewfile holds the taps, formulae and casks of a Homebrew Bundle file
+type Brewfile struct {
+	Taps     []BrewfileTap
+	Formulae []string
+	Casks    []string
+	Notes    []BrewfileNote // Lines anvil could not convert as-is
+}
+
+// Packages returns formulae followed by casks, in file order and without duplicates
+func (b *Brewfile) Packages() []string {
+	seen := make(map[string]bool)
+	var packages []string
+	for _, name := range append(append([]string{}, b.Formulae...), b.Casks...) {
+		if !seen[name] {
+			seen[name] = true
+			packages = append(packages, name)
+		}
+	}
+	return packages
+}
+
+// PackageTypes returns the type of each cask that detection would not install as a cask:
+// casks sharing their name with a formula, which 'brew install' prefers, and casks
+// unknown to the index. Names listed both as brew and cask are left to detection.
+func (b *Brewfile) PackageTypes(index *Index) map[string]string {
+	formulae := make(map[string]bool, len(b.Formulae))
+	for _, name := range b.Formulae {
+		formulae[name] = true
+	}
+
+	types := make(map[string]string)
+	for _, name := range b.Casks {
+		if formulae[name] {
+			continue
+		}
+		if kind, known := index.Kind(name); !known || kind != PackageTypeCask {
+			types[name] = PackageTypeCask
+		}
+	}
+	return types
+}
+
+// ParseBrewfile reads the tap, brew and cask entries of a Brewfile. Other entries
+// (mas, vscode, whalebrew, Ruby blocks...) are skipped and reported in Notes, as are
+// entry options, since anvil installs packages without them.
+func ParseBrewfile(data []byte) (*Brewfile, error) {
+	brewfile := &Brewfile{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(stripBrewfileComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+
+		match := brewfileEntryPattern.FindStringSubmatch(text)
+		if match == nil {
+			brewfile.note(lineNumber, text, "unsupported Brewfile syntax")
+			continue
+		}
+
+		kind, name, rest := match[1], match[2]+match[3], strings.TrimSpace(match[4])
+		if name == "" {
+			brewfile.note(lineNumber, text, fmt.Sprintf("%s entry without a name", kind))
+			continue
+		}
+
+		switch kind {
+		case BrewfileTapKind:
+			tap := BrewfileTap{Name: name}
+			if rest != "" {
+				urlMatch := brewfileStringPattern.FindStringSubmatch(rest)
+				if urlMatch == nil {
+					brewfile.note(lineNumber, text, "tap options are ignored")
+				} else {
+					tap.URL = urlMatch[1] + urlMatch[2]
+					if remaining := strings.TrimSpace(rest[len(urlMatch[0]):]); remaining != "" {
+						brewfile.note(lineNumber, text, "tap options are ignored")
+					}
+				}
+			}
+			brewfile.Taps = append(brewfile.Taps, tap)
+		case BrewfileBrewKind, BrewfileCaskKind:
+			if rest != "" {
+				brewfile.note(lineNumber, text, fmt.Sprintf("%s options are ignored", kind))
+			}
+			if kind == BrewfileBrewKind {
+				brewfile.Formulae = append(brewfile.Formulae, name)
+			} else {
+				brewfile.Casks = append(brewfile.Casks, name)
+			}
+		default:
+			brewfile.note(lineNumber, text, fmt.Sprintf("'%s' entries are not supported by anvil", kind))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Brewfile: %w", err)
+	}
+
+	if len(brewfile.Taps) == 0 && len(brewfile.Formulae) == 0 && len(brewfile.Casks) == 0 {
+		return nil, fmt.Errorf("Brewfile contains no tap, brew or cask entries")
+	}
+	return brewfile, nil
+}
+
+// NewBrewfile sorts packages into formulae and casks using isCask, keeping their order
+func NewBrewfile(packages []string, taps []BrewfileTap, isCask func(string) bool) *Brewfile {
+	brewfile := &Brewfile{Taps: taps}
+	for _, name := range packages {
+		if isCask(name) {
+			brewfile.Casks = append(brewfile.Casks, name)
+		} else {
+			brewfile.Formulae = append(brewfile.Formulae, name)
+		}
+	}
+	return brewfile
+}
+
+// Render writes the Brewfile in 'brew bundle dump' order: taps, then formulae, then casks.
+// Each header line is written as a leading comment.
+func (b *Brewfile) Render(header ...string) []byte {
+	var buf bytes.Buffer
+	for _, line := range header {
+		fmt.Fprintf(&buf, "# %s\n", line)
+	}
+	for _, tap := range b.Taps {
+		if tap.URL != "" {
+			fmt.Fprintf(&buf, "%s %q, %q\n", BrewfileTapKind, tap.Name, tap.URL)
+		} else {
+			fmt.Fprintf(&buf, "%s %q\n", BrewfileTapKind, tap.Name)
+		}
+	}
+	for _, name := range b.Formulae {
+		fmt.Fprintf(&buf, "%s %q\n", BrewfileBrewKind, name)
+	}
+	for _, name := range b.Casks {
+		fmt.Fprintf(&buf, "%s %q\n", BrewfileCaskKind, name)
+	}
+	return buf.Bytes()
+}
+
+// note records a Brewfile line that was skipped or partially converted
+func (b *Brewfile) note(line int, text, message string) {
+	b.Notes = append(b.Notes, BrewfileNote{Line: line, Text: text, Message: message})
+}
+
+// stripBrewfileComment removes a trailing '#' comment that is not inside a quoted string
+func stripBrewfileComment(line string) string {
+	var quote rune
+	for i, char := range line {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readBrewfileFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseBrewfile(t *testing.T) {
+	brewfile, err := ParseBrewfile(readBrewfileFixture(t, "Brewfile.dump"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedTaps := []BrewfileTap{{Name: "homebrew/bundle"}, {Name: "hashicorp/tap"}}
+	if !reflect.DeepEqual(brewfile.Taps, expectedTaps) {
+		t.Errorf("Expected taps %v, got %v", expectedTaps, brewfile.Taps)
+	}
+	expectedFormulae := []string{"git", "postgresql@16", "hashicorp/tap/terraform", "mas"}
+	if !reflect.DeepEqual(brewfile.Formulae, expectedFormulae) {
+		t.Errorf("Expected formulae %v, got %v", expectedFormulae, brewfile.Formulae)
+	}
+	expectedCasks := []string{"firefox", "font-fira-code"}
+	if !reflect.DeepEqual(brewfile.Casks, expectedCasks) {
+		t.Errorf("Expected casks %v, got %v", expectedCasks, brewfile.Casks)
+	}
+
+	var noteLines []int
+	for _, note := range brewfile.Notes {
+		noteLines = append(noteLines, note.Line)
+	}
+	// Options on lines 5 and 7, mas, vscode, cask_args, if and end
+	expectedLines := []int{5, 7, 9, 10, 11, 12, 14}
+	if !reflect.DeepEqual(noteLines, expectedLines) {
+		t.Errorf("Expected notes on lines %v, got %v (%+v)", expectedLines, noteLines, brewfile.Notes)
+	}
+}
+
+func TestParseBrewfileErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"empty":         "",
+		"comments only": "# nothing here\n\n",
+		"unsupported":   "mas \"Xcode\", id: 497799835\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseBrewfile([]byte(content)); err == nil {
+				t.Error("Expected an error for a Brewfile without packages")
+			}
+		})
+	}
+}
+
+func TestBrewfileRoundTrip(t *testing.T) {
+	original := readBrewfileFixture(t, "Brewfile")
+	brewfile, err := ParseBrewfile(original)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(brewfile.Notes) != 0 {
+		t.Errorf("Expected a clean parse, got notes %+v", brewfile.Notes)
+	}
+
+	// Rebuild from the flat package list, as export does from an anvil group
+	casks := make(map[string]bool)
+	for _, name := range brewfile.Casks {
+		casks[name] = true
+	}
+	rebuilt := NewBrewfile(brewfile.Packages(), brewfile.Taps, func(name string) bool { return casks[name] })
+
+	if rendered := rebuilt.Render(); string(rendered) != string(original) {
+		t.Errorf("Expected round-trip to reproduce the Brewfile:\n%s\ngot:\n%s", original, rendered)
+	}
+}
+
+func TestBrewfileRenderHeader(t *testing.T) {
+	brewfile := &Brewfile{Formulae: []string{"git"}}
+	expected := "# Team tools\n# Generated by anvil\nbrew \"git\"\n"
+	if rendered := string(brewfile.Render("Team tools", "Generated by anvil")); rendered != expected {
+		t.Errorf("Expected %q, got %q", expected, rendered)
+	}
+}
+
+func TestBrewfilePackagesDeduplicates(t *testing.T) {
+	brewfile := &Brewfile{Formulae: []string{"git", "jq", "git"}, Casks: []string{"iterm2"}}
+	expected := []string{"git", "jq", "iterm2"}
+	if packages := brewfile.Packages(); !reflect.DeepEqual(packages, expected) {
+		t.Errorf("Expected %v, got %v", expected, packages)
+	}
+}
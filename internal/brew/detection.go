@@ -18,32 +18,40 @@ package brew
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/0xjuanma/anvil/internal/system"
 )
 
-// isKnownCask checks if a package is a known cask from our lookup table
+var (
+	// Runtime cache for dynamically discovered package types
+	caskCache      = make(map[string]bool)
+	caskCacheMutex sync.RWMutex
+)
+
+// isKnownCask checks if a package is a known cask in the Homebrew index
 func isKnownCask(packageName string) bool {
-	if isCask, exists := knownBrewPackages[packageName]; exists {
-		return isCask
-	}
-	return false
+	kind, exists := CurrentIndex().Kind(packageName)
+	return exists && kind == PackageTypeCask
 }
 
-// isKnownFormula checks if a package is a known formula from our lookup table
+// isKnownFormula checks if a package is a known formula in the Homebrew index
 func isKnownFormula(packageName string) bool {
-	if isCask, exists := knownBrewPackages[packageName]; exists {
-		return !isCask // If it exists and is not a cask, it's a formula
-	}
-	return false
+	kind, exists := CurrentIndex().Kind(packageName)
+	return exists && kind == PackageTypeFormula
+}
+
+// IsCask reports whether a package is a Homebrew cask, using the same detection as installs
+func IsCask(packageName string) bool {
+	return isCaskPackage(packageName)
 }
 
 // isCaskPackage determines if a package is a Homebrew cask using optimized lookup
 func isCaskPackage(packageName string) bool {
-	// Step 1: Check static lookup table (fastest - covers 95% of common packages)
-	if isCask, exists := knownBrewPackages[packageName]; exists {
-		return isCask
+	// Step 1: Check the offline Homebrew index (fastest - no system calls)
+	if kind, exists := CurrentIndex().Kind(packageName); exists {
+		return kind == PackageTypeCask
 	}
 
 	// Step 2: Check runtime cache
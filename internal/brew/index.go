@@ -0,0 +1,354 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/system"
+)
+
+// seedIndex is a small index of popular packages (https://formulae.brew.sh/analytics) used
+// until 'anvil brew index update' has fetched the full Homebrew index
+//
+//go:embed index_seed.json
+var seedIndex []byte
+
+// Default tap prefixes, which Homebrew also accepts in package names
+const (
+	coreTapPrefix = "homebrew/core/"
+	caskTapPrefix = "homebrew/cask/"
+)
+
+// IndexEntry holds the metadata of a single formula or cask
+type IndexEntry struct {
+	Desc    string   `json:"desc,omitempty"`
+	Apps    []string `json:"apps,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Index is an offline copy of Homebrew's formula and cask metadata
+type Index struct {
+	UpdatedAt time.Time             `json:"updated_at,omitempty"`
+	Source    string                `json:"source,omitempty"`
+	Partial   bool                  `json:"partial,omitempty"`
+	Formulae  map[string]IndexEntry `json:"formulae"`
+	Casks     map[string]IndexEntry `json:"casks"`
+
+	aliases map[string]string
+}
+
+var (
+	currentIndex      *Index
+	currentIndexMutex sync.Mutex
+)
+
+// IndexPath returns the location of the offline Homebrew index
+func IndexPath() (string, error) {
+	homeDir, err := system.HomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, constants.ANVIL_CONFIG_DIR, constants.BREW_INDEX_FILE), nil
+}
+
+// CurrentIndex returns the index used for package lookups: the index saved by
+// 'anvil brew index update' when present, otherwise the built-in seed index
+func CurrentIndex() *Index {
+	currentIndexMutex.Lock()
+	defer currentIndexMutex.Unlock()
+
+	if currentIndex == nil {
+		currentIndex = loadDefaultIndex()
+	}
+	return currentIndex
+}
+
+// UseIndex replaces the index used for package lookups, e.g. after an update or with a test fixture
+func UseIndex(index *Index) {
+	currentIndexMutex.Lock()
+	defer currentIndexMutex.Unlock()
+
+	currentIndex = index
+}
+
+// loadDefaultIndex loads the saved index, falling back to the seed index when it is missing or unreadable
+func loadDefaultIndex() *Index {
+	if indexPath, err := IndexPath(); err == nil {
+		if index, err := LoadIndex(indexPath); err == nil {
+			return index
+		}
+	}
+
+	index, err := parseIndex(seedIndex)
+	if err != nil {
+		return newIndex()
+	}
+	return index
+}
+
+// LoadIndex reads an index file written by Save
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read brew index: %w", err)
+	}
+	return parseIndex(data)
+}
+
+// parseIndex decodes an index file and prepares its alias lookup
+func parseIndex(data []byte) (*Index, error) {
+	index := newIndex()
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse brew index: %w", err)
+	}
+	index.buildAliases()
+	return index, nil
+}
+
+// newIndex returns an empty index
+func newIndex() *Index {
+	return &Index{
+		Formulae: make(map[string]IndexEntry),
+		Casks:    make(map[string]IndexEntry),
+		aliases:  make(map[string]string),
+	}
+}
+
+// buildAliases maps formula aliases to their formula names
+func (idx *Index) buildAliases() {
+	if idx.Formulae == nil {
+		idx.Formulae = make(map[string]IndexEntry)
+	}
+	if idx.Casks == nil {
+		idx.Casks = make(map[string]IndexEntry)
+	}
+	idx.aliases = make(map[string]string)
+	for name, entry := range idx.Formulae {
+		for _, alias := range entry.Aliases {
+			idx.aliases[alias] = name
+		}
+	}
+}
+
+// Save writes the index to disk, replacing any previous index atomically
+func (idx *Index) Save(path string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to encode brew index: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirPerm); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, constants.FilePerm); err != nil {
+		return fmt.Errorf("failed to write brew index: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write brew index: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of formulae and casks in the index
+func (idx *Index) Len() int {
+	return len(idx.Formulae) + len(idx.Casks)
+}
+
+// Kind returns whether a package is a formula or a cask. Like 'brew install', a
+// formula wins when a formula and a cask share the same name.
+func (idx *Index) Kind(packageName string) (string, bool) {
+	if _, ok := idx.formula(packageName); ok {
+		return PackageTypeFormula, true
+	}
+	if _, ok := idx.cask(packageName); ok {
+		return PackageTypeCask, true
+	}
+	return "", false
+}
+
+// Has reports whether a package is a known formula, formula alias or cask
+func (idx *Index) Has(packageName string) bool {
+	_, ok := idx.Kind(packageName)
+	return ok
+}
+
+// Description returns the one-line description of a package, if known
+func (idx *Index) Description(packageName string) string {
+	if entry, ok := idx.formula(packageName); ok {
+		return entry.Desc
+	}
+	if entry, ok := idx.cask(packageName); ok {
+		return entry.Desc
+	}
+	return ""
+}
+
+// AppNames returns the app bundles a cask installs into /Applications
+func (idx *Index) AppNames(packageName string) []string {
+	if entry, ok := idx.cask(packageName); ok {
+		return entry.Apps
+	}
+	return nil
+}
+
+// formula looks up a formula by name, core-tap qualified name or alias
+func (idx *Index) formula(packageName string) (IndexEntry, bool) {
+	name := strings.TrimPrefix(packageName, coreTapPrefix)
+	if entry, ok := idx.Formulae[name]; ok {
+		return entry, true
+	}
+	if target, ok := idx.aliases[name]; ok {
+		entry, ok := idx.Formulae[target]
+		return entry, ok
+	}
+	return IndexEntry{}, false
+}
+
+// cask looks up a cask by token or cask-tap qualified token
+func (idx *Index) cask(packageName string) (IndexEntry, bool) {
+	entry, ok := idx.Casks[strings.TrimPrefix(packageName, caskTapPrefix)]
+	return entry, ok
+}
+
+// apiFormula is the subset of Homebrew's formula.json used by the index
+type apiFormula struct {
+	Name    string   `json:"name"`
+	Desc    string   `json:"desc"`
+	Aliases []string `json:"aliases"`
+}
+
+// apiCask is the subset of Homebrew's cask.json used by the index
+type apiCask struct {
+	Token     string                   `json:"token"`
+	Desc      string                   `json:"desc"`
+	Artifacts []map[string]interface{} `json:"artifacts"`
+}
+
+// ParseHomebrewAPI builds an index from the formula.json and cask.json documents of the Homebrew API
+func ParseHomebrewAPI(formulaJSON, caskJSON []byte) (*Index, error) {
+	var formulae []apiFormula
+	if err := json.Unmarshal(formulaJSON, &formulae); err != nil {
+		return nil, fmt.Errorf("failed to parse formula metadata: %w", err)
+	}
+	var casks []apiCask
+	if err := json.Unmarshal(caskJSON, &casks); err != nil {
+		return nil, fmt.Errorf("failed to parse cask metadata: %w", err)
+	}
+
+	index := newIndex()
+	for _, formula := range formulae {
+		if formula.Name == "" {
+			continue
+		}
+		index.Formulae[formula.Name] = IndexEntry{Desc: formula.Desc, Aliases: formula.Aliases}
+	}
+	for _, cask := range casks {
+		if cask.Token == "" {
+			continue
+		}
+		index.Casks[cask.Token] = IndexEntry{Desc: cask.Desc, Apps: caskApps(cask.Artifacts)}
+	}
+	index.buildAliases()
+	return index, nil
+}
+
+// caskApps returns the app bundle names from a cask's "app" artifacts
+func caskApps(artifacts []map[string]interface{}) []string {
+	var apps []string
+	for _, artifact := range artifacts {
+		values, ok := artifact["app"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, value := range values {
+			// Entries are the bundle name, optionally followed by options such as a target
+			if app, ok := value.(string); ok && strings.HasSuffix(app, ".app") {
+				apps = append(apps, app)
+			}
+		}
+	}
+	return apps
+}
+
+// HomebrewAPIURL returns the Homebrew API base URL, honoring HOMEBREW_API_DOMAIN like brew itself
+func HomebrewAPIURL() string {
+	if domain := strings.TrimSpace(os.Getenv(constants.HomebrewAPIDomainEnv)); domain != "" {
+		return strings.TrimSuffix(domain, "/")
+	}
+	return constants.HomebrewAPIURL
+}
+
+// FetchIndex downloads formula.json and cask.json from a Homebrew API base URL with client,
+// which should carry the network settings (see network.Client), and builds an index
+func FetchIndex(ctx context.Context, client *http.Client, apiURL string) (*Index, error) {
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	formulaJSON, err := fetchAPIDocument(ctx, client, apiURL+"/formula.json")
+	if err != nil {
+		return nil, err
+	}
+	caskJSON, err := fetchAPIDocument(ctx, client, apiURL+"/cask.json")
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := ParseHomebrewAPI(formulaJSON, caskJSON)
+	if err != nil {
+		return nil, err
+	}
+	index.Source = apiURL
+	index.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	return index, nil
+}
+
+// fetchAPIDocument downloads a single Homebrew API document
+func fetchAPIDocument(ctx context.Context, client *http.Client, documentURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", documentURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "anvil-cli/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", documentURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: HTTP %d", documentURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", documentURL, err)
+	}
+	return data, nil
+}
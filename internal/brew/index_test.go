@@ -0,0 +1,191 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useIndexFixture makes lookups use testdata/brew-index.json for the duration of a test
+func useIndexFixture(t *testing.T) *Index {
+	t.Helper()
+	index, err := LoadIndex(filepath.Join("testdata", "brew-index.json"))
+	if err != nil {
+		t.Fatalf("Failed to load index fixture: %v", err)
+	}
+	UseIndex(index)
+	t.Cleanup(func() { UseIndex(nil) })
+	return index
+}
+
+func TestParseHomebrewAPI(t *testing.T) {
+	index, err := ParseHomebrewAPI(readBrewfileFixture(t, "formula.json"), readBrewfileFixture(t, "cask.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if index.Len() != 6 {
+		t.Errorf("Expected 6 packages, got %d", index.Len())
+	}
+	if got := index.AppNames("alfred"); !reflect.DeepEqual(got, []string{"Alfred 5.app"}) {
+		t.Errorf("Expected app names [Alfred 5.app], got %v", got)
+	}
+	if got := index.AppNames("iterm2"); !reflect.DeepEqual(got, []string{"iTerm.app"}) {
+		t.Errorf("Expected app names [iTerm.app], got %v", got)
+	}
+	if got := index.Description("git"); got != "Distributed revision control system" {
+		t.Errorf("Unexpected description for git: %q", got)
+	}
+
+	if _, err := ParseHomebrewAPI([]byte("{"), []byte("[]")); err == nil {
+		t.Error("Expected an error for invalid formula metadata")
+	}
+}
+
+func TestIndexKind(t *testing.T) {
+	index, err := ParseHomebrewAPI(readBrewfileFixture(t, "formula.json"), readBrewfileFixture(t, "cask.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pkg      string
+		expected string
+		found    bool
+	}{
+		{"formula", "git", PackageTypeFormula, true},
+		{"cask", "iterm2", PackageTypeCask, true},
+		{"formula wins over cask", "docker", PackageTypeFormula, true},
+		{"formula alias", "postgres@16", PackageTypeFormula, true},
+		{"core tap qualified", "homebrew/core/git", PackageTypeFormula, true},
+		{"cask tap qualified", "homebrew/cask/iterm2", PackageTypeCask, true},
+		{"third-party tap", "hashicorp/tap/terraform", "", false},
+		{"unknown", "not-a-package", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, found := index.Kind(tt.pkg)
+			if kind != tt.expected || found != tt.found {
+				t.Errorf("Kind(%q) = %q, %v; expected %q, %v", tt.pkg, kind, found, tt.expected, tt.found)
+			}
+		})
+	}
+}
+
+func TestIndexSaveAndLoad(t *testing.T) {
+	index := useIndexFixture(t)
+
+	path := filepath.Join(t.TempDir(), "nested", "brew-index.json")
+	if err := index.Save(path); err != nil {
+		t.Fatalf("Failed to save index: %v", err)
+	}
+
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("Failed to load index: %v", err)
+	}
+	if !loaded.UpdatedAt.Equal(index.UpdatedAt) || loaded.Len() != index.Len() {
+		t.Errorf("Loaded index differs: %d packages updated %v", loaded.Len(), loaded.UpdatedAt)
+	}
+	if !loaded.Has("postgres@16") {
+		t.Error("Expected aliases to be rebuilt when loading an index")
+	}
+}
+
+func TestSeedIndex(t *testing.T) {
+	index, err := parseIndex(seedIndex)
+	if err != nil {
+		t.Fatalf("Failed to parse seed index: %v", err)
+	}
+	if !index.Partial {
+		t.Error("Expected the seed index to be marked partial")
+	}
+	if kind, _ := index.Kind("visual-studio-code"); kind != PackageTypeCask {
+		t.Errorf("Expected visual-studio-code to be a cask, got %q", kind)
+	}
+	if kind, _ := index.Kind("git"); kind != PackageTypeFormula {
+		t.Errorf("Expected git to be a formula, got %q", kind)
+	}
+	if got := index.AppNames("zoom"); !reflect.DeepEqual(got, []string{"zoom.us.app"}) {
+		t.Errorf("Expected zoom app names [zoom.us.app], got %v", got)
+	}
+}
+
+func TestFetchIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/formula.json":
+			w.Write(readBrewfileFixture(t, "formula.json"))
+		case "/api/cask.json":
+			w.Write(readBrewfileFixture(t, "cask.json"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	index, err := FetchIndex(context.Background(), server.Client(), server.URL+"/api/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if index.Source != server.URL+"/api" || index.UpdatedAt.IsZero() || index.Partial {
+		t.Errorf("Unexpected index metadata: source %q, updated %v, partial %v", index.Source, index.UpdatedAt, index.Partial)
+	}
+	if !index.Has("iterm2") || !index.Has("git") {
+		t.Error("Expected fetched index to contain git and iterm2")
+	}
+
+	if _, err := FetchIndex(context.Background(), server.Client(), server.URL+"/missing"); err == nil {
+		t.Error("Expected an error for a missing API document")
+	}
+}
+
+func TestHomebrewAPIURL(t *testing.T) {
+	t.Setenv("HOMEBREW_API_DOMAIN", "https://mirror.example.com/api/")
+	if got := HomebrewAPIURL(); got != "https://mirror.example.com/api" {
+		t.Errorf("Expected mirror API URL, got %q", got)
+	}
+
+	t.Setenv("HOMEBREW_API_DOMAIN", "")
+	if got := HomebrewAPIURL(); got != "https://formulae.brew.sh/api" {
+		t.Errorf("Expected default API URL, got %q", got)
+	}
+}
+
+func TestDetectionUsesIndex(t *testing.T) {
+	useIndexFixture(t)
+
+	if !IsCask("iterm2") || !isKnownCask("visual-studio-code") {
+		t.Error("Expected fixture casks to be detected as casks")
+	}
+	if IsCask("git") || !isKnownFormula("postgresql@16") {
+		t.Error("Expected fixture formulae to be detected as formulae")
+	}
+	if got := generateOptimizedAppNames("visual-studio-code"); !reflect.DeepEqual(got, []string{"Visual Studio Code.app"}) {
+		t.Errorf("Expected index app names, got %v", got)
+	}
+	if got := generateOptimizedAppNames("some-tool"); !reflect.DeepEqual(got, []string{"some-tool.app", "Some Tool.app"}) {
+		t.Errorf("Expected generated app names, got %v", got)
+	}
+}
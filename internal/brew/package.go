@@ -18,6 +18,7 @@ package brew
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/0xjuanma/anvil/internal/constants"
@@ -176,26 +177,75 @@ func InstallPackageWithCheck(packageName string) error {
 	return InstallPackageDirectly(packageName)
 }
 
+// Package types for InstallOptions
+const (
+	PackageTypeCask    = "cask"
+	PackageTypeFormula = "formula"
+)
+
+// InstallOptions customizes how a package is installed with 'brew install'
+type InstallOptions struct {
+	Type string            // "cask" or "formula", detected when empty
+	Args []string          // Extra 'brew install' arguments such as --HEAD or --no-quarantine
+	Env  map[string]string // Environment variables set for 'brew install'
+}
+
+// IsEmpty reports whether no install options are set
+func (o InstallOptions) IsEmpty() bool {
+	return o.Type == "" && len(o.Args) == 0 && len(o.Env) == 0
+}
+
+// InstallArgs returns the brew arguments that install a package with the given options.
+// The package type is detected when options do not set it.
+func InstallArgs(packageName string, opts InstallOptions) []string {
+	args := []string{constants.BrewInstall}
+	switch opts.Type {
+	case PackageTypeCask:
+		args = append(args, "--cask")
+	case PackageTypeFormula:
+		args = append(args, "--formula")
+	default:
+		if isCaskPackage(packageName) {
+			args = append(args, "--cask")
+		}
+	}
+	args = append(args, opts.Args...)
+	return append(args, packageName)
+}
+
+// InstallEnv returns the environment variables of the options in sorted "KEY=value" form
+func InstallEnv(opts InstallOptions) []string {
+	env := make([]string, 0, len(opts.Env))
+	for key, value := range opts.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// InstallCommand returns the full command line that installs a package with the given
+// options, for display; environment values are shown as "***" since they may hold tokens
+func InstallCommand(packageName string, opts InstallOptions) string {
+	parts := append(system.RedactEnv(InstallEnv(opts)), constants.BrewCommand)
+	return strings.Join(append(parts, InstallArgs(packageName, opts)...), " ")
+}
+
 // InstallPackageDirectly installs a package without checking availability first
 // Used when availability has already been verified by the caller
 func InstallPackageDirectly(packageName string) error {
+	return InstallPackageWithOptions(packageName, InstallOptions{})
+}
+
+// InstallPackageWithOptions installs a package with per-app options, without checking availability first
+func InstallPackageWithOptions(packageName string, opts InstallOptions) error {
 	if !IsBrewInstalled() {
 		return fmt.Errorf("Homebrew is not installed")
 	}
 
-	isCask := isCaskPackage(packageName)
 	spinner := charm.NewDotsSpinner(fmt.Sprintf("Installing %s", packageName))
 	spinner.Start()
 
-	var result *system.CommandResult
-	var err error
-
-	if isCask {
-		result, err = system.RunCommand(constants.BrewCommand, constants.BrewInstall, "--cask", packageName)
-	} else {
-		result, err = system.RunCommand(constants.BrewCommand, constants.BrewInstall, packageName)
-	}
-
+	result, err := system.RunCommandWithEnv(InstallEnv(opts), constants.BrewCommand, InstallArgs(packageName, opts)...)
 	if err != nil {
 		spinner.Error(fmt.Sprintf("Failed to install %s", packageName))
 		return fmt.Errorf("failed to run brew install: %w", err)
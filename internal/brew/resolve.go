@@ -0,0 +1,158 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/system"
+)
+
+// Where a name suggestion came from
+const (
+	SuggestionAlias  = "alias"
+	SuggestionIndex  = "index"
+	SuggestionSearch = "brew search"
+)
+
+// Suggestion is a package a mistyped or friendly app name may refer to
+type Suggestion struct {
+	Name     string
+	Source   string // SuggestionAlias, SuggestionIndex or SuggestionSearch
+	Distance int    // Edit distance between the requested name and the matched name
+}
+
+// SuggestPackages ranks packages an unknown app name may refer to, by edit distance to
+// the alias or package name they matched. Aliases, index entries and 'brew search'
+// results are all considered; searchResults may be nil to skip the search.
+func SuggestPackages(name string, userAliases map[string]string, index *Index, searchResults []string, limit int) []Suggestion {
+	query := strings.ToLower(name)
+	best := make(map[string]Suggestion)
+	consider := func(candidate, matched, source string, always bool) {
+		if strings.EqualFold(candidate, name) {
+			return
+		}
+		distance := editDistance(query, strings.ToLower(matched))
+		if !always && !isCloseMatch(query, strings.ToLower(matched), distance) {
+			return
+		}
+		if current, exists := best[candidate]; !exists || distance < current.Distance {
+			best[candidate] = Suggestion{Name: candidate, Source: source, Distance: distance}
+		}
+	}
+
+	if target, ok := LookupAlias(name, userAliases); ok {
+		consider(target, name, SuggestionAlias, true)
+	}
+	for alias, target := range userAliases {
+		consider(target, alias, SuggestionAlias, false)
+	}
+	for alias, target := range AppAliases {
+		consider(target, alias, SuggestionAlias, false)
+	}
+	if index != nil {
+		for formula := range index.Formulae {
+			consider(formula, formula, SuggestionIndex, false)
+		}
+		for cask := range index.Casks {
+			consider(cask, cask, SuggestionIndex, false)
+		}
+	}
+	for _, result := range searchResults {
+		consider(result, result, SuggestionSearch, true)
+	}
+
+	suggestions := make([]Suggestion, 0, len(best))
+	for _, suggestion := range best {
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Distance != suggestions[j].Distance {
+			return suggestions[i].Distance < suggestions[j].Distance
+		}
+		return suggestions[i].Name < suggestions[j].Name
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// isCloseMatch reports whether a name is similar enough to the query to be suggested:
+// a few typos, scaled with the query length, or one name being a prefix of the other
+func isCloseMatch(query, name string, distance int) bool {
+	maxDistance := len(query) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	if distance <= maxDistance {
+		return true
+	}
+	return len(query) >= 3 && (strings.HasPrefix(name, query) || strings.HasPrefix(query, name) && len(name) >= 3)
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
+
+// SearchPackages returns the formulae and casks 'brew search' finds for a name
+func SearchPackages(name string) ([]string, error) {
+	if !IsBrewInstalled() {
+		return nil, fmt.Errorf("Homebrew is not installed")
+	}
+
+	result, err := system.RunCommand(constants.BrewCommand, constants.BrewSearch, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run brew search: %w", err)
+	}
+	return parseSearchOutput(result.Output), nil
+}
+
+// parseSearchOutput extracts package names from 'brew search' output, skipping section headers
+func parseSearchOutput(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.Contains(line, "==>") || strings.Contains(line, "Error:") || strings.Contains(line, "Warning:") {
+			continue
+		}
+		names = append(names, strings.Fields(line)...)
+	}
+	return names
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"git", "git", 0},
+		{"gti", "git", 2},
+		{"slak", "slack", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestSuggestPackages(t *testing.T) {
+	index := useIndexFixture(t)
+
+	names := func(suggestions []Suggestion) []string {
+		var result []string
+		for _, suggestion := range suggestions {
+			result = append(result, suggestion.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		user     map[string]string
+		search   []string
+		expected []string
+	}{
+		{"built-in alias", "vscode", nil, nil, []string{"visual-studio-code"}},
+		{"alias is case-insensitive", "VSCode", nil, nil, []string{"visual-studio-code"}},
+		{"user alias wins", "code", map[string]string{"Code": "vscodium"}, nil, []string{"vscodium"}},
+		{"typo in index name", "itrem2", nil, nil, []string{"iterm2"}},
+		{"prefix of index name", "visual-studio", nil, nil, []string{"visual-studio-code"}},
+		{"search results ranked by distance", "postgres", nil, []string{"postgresql@17", "postgresql@16", "postgrest"}, []string{"postgrest", "postgresql@16", "postgresql@17"}},
+		{"exact name is not suggested", "git", nil, []string{"git", "git-lfs"}, []string{"git-lfs"}},
+		{"nothing close", "zzzzzz", nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(SuggestPackages(tt.query, tt.user, index, tt.search, 3))
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("SuggestPackages(%q) = %v, expected %v", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLookupAlias(t *testing.T) {
+	if target, ok := LookupAlias("Chrome", nil); !ok || target != "google-chrome" {
+		t.Errorf("Expected built-in alias google-chrome, got %q, %v", target, ok)
+	}
+	if target, ok := LookupAlias("chrome", map[string]string{"chrome": "chromium"}); !ok || target != "chromium" {
+		t.Errorf("Expected user alias chromium, got %q, %v", target, ok)
+	}
+	if _, ok := LookupAlias("git", nil); ok {
+		t.Error("Expected no alias for git")
+	}
+	if _, ok := LookupUserAlias("box", nil); ok {
+		t.Error("Expected built-in aliases not to be user aliases")
+	}
+	if target, ok := LookupUserAlias("Code", map[string]string{"code": "vscodium"}); !ok || target != "vscodium" {
+		t.Errorf("Expected user alias vscodium, got %q, %v", target, ok)
+	}
+}
+
+func TestParseSearchOutput(t *testing.T) {
+	output := "==> Formulae\npostgresql@16    postgresql@17\npostgrest\n\n==> Casks\npostgres-app\n"
+	expected := []string{"postgresql@16", "postgresql@17", "postgrest", "postgres-app"}
+	if got := parseSearchOutput(output); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/system"
+)
+
+// Service actions applied after an app is installed
+const (
+	ServiceStart   = "start"
+	ServiceRestart = "restart"
+	ServiceStop    = "stop"
+	ServiceNone    = "none"
+)
+
+// ServiceStatus is the state of a Homebrew service as reported by 'brew services list'
+type ServiceStatus struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // started, stopped, error, scheduled, none...
+	Running bool   `json:"running"`
+	User    string `json:"user"`
+}
+
+// IsRunning reports whether the service is running or started
+func (s ServiceStatus) IsRunning() bool {
+	return s.Running || s.Status == "started"
+}
+
+// ServiceName returns the Homebrew service name of a package, which drops any tap prefix
+func ServiceName(packageName string) string {
+	return path.Base(packageName)
+}
+
+// Services returns the Homebrew services known on this machine
+func Services() ([]ServiceStatus, error) {
+	if !IsBrewInstalled() {
+		return nil, fmt.Errorf("Homebrew is not installed")
+	}
+
+	result, err := system.RunCommand(constants.BrewCommand, constants.BrewServices, "list", "--json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run brew services: %w", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("failed to list services: %s", strings.TrimSpace(result.Output))
+	}
+
+	return parseServices([]byte(result.Output))
+}
+
+// parseServices decodes the JSON output of 'brew services list --json'
+func parseServices(data []byte) ([]ServiceStatus, error) {
+	// brew may print notices before the JSON document
+	if start := strings.IndexAny(string(data), "[{"); start > 0 {
+		data = data[start:]
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+
+	var services []ServiceStatus
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("failed to parse brew services output: %w", err)
+	}
+	return services, nil
+}
+
+// FindService returns the status of a package's service, or false if Homebrew has no such service
+func FindService(services []ServiceStatus, packageName string) (ServiceStatus, bool) {
+	name := ServiceName(packageName)
+	for _, service := range services {
+		if service.Name == name {
+			return service, true
+		}
+	}
+	return ServiceStatus{}, false
+}
+
+// RunServiceAction starts, stops or restarts the service of a package
+func RunServiceAction(action, packageName string) error {
+	switch action {
+	case ServiceStart, ServiceStop, ServiceRestart:
+	default:
+		return fmt.Errorf("unknown service action '%s'", action)
+	}
+	if !IsBrewInstalled() {
+		return fmt.Errorf("Homebrew is not installed")
+	}
+
+	name := ServiceName(packageName)
+	result, err := system.RunCommand(constants.BrewCommand, constants.BrewServices, action, name)
+	if err != nil {
+		return fmt.Errorf("failed to run brew services %s: %w", action, err)
+	}
+	if !result.Success {
+		if result.Output != "" {
+			return fmt.Errorf("failed to %s %s: %s", action, name, strings.TrimSpace(result.Output))
+		}
+		return fmt.Errorf("failed to %s %s: %s", action, name, result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import "testing"
+
+const servicesJSON = `==> Tapping homebrew/services
+[
+  {"name": "postgresql@16", "service_name": "homebrew.mxcl.postgresql@16", "running": true, "loaded": true, "user": "me", "status": "started"},
+  {"name": "redis", "service_name": "homebrew.mxcl.redis", "running": false, "loaded": false, "user": null, "status": "none"},
+  {"name": "unbound", "running": false, "user": "root", "status": "error"}
+]`
+
+func TestParseServices(t *testing.T) {
+	services, err := parseServices([]byte(servicesJSON))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(services) != 3 {
+		t.Fatalf("Expected 3 services, got %d", len(services))
+	}
+	if !services[0].IsRunning() || services[0].User != "me" {
+		t.Errorf("Expected postgresql@16 to be running for me, got %+v", services[0])
+	}
+	if services[1].IsRunning() || services[2].IsRunning() {
+		t.Errorf("Expected redis and unbound not to be running, got %+v", services[1:])
+	}
+
+	if services, err := parseServices([]byte("")); err != nil || len(services) != 0 {
+		t.Errorf("Expected no services for empty output, got %v, %v", services, err)
+	}
+	if _, err := parseServices([]byte("[{")); err == nil {
+		t.Error("Expected an error for malformed output")
+	}
+}
+
+func TestFindService(t *testing.T) {
+	services, _ := parseServices([]byte(servicesJSON))
+
+	if service, ok := FindService(services, "homebrew/core/redis"); !ok || service.Name != "redis" {
+		t.Errorf("Expected tap-qualified redis to match, got %+v, %v", service, ok)
+	}
+	if _, ok := FindService(services, "git"); ok {
+		t.Error("Expected git to have no service")
+	}
+}
+
+func TestRunServiceActionRejectsUnknownAction(t *testing.T) {
+	if err := RunServiceAction("reload", "redis"); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+}
@@ -0,0 +1,343 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/system"
+)
+
+// AvailabilityCacheTTL is how long a saved availability snapshot is reused. It is kept
+// short so apps installed or removed outside anvil are noticed on the next run.
+const AvailabilityCacheTTL = 2 * time.Minute
+
+// AvailabilitySnapshot records what is installed on the system at one point in time, so
+// the availability of a whole group is resolved in memory instead of running `which`,
+// `brew list` and `mdfind` for every app
+type AvailabilitySnapshot struct {
+	CreatedAt    time.Time `json:"created_at"`
+	Formulae     []string  `json:"formulae"`
+	Casks        []string  `json:"casks"`
+	Executables  []string  `json:"executables"`
+	Applications []string  `json:"applications"`
+
+	formulae     map[string]string
+	casks        map[string]string
+	executables  map[string]bool
+	applications map[string]bool
+}
+
+// NewAvailabilitySnapshot builds a snapshot from installed package names (optionally tap
+// qualified), executable names found in PATH and app bundle names
+func NewAvailabilitySnapshot(formulae, casks, executables, applications []string) *AvailabilitySnapshot {
+	snapshot := &AvailabilitySnapshot{
+		CreatedAt:    time.Now().UTC(),
+		Formulae:     sortedUnique(formulae),
+		Casks:        sortedUnique(casks),
+		Executables:  sortedUnique(executables),
+		Applications: sortedUnique(applications),
+	}
+	snapshot.prepare()
+	return snapshot
+}
+
+// prepare builds the lookup sets. Installed packages are keyed by their short name
+// and map to the name brew reports, which is tap qualified for third-party taps.
+func (s *AvailabilitySnapshot) prepare() {
+	s.formulae = packageSet(s.Formulae)
+	s.casks = packageSet(s.Casks)
+	s.executables = stringSet(s.Executables)
+	// The macOS file system is case-insensitive, so app bundles are matched regardless of case
+	s.applications = make(map[string]bool, len(s.Applications))
+	for _, appName := range s.Applications {
+		s.applications[strings.ToLower(appName)] = true
+	}
+}
+
+// TakeAvailabilitySnapshot snapshots installed Homebrew formulae and casks, the executables
+// in PATH and the app bundles in the Applications directories
+func TakeAvailabilitySnapshot() (*AvailabilitySnapshot, error) {
+	var formulae, casks []string
+	if IsBrewInstalled() {
+		var err error
+		if formulae, err = listInstalled("--formula"); err != nil {
+			return nil, err
+		}
+		if casks, err = listInstalled("--cask"); err != nil {
+			return nil, err
+		}
+	}
+
+	executables := scanExecutables(os.Getenv(constants.EnvPath))
+
+	var applications []string
+	if system.IsMacOS() {
+		applications = scanApplications(applicationDirs())
+	}
+
+	return NewAvailabilitySnapshot(formulae, casks, executables, applications), nil
+}
+
+// LoadAvailabilitySnapshot returns the cached snapshot when it is younger than maxAge,
+// otherwise takes a new snapshot and caches it. A failure to cache is not an error.
+func LoadAvailabilitySnapshot(maxAge time.Duration) (*AvailabilitySnapshot, error) {
+	cachePath, pathErr := AvailabilityCachePath()
+	if pathErr == nil {
+		if snapshot, err := readAvailabilitySnapshot(cachePath); err == nil && time.Since(snapshot.CreatedAt) < maxAge {
+			return snapshot, nil
+		}
+	}
+
+	snapshot, err := TakeAvailabilitySnapshot()
+	if err != nil {
+		return nil, err
+	}
+	if pathErr == nil {
+		_ = snapshot.Save(cachePath)
+	}
+	return snapshot, nil
+}
+
+// InvalidateAvailabilityCache removes the cached snapshot, e.g. after installing an app
+func InvalidateAvailabilityCache() {
+	if cachePath, err := AvailabilityCachePath(); err == nil {
+		os.Remove(cachePath)
+	}
+}
+
+// AvailabilityCachePath returns the location of the cached availability snapshot
+func AvailabilityCachePath() (string, error) {
+	homeDir, err := system.HomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, constants.ANVIL_CONFIG_DIR, constants.CACHE_DIR, constants.AVAILABILITY_FILE), nil
+}
+
+// Save writes the snapshot to disk
+func (s *AvailabilitySnapshot) Save(cachePath string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode availability snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), constants.DirPerm); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(cachePath, data, constants.FilePerm); err != nil {
+		return fmt.Errorf("failed to write availability snapshot: %w", err)
+	}
+	return nil
+}
+
+// readAvailabilitySnapshot reads a snapshot written by Save
+func readAvailabilitySnapshot(cachePath string) (*AvailabilitySnapshot, error) {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot AvailabilitySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse availability snapshot: %w", err)
+	}
+	snapshot.prepare()
+	return &snapshot, nil
+}
+
+// IsAvailable reports whether an app is available, following the same order as
+// IsApplicationAvailable: installed with Homebrew, app bundle of a cask, then PATH.
+// Spotlight is not searched; apps outside the Applications directories are missed.
+// A nil snapshot checks the system directly with IsApplicationAvailable.
+func (s *AvailabilitySnapshot) IsAvailable(packageName string) bool {
+	if s == nil {
+		return IsApplicationAvailable(packageName)
+	}
+
+	if _, installed := s.InstalledName(packageName); installed {
+		return true
+	}
+
+	name := ServiceName(packageName)
+	if isKnownCask(packageName) {
+		for _, appName := range generateOptimizedAppNames(packageName) {
+			if s.applications[strings.ToLower(appName)] {
+				return true
+			}
+		}
+	}
+
+	if s.executables[name] {
+		return true
+	}
+	if isKnownFormula(packageName) {
+		return false
+	}
+
+	return s.applications[strings.ToLower(name)+".app"]
+}
+
+// InstalledName returns the name brew lists an installed package under, which is tap
+// qualified for packages from third-party taps
+func (s *AvailabilitySnapshot) InstalledName(packageName string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	for _, installed := range []map[string]string{s.formulae, s.casks} {
+		if fullName, ok := installed[ServiceName(packageName)]; ok {
+			// A tap qualified name only matches a package installed from that tap
+			if strings.Count(packageName, "/") == 2 && !strings.EqualFold(fullName, packageName) && !isDefaultTapName(packageName) {
+				continue
+			}
+			return fullName, true
+		}
+	}
+	return "", false
+}
+
+// Partition splits tools into the ones already available and the ones still to install
+func (s *AvailabilitySnapshot) Partition(tools []string) (available, missing []string) {
+	for _, tool := range tools {
+		if s.IsAvailable(tool) {
+			available = append(available, tool)
+		} else {
+			missing = append(missing, tool)
+		}
+	}
+	return available, missing
+}
+
+// InstalledCaskForApp returns the installed cask whose app bundle is appName, if any
+func (s *AvailabilitySnapshot) InstalledCaskForApp(appName string) (string, bool) {
+	index := CurrentIndex()
+	for _, cask := range s.Casks {
+		for _, app := range index.AppNames(cask) {
+			if app == appName {
+				return cask, true
+			}
+		}
+	}
+	return "", false
+}
+
+// isDefaultTapName reports whether a package name is qualified with a built-in Homebrew tap
+func isDefaultTapName(packageName string) bool {
+	return strings.HasPrefix(packageName, coreTapPrefix) || strings.HasPrefix(packageName, caskTapPrefix)
+}
+
+// listInstalled lists installed formulae or casks with their full names
+func listInstalled(kindFlag string) ([]string, error) {
+	result, err := system.RunCommand(constants.BrewCommand, constants.BrewList, kindFlag, "--full-name", "-1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run brew list: %w", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("failed to list installed packages: %s", result.Error)
+	}
+	return strings.Fields(result.Output), nil
+}
+
+// scanExecutables returns the names of executable files in the directories of a PATH value
+func scanExecutables(pathEnv string) []string {
+	var executables []string
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			// Stat follows symlinks, which is how Homebrew links executables into PATH
+			info, err := os.Stat(filepath.Join(dir, entry.Name()))
+			if err != nil || info.IsDir() || info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			executables = append(executables, entry.Name())
+		}
+	}
+	return executables
+}
+
+// applicationDirs returns the directories searched for app bundles
+func applicationDirs() []string {
+	dirs := []string{"/Applications"}
+	if homeDir, err := system.HomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, "Applications"))
+	}
+	return dirs
+}
+
+// ScanApplications returns the app bundle names in the Applications directories,
+// without asking Homebrew
+func ScanApplications() []string {
+	return scanApplications(applicationDirs())
+}
+
+// scanApplications returns the app bundle names found in the given directories
+func scanApplications(dirs []string) []string {
+	var applications []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasSuffix(entry.Name(), ".app") {
+				applications = append(applications, entry.Name())
+			}
+		}
+	}
+	return applications
+}
+
+// packageSet maps the short name of each package to its listed name
+func packageSet(names []string) map[string]string {
+	set := make(map[string]string, len(names))
+	for _, name := range names {
+		set[path.Base(name)] = name
+	}
+	return set
+}
+
+// stringSet converts a list of names to a set
+func stringSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// sortedUnique returns a sorted copy of names without duplicates
+func sortedUnique(names []string) []string {
+	set := stringSet(names)
+	unique := make([]string, 0, len(set))
+	for name := range set {
+		unique = append(unique, name)
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAvailabilitySnapshotIsAvailable(t *testing.T) {
+	useIndexFixture(t)
+
+	snapshot := NewAvailabilitySnapshot(
+		[]string{"git", "hashicorp/tap/terraform"},
+		[]string{"iterm2"},
+		[]string{"go", "zsh"},
+		[]string{"Visual Studio Code.app", "Figma.app"},
+	)
+
+	tests := []struct {
+		pkg      string
+		expected bool
+	}{
+		{"git", true},                     // installed formula
+		{"homebrew/core/git", true},       // default tap qualified
+		{"hashicorp/tap/terraform", true}, // third-party tap qualified
+		{"terraform", true},               // short name of a tapped package
+		{"other/tap/terraform", false},    // same name from a different tap
+		{"iterm2", true},                  // installed cask
+		{"visual-studio-code", true},      // cask app bundle from the index
+		{"zsh", true},                     // executable in PATH
+		{"figma", true},                   // unknown package with a matching app bundle
+		{"postgresql@16", false},          // known formula that is not installed
+		{"slack", false},                  // nothing found
+	}
+
+	for _, tt := range tests {
+		if got := snapshot.IsAvailable(tt.pkg); got != tt.expected {
+			t.Errorf("IsAvailable(%q) = %v, expected %v", tt.pkg, got, tt.expected)
+		}
+	}
+
+	available, missing := snapshot.Partition([]string{"git", "slack", "zsh", "postgresql@16"})
+	if !reflect.DeepEqual(available, []string{"git", "zsh"}) || !reflect.DeepEqual(missing, []string{"slack", "postgresql@16"}) {
+		t.Errorf("Unexpected partition: available %v, missing %v", available, missing)
+	}
+}
+
+func TestAvailabilitySnapshotInstalledCaskForApp(t *testing.T) {
+	useIndexFixture(t)
+
+	snapshot := NewAvailabilitySnapshot(nil, []string{"iterm2"}, nil, []string{"iTerm.app"})
+	if cask, ok := snapshot.InstalledCaskForApp("iTerm.app"); !ok || cask != "iterm2" {
+		t.Errorf("Expected iTerm.app to belong to iterm2, got %q, %v", cask, ok)
+	}
+	if _, ok := snapshot.InstalledCaskForApp("Visual Studio Code.app"); ok {
+		t.Error("Expected no installed cask for an app whose cask is not installed")
+	}
+}
+
+func TestAvailabilitySnapshotSaveAndRead(t *testing.T) {
+	snapshot := NewAvailabilitySnapshot([]string{"git", "git"}, nil, []string{"zsh"}, nil)
+	cachePath := filepath.Join(t.TempDir(), "cache", "availability.json")
+	if err := snapshot.Save(cachePath); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	loaded, err := readAvailabilitySnapshot(cachePath)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Formulae, []string{"git"}) || !loaded.IsAvailable("zsh") {
+		t.Errorf("Loaded snapshot differs: %+v", loaded)
+	}
+}
+
+func TestLoadAvailabilitySnapshotUsesFreshCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cached := NewAvailabilitySnapshot([]string{"cached-formula"}, nil, nil, nil)
+	cachePath, err := AvailabilityCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cached.Save(cachePath); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := LoadAvailabilitySnapshot(time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !snapshot.IsAvailable("cached-formula") {
+		t.Error("Expected the fresh cached snapshot to be used")
+	}
+
+	InvalidateAvailabilityCache()
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Errorf("Expected cache to be removed, got %v", err)
+	}
+}
+
+func TestScanExecutablesAndApplications(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "tool"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "README"), []byte("docs"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(binDir, "tool"), filepath.Join(binDir, "tool-link")); err != nil {
+		t.Fatal(err)
+	}
+
+	executables := scanExecutables(binDir + string(os.PathListSeparator) + filepath.Join(binDir, "missing"))
+	if !reflect.DeepEqual(sortedUnique(executables), []string{"tool", "tool-link"}) {
+		t.Errorf("Unexpected executables: %v", executables)
+	}
+
+	appDir := t.TempDir()
+	for _, name := range []string{"Slack.app", "Utilities"} {
+		if err := os.Mkdir(filepath.Join(appDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := scanApplications([]string{appDir, filepath.Join(appDir, "missing")}); !reflect.DeepEqual(got, []string{"Slack.app"}) {
+		t.Errorf("Unexpected applications: %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/system"
+)
+
+// TappedRepositories returns the names of the taps currently added to Homebrew
+func TappedRepositories() ([]string, error) {
+	if !IsBrewInstalled() {
+		return nil, fmt.Errorf("Homebrew is not installed")
+	}
+
+	result, err := system.RunCommand(constants.BrewCommand, constants.BrewTap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run brew tap: %w", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("failed to list taps: %s", result.Error)
+	}
+
+	var taps []string
+	for _, line := range strings.Split(strings.TrimSpace(result.Output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			taps = append(taps, line)
+		}
+	}
+	return taps, nil
+}
+
+// IsTapped reports whether a tap name is in the list of tapped repositories.
+// Tap names are case-insensitive.
+func IsTapped(tapName string, tapped []string) bool {
+	for _, name := range tapped {
+		if strings.EqualFold(name, tapName) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tap adds a Homebrew tap. The URL is optional and only needed for taps not hosted on GitHub.
+func Tap(tapName, url string) error {
+	if !IsBrewInstalled() {
+		return fmt.Errorf("Homebrew is not installed")
+	}
+
+	args := []string{constants.BrewTap, tapName}
+	if url != "" {
+		args = append(args, url)
+	}
+
+	result, err := system.RunCommand(constants.BrewCommand, args...)
+	if err != nil {
+		return fmt.Errorf("failed to run brew tap: %w", err)
+	}
+	if !result.Success {
+		if result.Output != "" {
+			return fmt.Errorf("failed to tap %s: %s", tapName, strings.TrimSpace(result.Output))
+		}
+		return fmt.Errorf("failed to tap %s: %s", tapName, result.Error)
+	}
+	return nil
+}
+
+// TapFromPackage returns the tap of a tap-qualified package name ("owner/tap/name"),
+// or false for plain package names
+func TapFromPackage(packageName string) (string, bool) {
+	parts := strings.Split(packageName, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[0] + "/" + parts[1], true
+}
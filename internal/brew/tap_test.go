@@ -0,0 +1,46 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brew
+
+import "testing"
+
+func TestIsTapped(t *testing.T) {
+	tapped := []string{"homebrew/cask", "hashicorp/tap"}
+	if !IsTapped("HashiCorp/tap", tapped) {
+		t.Error("Expected tap names to match case-insensitively")
+	}
+	if IsTapped("company/private", tapped) {
+		t.Error("Expected company/private not to be tapped")
+	}
+}
+
+func TestTapFromPackage(t *testing.T) {
+	tests := map[string]string{
+		"nikitabobko/tap/aerospace": "nikitabobko/tap",
+		"hashicorp/tap/terraform":   "hashicorp/tap",
+		"git":                       "",
+		"owner/name":                "",
+		"owner//name":               "",
+	}
+
+	for packageName, expected := range tests {
+		tap, ok := TapFromPackage(packageName)
+		if tap != expected || ok != (expected != "") {
+			t.Errorf("TapFromPackage(%q) = %q, %v, expected %q", packageName, tap, ok, expected)
+		}
+	}
+}
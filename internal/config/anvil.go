@@ -33,13 +33,23 @@ import (
 
 // AnvilConfig represents the main anvil configuration
 type AnvilConfig struct {
-	Version string            `yaml:"version"`
-	Tools   AnvilTools        `yaml:"tools"`
-	Groups  AnvilGroups       `yaml:"groups"`
-	Configs map[string]string `yaml:"configs"` // Maps app names to their local config paths
-	Sources map[string]string `yaml:"sources"` // Maps app names to their download URLs
-	Git     GitConfig         `yaml:"git"`
-	GitHub  GitHubConfig      `yaml:"github"`
+	Version       string                   `yaml:"version"`
+	Tools         AnvilTools               `yaml:"tools"`
+	Groups        AnvilGroups              `yaml:"groups"`
+	Configs       map[string]string        `yaml:"configs"`                  // Maps app names to their local config paths
+	Sources       map[string]AppSource     `yaml:"sources"`                  // Maps app names to their download URLs, per platform if needed
+	SourceOptions map[string]SourceOptions `yaml:"source_options,omitempty"` // Per-app download headers and mirrors
+	Git           GitConfig                `yaml:"git"`
+	GitHub        GitHubConfig             `yaml:"github"`
+	Taps          []BrewTap                `yaml:"taps,omitempty"`         // Homebrew taps ensured before installs
+	Brew          AnvilBrewOptions         `yaml:"brew_options,omitempty"` // Per-app 'brew install' options
+	Services      map[string]string        `yaml:"services,omitempty"`     // Maps app names to the brew service action run after install
+	Aliases       map[string]string        `yaml:"aliases,omitempty"`      // Maps friendly app names to Homebrew package names
+	Hooks         AnvilHooks               `yaml:"hooks,omitempty"`        // Commands run around installations
+	Install       AnvilInstallOptions      `yaml:"install,omitempty"`      // Where archives and binaries from sources are installed
+	Network       AnvilNetworkOptions      `yaml:"network,omitempty"`      // Proxy and CA bundle used for downloads
+	Imports       []ImportOrigin           `yaml:"imports,omitempty"`      // Tracks where imported groups came from
+	Trust         ImportTrustConfig        `yaml:"import_trust,omitempty"` // Which import files are trusted
 }
 
 // AnvilConfigDirectory returns the path to the anvil config directory
@@ -24,6 +24,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/0xjuanma/anvil/internal/brew"
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/0xjuanma/anvil/internal/system"
 	"github.com/0xjuanma/anvil/internal/utils"
@@ -61,12 +62,192 @@ type GitHubConfig struct {
 	TokenEnvVar string `yaml:"token_env_var,omitempty"` // Environment variable name for token
 }
 
+// AccessToken returns the GitHub token, read from TokenEnvVar when set, otherwise from
+// Token with environment variable references expanded
+func (g GitHubConfig) AccessToken() string {
+	if g.TokenEnvVar != "" {
+		if token := os.Getenv(g.TokenEnvVar); token != "" {
+			return token
+		}
+	}
+	return os.ExpandEnv(g.Token)
+}
+
 // AnvilTools represents tool configurations
 type AnvilTools struct {
 	RequiredTools []string `yaml:"required_tools"`
 	InstalledApps []string `yaml:"installed_apps"` // Tracks individually installed applications
 }
 
+// BrewTap represents a Homebrew tap, optionally with a custom repository URL
+type BrewTap struct {
+	Name string `yaml:"name"`          // Tap name, e.g. "nikitabobko/tap"
+	URL  string `yaml:"url,omitempty"` // Git URL for taps not hosted on GitHub
+}
+
+// AnvilBrewOptions maps app names to their 'brew install' options
+type AnvilBrewOptions map[string]BrewInstallOptions
+
+// BrewInstallOptions customizes how Homebrew installs a single app
+type BrewInstallOptions struct {
+	Type string            `yaml:"type,omitempty"` // "cask" or "formula", detected when empty
+	Args []string          `yaml:"args,omitempty"` // Extra 'brew install' arguments
+	Env  map[string]string `yaml:"env,omitempty"`  // Environment variables for 'brew install'
+}
+
+// IsCask reports whether an app is installed as a cask: its configured type when set,
+// otherwise the result of detect
+func (o AnvilBrewOptions) IsCask(appName string, detect func(string) bool) bool {
+	if options, exists := o[appName]; exists && options.Type != "" {
+		return options.Type == brew.PackageTypeCask
+	}
+	return detect(appName)
+}
+
+// ImportBrewOptions represents the brew_options a shared file may carry. Only the package
+// type is shared; arguments and environment stay personal.
+type ImportBrewOptions struct {
+	Type string `yaml:"type"`
+}
+
+// AnvilHooks represents commands run around installations
+type AnvilHooks struct {
+	PostInstall map[string][]string `yaml:"post_install,omitempty"` // Maps app names to commands run after they are installed
+}
+
+// AnvilInstallOptions controls where archives and binaries from sources are installed.
+// Apps are placed in <prefix>/opt/<app> with links to their executables in <prefix>/bin,
+// so no root access is needed.
+type AnvilInstallOptions struct {
+	Prefix       string            `yaml:"prefix,omitempty"`        // User install prefix (default ~/.local)
+	Mode         string            `yaml:"mode,omitempty"`          // Linux archive layout: "prefix" (default) or "applications"
+	TrustScripts string            `yaml:"trust_scripts,omitempty"` // "prompt" (default), "pinned" or "all"
+	ScriptSHA256 map[string]string `yaml:"script_sha256,omitempty"` // Maps app names to the digest of their install script
+}
+
+// AnvilNetworkOptions configures how anvil reaches the network for source downloads,
+// imports and updates
+type AnvilNetworkOptions struct {
+	Proxy    string `yaml:"proxy,omitempty"`     // Proxy URL for all requests (default: HTTPS_PROXY, HTTP_PROXY and NO_PROXY)
+	CABundle string `yaml:"ca_bundle,omitempty"` // PEM file of certificate authorities trusted besides the system ones
+}
+
+// CABundlePath returns the CA bundle path with ~ expanded, or "" when none is set
+func (o AnvilNetworkOptions) CABundlePath() string {
+	if o.CABundle == "" {
+		return ""
+	}
+	return expandHomePath(o.CABundle)
+}
+
+// SourceOptions controls how an app's source is downloaded
+type SourceOptions struct {
+	Headers map[string]string `yaml:"headers,omitempty"` // Request headers whose values reference environment variables, e.g. "Bearer ${TOKEN}"
+	Mirrors []string          `yaml:"mirrors,omitempty"` // URLs tried in order before the source; {file} is replaced by its file name
+}
+
+// HasEnvReference reports whether a value takes its content from an environment variable
+func HasEnvReference(value string) bool {
+	return envReferenceRegex.MatchString(value)
+}
+
+// Trust policies for remote install scripts without a pinned sha256
+const (
+	ScriptTrustPrompt = "prompt" // Preview the script and ask before running it (default)
+	ScriptTrustPinned = "pinned" // Refuse scripts that are not pinned
+	ScriptTrustAll    = "all"    // Run scripts without asking
+)
+
+// Layouts for archives installed on Linux
+const (
+	InstallModePrefix       = "prefix"       // <prefix>/opt/<app> with executables linked into <prefix>/bin (default)
+	InstallModeApplications = "applications" // Copied to ~/.local/share/applications/<app> without links
+)
+
+// IsInstallMode reports whether mode is a known install mode
+func IsInstallMode(mode string) bool {
+	return mode == InstallModePrefix || mode == InstallModeApplications
+}
+
+// IsScriptTrustPolicy reports whether policy is a known script trust policy
+func IsScriptTrustPolicy(policy string) bool {
+	return policy == ScriptTrustPrompt || policy == ScriptTrustPinned || policy == ScriptTrustAll
+}
+
+// PrefixDir returns the install prefix with ~ expanded
+func (o AnvilInstallOptions) PrefixDir() string {
+	if o.Prefix == "" {
+		return expandHomePath(constants.UserInstallPrefix)
+	}
+	return expandHomePath(o.Prefix)
+}
+
+// BinDir returns the directory holding links to installed executables
+func (o AnvilInstallOptions) BinDir() string {
+	return filepath.Join(o.PrefixDir(), constants.UserInstallBinDir)
+}
+
+// AppDir returns the directory an app is installed into
+func (o AnvilInstallOptions) AppDir(appName string) string {
+	return filepath.Join(o.PrefixDir(), constants.UserInstallAppsDir, appName)
+}
+
+// ImportTools represents the tool settings a shared file may carry
+type ImportTools struct {
+	RequiredTools []string `yaml:"required_tools,omitempty"`
+}
+
+// Import trust policies
+const (
+	ImportPolicyWarn   = "warn"   // Untrusted imports show a warning (default)
+	ImportPolicyStrict = "strict" // Untrusted imports are refused
+)
+
+// ImportTrustConfig controls which import files are trusted
+type ImportTrustConfig struct {
+	Policy       string   `yaml:"policy,omitempty"`        // "warn" (default) or "strict"
+	AllowedHosts []string `yaml:"allowed_hosts,omitempty"` // HTTPS hosts imports are trusted from (e.g. "*.example.com")
+	PublicKeys   []string `yaml:"public_keys,omitempty"`   // Ed25519 or minisign public keys for signed catalogs
+	AllowLocal   bool     `yaml:"allow_local,omitempty"`   // Trust local files and git+file repositories
+}
+
+// ImportOrigin records where imported groups came from so they can be refreshed
+type ImportOrigin struct {
+	Source    string            `yaml:"source"`                  // URL or absolute path of the import file
+	FetchedAt string            `yaml:"fetched_at"`              // RFC3339 time of the last fetch
+	SHA256    string            `yaml:"sha256"`                  // Digest of the last fetched content
+	Strategy  string            `yaml:"strategy,omitempty"`      // Conflict strategy used for the import
+	Groups    map[string]string `yaml:"groups"`                  // Maps group names in the file to names in settings
+	Format    string            `yaml:"format,omitempty"`        // "brewfile" for Brewfiles, YAML otherwise
+	Pinned    string            `yaml:"pinned_sha256,omitempty"` // Digest given with --sha256, enforced on refresh
+}
+
+// ImportMetadata describes a shared group file. It is informational only and never imported
+type ImportMetadata struct {
+	Name         string `yaml:"name,omitempty"`
+	Description  string `yaml:"description,omitempty"`
+	ExportedAt   string `yaml:"exported_at,omitempty"`   // RFC3339 time the file was exported
+	AnvilVersion string `yaml:"anvil_version,omitempty"` // anvil version that exported the file
+}
+
+// ImportConfig represents the structure of a shared group file accepted by 'anvil config import'
+type ImportConfig struct {
+	Metadata ImportMetadata               `yaml:"metadata,omitempty"`
+	Groups   AnvilGroups                  `yaml:"groups,omitempty"`
+	Sources  map[string]AppSource         `yaml:"sources,omitempty"`
+	Configs  map[string]string            `yaml:"configs,omitempty"`
+	Tools    ImportTools                  `yaml:"tools,omitempty"`
+	Taps     []BrewTap                    `yaml:"taps,omitempty"`
+	Brew     map[string]ImportBrewOptions `yaml:"brew_options,omitempty"`
+	Hooks    AnvilHooks                   `yaml:"hooks,omitempty"`
+}
+
+// IsEmpty reports whether an import file carries nothing to import
+func (ic *ImportConfig) IsEmpty() bool {
+	return len(ic.Groups) == 0 && len(ic.Sources) == 0 && len(ic.Configs) == 0 &&
+		len(ic.Tools.RequiredTools) == 0 && len(ic.Taps) == 0 && len(ic.Brew) == 0 && len(ic.Hooks.PostInstall) == 0
+}
+
 // getCachedConfig returns the cached configuration or loads it if not cached.
 // Uses double-checked locking for thread-safe caching.
 func getCachedConfig() (*AnvilConfig, error) {
@@ -344,6 +525,60 @@ func (ls LocationSource) String() string {
 	}
 }
 
+// AppBrewOptions returns the 'brew install' options configured for an app
+func AppBrewOptions(appName string) (brew.InstallOptions, error) {
+	var opts brew.InstallOptions
+	err := withConfig(func(config *AnvilConfig) error {
+		if appOpts, exists := config.Brew[appName]; exists {
+			opts = brew.InstallOptions{Type: appOpts.Type, Args: appOpts.Args, Env: appOpts.Env}
+		}
+		return nil
+	})
+	return opts, err
+}
+
+// AppServiceAction returns the brew service action configured for an app, or "" if none
+func AppServiceAction(appName string) (string, error) {
+	var action string
+	err := withConfig(func(config *AnvilConfig) error {
+		action = config.Services[appName]
+		return nil
+	})
+	return action, err
+}
+
+// ResolveAppName returns the Homebrew package an app name refers to, following the
+// aliases section. Built-in aliases only drive suggestions, since some of them are real
+// package names. Names without an alias are returned unchanged.
+func ResolveAppName(appName string) string {
+	aliases, _ := UserAliases()
+	if target, ok := brew.LookupUserAlias(appName, aliases); ok {
+		return target
+	}
+	return appName
+}
+
+// UserAliases returns the aliases section of settings.yaml
+func UserAliases() (map[string]string, error) {
+	var aliases map[string]string
+	err := withConfig(func(config *AnvilConfig) error {
+		aliases = config.Aliases
+		return nil
+	})
+	return aliases, err
+}
+
+// SetAppAlias remembers that an app name refers to a Homebrew package
+func SetAppAlias(appName, packageName string) error {
+	return withConfigAndSave(func(config *AnvilConfig) error {
+		if config.Aliases == nil {
+			config.Aliases = make(map[string]string)
+		}
+		config.Aliases[appName] = packageName
+		return nil
+	})
+}
+
 // AppConfigPath checks if an app has a configured local path in the configs section
 func AppConfigPath(appName string) (string, bool, error) {
 	config, err := getCachedConfig()
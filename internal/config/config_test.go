@@ -351,6 +351,38 @@ func TestAppConfigFunctions(t *testing.T) {
 	}
 }
 
+func TestAppBrewOptions(t *testing.T) {
+	_, cleanup := setupTestConfig(t)
+	defer cleanup()
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.Brew = AnvilBrewOptions{
+		"firefox": {Type: "cask", Args: []string{"--no-quarantine"}, Env: map[string]string{"HOMEBREW_NO_AUTO_UPDATE": "1"}},
+	}
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	opts, err := AppBrewOptions("firefox")
+	if err != nil {
+		t.Fatalf("Failed to get brew options: %v", err)
+	}
+	if opts.Type != "cask" || len(opts.Args) != 1 || opts.Env["HOMEBREW_NO_AUTO_UPDATE"] != "1" {
+		t.Errorf("Unexpected brew options: %+v", opts)
+	}
+
+	opts, err = AppBrewOptions("git")
+	if err != nil {
+		t.Fatalf("Failed to get brew options: %v", err)
+	}
+	if !opts.IsEmpty() {
+		t.Errorf("Expected no brew options for git, got %+v", opts)
+	}
+}
+
 // Test group management functions
 func TestGroupManagementFunctions(t *testing.T) {
 	_, cleanup := setupTestConfig(t)
@@ -398,3 +430,47 @@ func TestGroupManagementFunctions(t *testing.T) {
 		t.Errorf("Expected at least 3 groups, got %d", len(groups))
 	}
 }
+
+func TestInstallOptionsDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	var defaults AnvilInstallOptions
+	if got := defaults.BinDir(); got != filepath.Join(home, ".local", "bin") {
+		t.Errorf("Expected default bin directory under ~/.local, got %s", got)
+	}
+	if got := defaults.AppDir("tool"); got != filepath.Join(home, ".local", "opt", "tool") {
+		t.Errorf("Expected default app directory under ~/.local/opt, got %s", got)
+	}
+
+	custom := AnvilInstallOptions{Prefix: "~/tools"}
+	if got := custom.BinDir(); got != filepath.Join(home, "tools", "bin") {
+		t.Errorf("Expected ~ to be expanded in the prefix, got %s", got)
+	}
+	if got := (AnvilInstallOptions{Prefix: "/srv/user"}).AppDir("tool"); got != "/srv/user/opt/tool" {
+		t.Errorf("Expected an absolute prefix to be kept, got %s", got)
+	}
+}
+
+func TestValidateAppNameRejectsDotSegments(t *testing.T) {
+	validator := NewConfigValidator(nil)
+	for _, name := range []string{"git", "node@20", "c++", "owner/tap/tool", ".hidden", "tool.app"} {
+		if err := validator.ValidateAppName(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "...", "owner/../tool", "../x/tool", "owner/./tool"} {
+		if err := validator.ValidateAppName(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestResolveAppNameIgnoresBuiltinAliases(t *testing.T) {
+	// Built-in aliases such as box -> box-drive name real packages too
+	for _, name := range []string{"box", "teams", "edge", "brave"} {
+		if got := ResolveAppName(name); got != name {
+			t.Errorf("Expected %s to install as itself, got %s", name, got)
+		}
+	}
+}
@@ -0,0 +1,585 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/system"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// yamlErrorLinePattern extracts the line number from yaml parser error messages
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// configRepoPattern matches the expected "username/repository" format
+var configRepoPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+/[a-zA-Z0-9._-]+$`)
+
+// envVarNameRegex matches valid environment variable names
+var envVarNameRegex = regexp.MustCompile(envVarNamePattern)
+
+// appNameRegex matches valid application names
+var appNameRegex = regexp.MustCompile(appNamePattern)
+
+// sha256Regex matches hex SHA-256 digests
+var sha256Regex = regexp.MustCompile(sha256Pattern)
+
+// envReferenceRegex matches ${VAR} and $VAR references in a value
+var envReferenceRegex = regexp.MustCompile(envReferencePattern)
+
+// Diagnostic describes a single problem found in a settings file, with its position
+type Diagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// String formats the diagnostic as file:line:column: message
+func (d Diagnostic) String() string {
+	switch {
+	case d.Line > 0 && d.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+	case d.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	default:
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+}
+
+// diagnostics collects problems for a single file
+type diagnostics struct {
+	file  string
+	items []Diagnostic
+}
+
+// add records a problem at the position of the given node
+func (d *diagnostics) add(node *yamlv3.Node, format string, args ...interface{}) {
+	diag := Diagnostic{File: d.file, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		diag.Line = node.Line
+		diag.Column = node.Column
+	}
+	d.items = append(d.items, diag)
+}
+
+// sorted returns the collected diagnostics ordered by position
+func (d *diagnostics) sorted() []Diagnostic {
+	sort.SliceStable(d.items, func(i, j int) bool {
+		if d.items[i].Line != d.items[j].Line {
+			return d.items[i].Line < d.items[j].Line
+		}
+		return d.items[i].Column < d.items[j].Column
+	})
+	return d.items
+}
+
+// DiagnoseSettingsFile reads a settings file and reports every problem found in it.
+// An error is only returned if the file cannot be read.
+func DiagnoseSettingsFile(path string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return DiagnoseSettings(path, data), nil
+}
+
+// DiagnoseSettings parses settings data with position information and reports every problem found.
+// It covers YAML syntax, unknown keys, duplicate apps, invalid group names,
+// nonexistent config paths and malformed repository URLs.
+func DiagnoseSettings(file string, data []byte) []Diagnostic {
+	diags := &diagnostics{file: file}
+
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		diags.items = append(diags.items, syntaxDiagnostic(file, err))
+		return diags.items
+	}
+
+	if len(root.Content) == 0 {
+		diags.add(nil, "file is empty")
+		return diags.items
+	}
+
+	doc := root.Content[0]
+	checkNodeShape(diags, doc, reflect.TypeOf(AnvilConfig{}), "")
+	if doc.Kind != yamlv3.MappingNode {
+		return diags.sorted()
+	}
+
+	checkToolsSection(diags, mappingValue(doc, "tools"))
+	checkGroupsSection(diags, mappingValue(doc, "groups"))
+	checkConfigsSection(diags, mappingValue(doc, "configs"))
+	checkGitHubSection(diags, mappingValue(doc, "github"))
+	checkImportTrustSection(diags, mappingValue(doc, "import_trust"))
+	checkBrewOptionsSection(diags, mappingValue(doc, "brew_options"))
+	checkServicesSection(diags, mappingValue(doc, "services"))
+	checkAliasesSection(diags, mappingValue(doc, "aliases"))
+	checkSourcesSection(diags, mappingValue(doc, "sources"))
+	checkInstallSection(diags, mappingValue(doc, "install"))
+	checkSourceOptionsSection(diags, mappingValue(doc, "source_options"))
+	checkNetworkSection(diags, mappingValue(doc, "network"))
+
+	return diags.sorted()
+}
+
+// syntaxDiagnostic converts a yaml parser error into a diagnostic
+func syntaxDiagnostic(file string, err error) Diagnostic {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	diag := Diagnostic{File: file, Message: "invalid YAML: " + message}
+	if matches := yamlErrorLinePattern.FindStringSubmatch(message); len(matches) > 1 {
+		diag.Line, _ = strconv.Atoi(matches[1])
+		diag.Message = "invalid YAML: " + strings.TrimSpace(yamlErrorLinePattern.ReplaceAllString(message, ""))
+		diag.Message = strings.Replace(diag.Message, ": :", ":", 1)
+	}
+	return diag
+}
+
+// checkNodeShape verifies that a node matches the Go type it will be decoded into,
+// reporting unknown keys, duplicate keys and mismatched value kinds.
+func checkNodeShape(diags *diagnostics, node *yamlv3.Node, t reflect.Type, path string) {
+	if node == nil || isNullNode(node) {
+		return
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Sources are a single value or a mapping of platforms, checked by checkSourcesSection
+	if t == appSourceType {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if !expectKind(diags, node, yamlv3.MappingNode, "a mapping", path) {
+			return
+		}
+		fields := yamlFields(t)
+		forEachUniqueKey(diags, node, path, func(key, value *yamlv3.Node) {
+			fieldType, known := fields[key.Value]
+			if !known {
+				diags.add(key, "unknown key '%s'%s", key.Value, describePath(path))
+				return
+			}
+			checkNodeShape(diags, value, fieldType, joinPath(path, key.Value))
+		})
+	case reflect.Map:
+		if !expectKind(diags, node, yamlv3.MappingNode, "a mapping", path) {
+			return
+		}
+		forEachUniqueKey(diags, node, path, func(key, value *yamlv3.Node) {
+			checkNodeShape(diags, value, t.Elem(), joinPath(path, key.Value))
+		})
+	case reflect.Slice:
+		if !expectKind(diags, node, yamlv3.SequenceNode, "a list", path) {
+			return
+		}
+		for _, item := range node.Content {
+			checkNodeShape(diags, item, t.Elem(), path)
+		}
+	default:
+		expectKind(diags, node, yamlv3.ScalarNode, "a single value", path)
+	}
+}
+
+// checkToolsSection reports duplicate entries across required_tools and installed_apps
+func checkToolsSection(diags *diagnostics, tools *yamlv3.Node) {
+	if tools == nil || tools.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	seen := make(map[string]*yamlv3.Node)
+	for _, section := range []string{"required_tools", "installed_apps"} {
+		list := mappingValue(tools, section)
+		if list == nil || list.Kind != yamlv3.SequenceNode {
+			continue
+		}
+		for _, item := range list.Content {
+			if item.Kind != yamlv3.ScalarNode {
+				continue
+			}
+			if first, exists := seen[item.Value]; exists {
+				diags.add(item, "duplicate app '%s' in tools.%s (first listed on line %d)", item.Value, section, first.Line)
+				continue
+			}
+			seen[item.Value] = item
+		}
+	}
+}
+
+// checkGroupsSection reports invalid group names, empty groups and duplicate apps within a group
+func checkGroupsSection(diags *diagnostics, groups *yamlv3.Node) {
+	if groups == nil || groups.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	validator := NewConfigValidator(nil)
+	for i := 0; i+1 < len(groups.Content); i += 2 {
+		key, value := groups.Content[i], groups.Content[i+1]
+
+		if err := validator.ValidateGroupName(key.Value); err != nil {
+			diags.add(key, "invalid group name: %v", err)
+		}
+
+		if value.Kind != yamlv3.SequenceNode {
+			continue
+		}
+		if len(value.Content) == 0 {
+			diags.add(key, "group '%s' cannot be empty", key.Value)
+			continue
+		}
+
+		seen := make(map[string]*yamlv3.Node, len(value.Content))
+		for _, item := range value.Content {
+			if item.Kind != yamlv3.ScalarNode {
+				continue
+			}
+			if first, exists := seen[item.Value]; exists {
+				diags.add(item, "duplicate app '%s' in group '%s' (first listed on line %d)", item.Value, key.Value, first.Line)
+				continue
+			}
+			seen[item.Value] = item
+		}
+	}
+}
+
+// checkConfigsSection reports config paths that do not exist on this machine
+func checkConfigsSection(diags *diagnostics, configs *yamlv3.Node) {
+	if configs == nil || configs.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(configs.Content); i += 2 {
+		key, value := configs.Content[i], configs.Content[i+1]
+		if value.Kind != yamlv3.ScalarNode || isNullNode(value) {
+			continue
+		}
+		if value.Value == "" {
+			diags.add(value, "config path for '%s' cannot be empty", key.Value)
+			continue
+		}
+		if _, err := os.Stat(expandHomePath(value.Value)); err != nil {
+			diags.add(value, "config path for '%s' does not exist: %s", key.Value, value.Value)
+		}
+	}
+}
+
+// checkGitHubSection reports a config_repo that cannot be resolved to "username/repository"
+func checkGitHubSection(diags *diagnostics, github *yamlv3.Node) {
+	if github == nil || github.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	repo := mappingValue(github, "config_repo")
+	if repo == nil || repo.Kind != yamlv3.ScalarNode || repo.Value == "" {
+		return
+	}
+
+	if !configRepoPattern.MatchString(normalizeGitHubRepo(repo.Value)) {
+		diags.add(repo, "malformed config_repo '%s' (expected 'username/repository' or a GitHub URL)", repo.Value)
+	}
+}
+
+// checkImportTrustSection reports an unknown import policy
+func checkImportTrustSection(diags *diagnostics, trust *yamlv3.Node) {
+	policy := mappingValue(trust, "policy")
+	if policy == nil || policy.Kind != yamlv3.ScalarNode || isNullNode(policy) {
+		return
+	}
+
+	if policy.Value != ImportPolicyWarn && policy.Value != ImportPolicyStrict {
+		diags.add(policy, "unknown import_trust policy '%s' (expected '%s' or '%s')", policy.Value, ImportPolicyWarn, ImportPolicyStrict)
+	}
+}
+
+// checkBrewOptionsSection reports unknown package types and invalid environment variable names
+func checkBrewOptionsSection(diags *diagnostics, brewOptions *yamlv3.Node) {
+	if brewOptions == nil || brewOptions.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(brewOptions.Content); i += 2 {
+		appName, options := brewOptions.Content[i].Value, brewOptions.Content[i+1]
+		if options.Kind != yamlv3.MappingNode {
+			continue
+		}
+
+		packageType := mappingValue(options, "type")
+		if packageType != nil && packageType.Kind == yamlv3.ScalarNode && !isNullNode(packageType) &&
+			packageType.Value != brew.PackageTypeCask && packageType.Value != brew.PackageTypeFormula {
+			diags.add(packageType, "unknown brew type '%s' for '%s' (expected '%s' or '%s')",
+				packageType.Value, appName, brew.PackageTypeCask, brew.PackageTypeFormula)
+		}
+
+		env := mappingValue(options, "env")
+		if env == nil || env.Kind != yamlv3.MappingNode {
+			continue
+		}
+		for j := 0; j < len(env.Content); j += 2 {
+			if key := env.Content[j]; !envVarNameRegex.MatchString(key.Value) {
+				diags.add(key, "invalid environment variable name '%s' for '%s'", key.Value, appName)
+			}
+		}
+	}
+}
+
+// checkServicesSection reports unknown service actions
+func checkServicesSection(diags *diagnostics, services *yamlv3.Node) {
+	if services == nil || services.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		appName, action := services.Content[i].Value, services.Content[i+1]
+		if action.Kind != yamlv3.ScalarNode || isNullNode(action) {
+			continue
+		}
+		switch action.Value {
+		case brew.ServiceStart, brew.ServiceRestart, brew.ServiceNone:
+		default:
+			diags.add(action, "unknown service action '%s' for '%s' (expected '%s', '%s' or '%s')",
+				action.Value, appName, brew.ServiceStart, brew.ServiceRestart, brew.ServiceNone)
+		}
+	}
+}
+
+// checkInstallSection reports an unknown install mode or script trust policy and malformed
+// script digests
+func checkInstallSection(diags *diagnostics, install *yamlv3.Node) {
+	if mode := mappingValue(install, "mode"); mode != nil && mode.Kind == yamlv3.ScalarNode && !isNullNode(mode) {
+		if !IsInstallMode(mode.Value) {
+			diags.add(mode, "unknown install mode '%s' (expected '%s' or '%s')",
+				mode.Value, InstallModePrefix, InstallModeApplications)
+		}
+	}
+	if policy := mappingValue(install, "trust_scripts"); policy != nil && policy.Kind == yamlv3.ScalarNode && !isNullNode(policy) {
+		if !IsScriptTrustPolicy(policy.Value) {
+			diags.add(policy, "unknown trust_scripts policy '%s' (expected '%s', '%s' or '%s')",
+				policy.Value, ScriptTrustPrompt, ScriptTrustPinned, ScriptTrustAll)
+		}
+	}
+
+	digests := mappingValue(install, "script_sha256")
+	if digests == nil || digests.Kind != yamlv3.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(digests.Content); i += 2 {
+		appName, digest := digests.Content[i].Value, digests.Content[i+1]
+		if digest.Kind != yamlv3.ScalarNode || isNullNode(digest) {
+			continue
+		}
+		if !sha256Regex.MatchString(digest.Value) {
+			diags.add(digest, "script_sha256 for '%s' is not a SHA-256 digest (expected 64 hex characters)", appName)
+		}
+	}
+}
+
+// checkSourceOptionsSection reports header values that hold secrets instead of
+// referencing environment variables, and mirrors that are not URLs
+func checkSourceOptionsSection(diags *diagnostics, sourceOptions *yamlv3.Node) {
+	if sourceOptions == nil || sourceOptions.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(sourceOptions.Content); i += 2 {
+		appName, options := sourceOptions.Content[i].Value, sourceOptions.Content[i+1]
+		if options.Kind != yamlv3.MappingNode {
+			continue
+		}
+
+		if headers := mappingValue(options, "headers"); headers != nil && headers.Kind == yamlv3.MappingNode {
+			for j := 0; j+1 < len(headers.Content); j += 2 {
+				name, value := headers.Content[j].Value, headers.Content[j+1]
+				if value.Kind == yamlv3.ScalarNode && !isNullNode(value) && !HasEnvReference(value.Value) {
+					diags.add(value, "header '%s' for '%s' must reference an environment variable such as ${TOKEN}", name, appName)
+				}
+			}
+		}
+
+		if mirrors := mappingValue(options, "mirrors"); mirrors != nil && mirrors.Kind == yamlv3.SequenceNode {
+			for _, mirror := range mirrors.Content {
+				if mirror.Kind == yamlv3.ScalarNode && !isURL(mirror.Value) {
+					diags.add(mirror, "mirror '%s' for '%s' is not an http(s) URL", mirror.Value, appName)
+				}
+			}
+		}
+	}
+}
+
+// checkNetworkSection reports a proxy that is not a URL
+func checkNetworkSection(diags *diagnostics, network *yamlv3.Node) {
+	proxy := mappingValue(network, "proxy")
+	if proxy == nil || proxy.Kind != yamlv3.ScalarNode || isNullNode(proxy) {
+		return
+	}
+
+	if parsed, err := url.Parse(proxy.Value); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		diags.add(proxy, "invalid proxy '%s' (expected a URL such as http://proxy.example.com:3128)", proxy.Value)
+	}
+}
+
+// isURL reports whether value is an absolute http or https URL
+func isURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// checkAliasesSection reports aliases that are not valid package names or refer to themselves
+func checkAliasesSection(diags *diagnostics, aliases *yamlv3.Node) {
+	if aliases == nil || aliases.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(aliases.Content); i += 2 {
+		alias, target := aliases.Content[i].Value, aliases.Content[i+1]
+		if target.Kind != yamlv3.ScalarNode || isNullNode(target) {
+			continue
+		}
+		switch {
+		case !appNameRegex.MatchString(target.Value):
+			diags.add(target, "invalid package name '%s' for alias '%s'", target.Value, alias)
+		case strings.EqualFold(target.Value, alias):
+			diags.add(target, "alias '%s' refers to itself", alias)
+		}
+	}
+}
+
+// checkSourcesSection reports sources that are neither a single value nor a mapping of
+// known platform keys to sources
+func checkSourcesSection(diags *diagnostics, sources *yamlv3.Node) {
+	if sources == nil || sources.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(sources.Content); i += 2 {
+		appName, source := sources.Content[i].Value, sources.Content[i+1]
+		path := joinPath("sources", appName)
+		switch source.Kind {
+		case yamlv3.ScalarNode:
+		case yamlv3.MappingNode:
+			forEachUniqueKey(diags, source, path, func(key, value *yamlv3.Node) {
+				if !IsValidSourcePlatform(key.Value) {
+					diags.add(key, "unknown platform '%s' for source '%s' (expected os-arch such as darwin-arm64 or linux-amd64, an os, or '%s')",
+						key.Value, appName, SourcePlatformDefault)
+				}
+				expectKind(diags, value, yamlv3.ScalarNode, "a single value", joinPath(path, key.Value))
+			})
+		default:
+			diags.add(source, "expected a single value or a mapping of platforms for '%s'", path)
+		}
+	}
+}
+
+// forEachUniqueKey calls fn for every key in a mapping node, reporting duplicated keys instead
+func forEachUniqueKey(diags *diagnostics, node *yamlv3.Node, path string, fn func(key, value *yamlv3.Node)) {
+	seen := make(map[string]*yamlv3.Node, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if first, exists := seen[key.Value]; exists {
+			diags.add(key, "duplicate key '%s'%s (first defined on line %d)", key.Value, describePath(path), first.Line)
+			continue
+		}
+		seen[key.Value] = key
+		fn(key, value)
+	}
+}
+
+// expectKind reports a diagnostic when a node is not of the expected kind
+func expectKind(diags *diagnostics, node *yamlv3.Node, kind yamlv3.Kind, description, path string) bool {
+	if node.Kind == kind {
+		return true
+	}
+	if path == "" {
+		diags.add(node, "expected %s at the top level", description)
+	} else {
+		diags.add(node, "expected %s for '%s'", description, path)
+	}
+	return false
+}
+
+// yamlFields maps the yaml key of each struct field to its type
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// mappingValue returns the value node for a key in a mapping node
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// isNullNode reports whether a node is an explicit or implicit YAML null
+func isNullNode(node *yamlv3.Node) bool {
+	return node.Kind == yamlv3.ScalarNode && node.Tag == "!!null"
+}
+
+// joinPath appends a key to a dotted settings path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// describePath renders a settings path for use in messages
+func describePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in '%s'", path)
+}
+
+// expandHomePath expands a leading ~ to the user's home directory
+func expandHomePath(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := system.HomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
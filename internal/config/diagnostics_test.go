@@ -0,0 +1,227 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseSettings(t *testing.T) {
+	existingDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []Diagnostic
+	}{
+		{
+			name: "valid settings",
+			content: `version: 1.0.0
+tools:
+  required_tools: [git, curl]
+  installed_apps: [slack]
+groups:
+  dev: [git, node]
+configs:
+  app: ` + existingDir + `
+github:
+  config_repo: https://github.com/user/dotfiles.git
+`,
+			expected: nil,
+		},
+		{
+			name:     "yaml syntax error",
+			content:  "groups:\n  dev: git\n    extra: true\n",
+			expected: []Diagnostic{{Line: 3, Message: "invalid YAML: mapping values are not allowed"}},
+		},
+		{
+			name:     "unknown top-level key",
+			content:  "version: 1.0.0\ngropus:\n  dev: [git]\n",
+			expected: []Diagnostic{{Line: 2, Column: 1, Message: "unknown key 'gropus'"}},
+		},
+		{
+			name:     "unknown nested key",
+			content:  "github:\n  branch: main\n  repo: user/dotfiles\n",
+			expected: []Diagnostic{{Line: 3, Column: 3, Message: "unknown key 'repo' in 'github'"}},
+		},
+		{
+			name:     "duplicate key",
+			content:  "version: 1.0.0\nversion: 2.0.0\n",
+			expected: []Diagnostic{{Line: 2, Column: 1, Message: "duplicate key 'version'"}},
+		},
+		{
+			name:     "wrong value kind",
+			content:  "groups:\n  dev: git\n",
+			expected: []Diagnostic{{Line: 2, Column: 8, Message: "expected a list for 'groups.dev'"}},
+		},
+		{
+			name:    "duplicate apps in group and tools",
+			content: "tools:\n  required_tools: [git]\n  installed_apps:\n    - slack\n    - git\ngroups:\n  dev:\n    - node\n    - node\n",
+			expected: []Diagnostic{
+				{Line: 5, Column: 7, Message: "duplicate app 'git' in tools.installed_apps"},
+				{Line: 9, Column: 7, Message: "duplicate app 'node' in group 'dev'"},
+			},
+		},
+		{
+			name:    "invalid and empty groups",
+			content: "groups:\n  bad name: [git]\n  empty: []\n",
+			expected: []Diagnostic{
+				{Line: 2, Column: 3, Message: "invalid group name"},
+				{Line: 3, Column: 3, Message: "group 'empty' cannot be empty"},
+			},
+		},
+		{
+			name:     "nonexistent config path",
+			content:  "configs:\n  app: /definitely/not/a/real/path\n",
+			expected: []Diagnostic{{Line: 2, Column: 8, Message: "config path for 'app' does not exist"}},
+		},
+		{
+			name:     "malformed config repo",
+			content:  "github:\n  config_repo: not a repo\n",
+			expected: []Diagnostic{{Line: 2, Column: 16, Message: "malformed config_repo"}},
+		},
+		{
+			name:     "unknown import trust policy",
+			content:  "import_trust:\n  policy: paranoid\n",
+			expected: []Diagnostic{{Line: 2, Column: 11, Message: "unknown import_trust policy 'paranoid'"}},
+		},
+		{
+			name:    "invalid install mode and script trust",
+			content: "install:\n  trust_scripts: never\n  script_sha256:\n    oh-my-zsh: abc123\n  mode: system\n",
+			expected: []Diagnostic{
+				{Line: 2, Column: 18, Message: "unknown trust_scripts policy 'never'"},
+				{Line: 4, Column: 16, Message: "script_sha256 for 'oh-my-zsh' is not a SHA-256 digest"},
+				{Line: 5, Column: 9, Message: "unknown install mode 'system'"},
+			},
+		},
+		{
+			name:    "invalid brew options",
+			content: "brew_options:\n  firefox:\n    type: app\n    env:\n      1BAD: x\n",
+			expected: []Diagnostic{
+				{Line: 3, Column: 11, Message: "unknown brew type 'app' for 'firefox'"},
+				{Line: 5, Column: 7, Message: "invalid environment variable name '1BAD'"},
+			},
+		},
+		{
+			name:     "unknown service action",
+			content:  "services:\n  redis: enable\n",
+			expected: []Diagnostic{{Line: 2, Column: 10, Message: "unknown service action 'enable' for 'redis'"}},
+		},
+		{
+			name:    "invalid aliases",
+			content: "aliases:\n  vscode: visual studio code\n  git: Git\n",
+			expected: []Diagnostic{
+				{Line: 2, Column: 11, Message: "invalid package name 'visual studio code' for alias 'vscode'"},
+				{Line: 3, Column: 8, Message: "alias 'git' refers to itself"},
+			},
+		},
+		{
+			name:    "per-platform sources",
+			content: "sources:\n  moom: https://example.com/moom.dmg\n  tool:\n    darwin-arm64: https://example.com/tool.zip\n    macos: https://example.com/tool.dmg\n    linux: [a, b]\n  other: [a]\n",
+			expected: []Diagnostic{
+				{Line: 5, Column: 5, Message: "unknown platform 'macos' for source 'tool'"},
+				{Line: 6, Column: 12, Message: "expected a single value for 'sources.tool.linux'"},
+				{Line: 7, Column: 10, Message: "expected a single value or a mapping of platforms for 'sources.other'"},
+			},
+		},
+		{
+			name:    "invalid source options and network",
+			content: "source_options:\n  tool:\n    headers:\n      Authorization: Bearer abc123\n      X-Token: ${TOKEN}\n    mirrors:\n      - https://mirror.example.com/{file}\n      - mirror.example.com\nnetwork:\n  proxy: proxy:3128\n",
+			expected: []Diagnostic{
+				{Line: 4, Column: 22, Message: "header 'Authorization' for 'tool' must reference an environment variable"},
+				{Line: 8, Column: 9, Message: "mirror 'mirror.example.com' for 'tool' is not an http(s) URL"},
+				{Line: 10, Column: 10, Message: "invalid proxy 'proxy:3128'"},
+			},
+		},
+		{
+			name:    "multiple problems are all reported in order",
+			content: "unknown: true\ngroups:\n  dev: [git, git]\ngithub:\n  config_repo: ://bad\n",
+			expected: []Diagnostic{
+				{Line: 1, Column: 1, Message: "unknown key 'unknown'"},
+				{Line: 3, Column: 14, Message: "duplicate app 'git'"},
+				{Line: 5, Column: 16, Message: "malformed config_repo"},
+			},
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			expected: []Diagnostic{{Message: "file is empty"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := DiagnoseSettings("settings.yaml", []byte(tt.content))
+
+			if len(diagnostics) != len(tt.expected) {
+				t.Fatalf("Expected %d diagnostics, got %d: %v", len(tt.expected), len(diagnostics), diagnostics)
+			}
+
+			for i, expected := range tt.expected {
+				got := diagnostics[i]
+				if got.File != "settings.yaml" {
+					t.Errorf("Expected file settings.yaml, got %s", got.File)
+				}
+				if got.Line != expected.Line || got.Column != expected.Column {
+					t.Errorf("Diagnostic %d: expected position %d:%d, got %d:%d (%s)", i, expected.Line, expected.Column, got.Line, got.Column, got.Message)
+				}
+				if !strings.Contains(got.Message, expected.Message) {
+					t.Errorf("Diagnostic %d: expected message containing %q, got %q", i, expected.Message, got.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	tests := []struct {
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{Diagnostic{File: "s.yaml", Line: 3, Column: 5, Message: "bad"}, "s.yaml:3:5: bad"},
+		{Diagnostic{File: "s.yaml", Line: 3, Message: "bad"}, "s.yaml:3: bad"},
+		{Diagnostic{File: "s.yaml", Message: "bad"}, "s.yaml: bad"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.diagnostic.String(); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestDiagnoseSettingsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yaml")
+	if err := os.WriteFile(path, []byte("groups:\n  dev: [git]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write settings: %v", err)
+	}
+
+	diagnostics, err := DiagnoseSettingsFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", diagnostics)
+	}
+
+	if _, err := DiagnoseSettingsFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
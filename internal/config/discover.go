@@ -17,7 +17,6 @@ limitations under the License.
 package config
 
 import (
-	"os"
 	"strings"
 
 	"github.com/0xjuanma/anvil/internal/brew"
@@ -58,29 +57,6 @@ var defaultAppSet = map[string]struct{}{
 	"tv":                 {},
 }
 
-var appAliases = map[string]string{
-	"iterm":                   "iterm2",
-	"zoom.us":                 "zoom",
-	"1password 7":             "1password",
-	"alfred 5":                "alfred",
-	"alfred 4":                "alfred",
-	"pgadmin 4":               "pgadmin4",
-	"dbeaver":                 "dbeaver-community",
-	"alttab":                  "alt-tab",
-	"adobe acrobat reader dc": "adobe-acrobat-reader",
-	"parallels desktop":       "parallels",
-	"cleanmymac x":            "cleanmymac",
-	"bartender 5":             "bartender",
-	"bartender 4":             "bartender",
-	"logi options+":           "logi-options-plus",
-	"hands off!":              "hands-off",
-	"box":                     "box-drive",
-	"pcloud":                  "pcloud-drive",
-	"superduper!":             "superduper",
-	"vlc media player":        "vlc",
-	"epic games launcher":     "epic-games",
-}
-
 // RunDiscoverLogic discovers apps and tools installed on the system and adds them to the "discovered-apps" group if not tracked
 func RunDiscoverLogic() error {
 	// 1. Use Homebrew to discover tools(using --formulae flag)
@@ -90,13 +66,10 @@ func RunDiscoverLogic() error {
 		palantir.GetGlobalOutputHandler().PrintWarning("Failed to discover Homebrew tools: %v", err)
 	}
 
-	// 2. Use Applications folder to discover apps
+	// 2. Use the Applications folders to discover apps
 	macOSApps := []string{}
 	if system.IsMacOS() {
-		macOSApps, err = discoverMacOSApps()
-		if err != nil {
-			return err
-		}
+		macOSApps = discoverMacOSApps(discoveryAvailability(brew.LoadAvailabilitySnapshot(brew.AvailabilityCacheTTL)))
 	}
 
 	// 3. Filter tracked apps
@@ -119,6 +92,17 @@ func RunDiscoverLogic() error {
 	return nil
 }
 
+// discoveryAvailability returns the snapshot apps are discovered from. When Homebrew
+// cannot be queried, apps are still discovered from the Applications directories,
+// named after their bundles since the casks that installed them are unknown.
+func discoveryAvailability(availability *brew.AvailabilitySnapshot, err error) *brew.AvailabilitySnapshot {
+	if err == nil {
+		return availability
+	}
+	palantir.GetGlobalOutputHandler().PrintWarning("Failed to check installed Homebrew packages, discovering apps by bundle name: %v", err)
+	return brew.NewAvailabilitySnapshot(nil, nil, nil, brew.ScanApplications())
+}
+
 // discoverHomebrewTools discovers tools installed via Homebrew using the --formula flag
 func discoverHomebrewTools() ([]string, error) {
 	tools := []string{}
@@ -134,30 +118,31 @@ func discoverHomebrewTools() ([]string, error) {
 	return tools, nil
 }
 
-// discoverMacOSApps discovers apps in the /Applications folder
-func discoverMacOSApps() ([]string, error) {
+// discoverMacOSApps discovers apps in the Applications folders of an availability snapshot.
+// Apps installed by a cask are named after the cask, others after their app bundle.
+func discoverMacOSApps(availability *brew.AvailabilitySnapshot) []string {
 	apps := []string{}
+	seen := make(map[string]bool)
 
-	entries, err := os.ReadDir("/Applications")
-	if err != nil {
-		return nil, err
-	}
-
-	for _, entry := range entries {
-		// Skip dotfiles, non-directories, and non-app bundles
-		if strings.HasPrefix(entry.Name(), ".") || !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".app") {
+	for _, appName := range availability.Applications {
+		// Skip hidden app bundles
+		if strings.HasPrefix(appName, ".") {
 			continue
 		}
 
-		packageName := convertAppNameToPackage(entry.Name())
-		if _, exists := defaultAppSet[packageName]; exists {
+		packageName, fromCask := availability.InstalledCaskForApp(appName)
+		if !fromCask {
+			packageName = convertAppNameToPackage(appName)
+		}
+		if _, exists := defaultAppSet[packageName]; exists || seen[packageName] {
 			continue
 		}
 
+		seen[packageName] = true
 		apps = append(apps, packageName)
 	}
 
-	return apps, nil
+	return apps
 }
 
 // convertAppNameToPackage converts a macOS .app name to a package name
@@ -166,7 +151,7 @@ func convertAppNameToPackage(name string) string {
 	lowerName := strings.ToLower(cleanName)
 
 	// Check aliases first
-	if pkg, ok := appAliases[lowerName]; ok {
+	if pkg, ok := brew.AppAliases[lowerName]; ok {
 		return pkg
 	}
 
@@ -0,0 +1,58 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+)
+
+func TestDiscoverMacOSApps(t *testing.T) {
+	index, err := brew.ParseHomebrewAPI([]byte(`[]`), []byte(`[{"token": "visual-studio-code", "artifacts": [{"app": ["Visual Studio Code.app"]}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	brew.UseIndex(index)
+	t.Cleanup(func() { brew.UseIndex(nil) })
+
+	availability := brew.NewAvailabilitySnapshot(nil, []string{"visual-studio-code"}, nil, []string{
+		"Visual Studio Code.app", "Safari.app", "iTerm.app", "Alfred 5.app", "Alfred 4.app", ".hidden.app",
+	})
+
+	expected := []string{"alfred", "visual-studio-code", "iterm2"}
+	if got := discoverMacOSApps(availability); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestDiscoveryAvailabilityFallsBackToApplications(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, "Applications", "iTerm.app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	availability := discoveryAvailability(nil, errors.New("brew list failed"))
+	if got := discoverMacOSApps(availability); !reflect.DeepEqual(got, []string{"iterm2"}) {
+		t.Errorf("Expected apps to be discovered without Homebrew data, got %v", got)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sensitiveQueryParams are URL query parameters that carry credentials or signed access
+var sensitiveQueryParams = []string{
+	"token", "access_token", "auth", "key", "api_key", "apikey", "password", "secret",
+	"sig", "signature", "x-amz-credential", "x-amz-signature", "x-goog-signature",
+}
+
+// Patterns used to find credentials in command sources such as "curl -H 'Authorization: ...' URL | sh"
+var (
+	embeddedURLPattern      = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s'"` + "`" + `()<>|;]+`)
+	credentialHeaderPattern = regexp.MustCompile(`(?i)(authorization|cookie|private-token|x-[a-z-]*(token|key|secret))\s*:`)
+	credentialFlagPattern   = regexp.MustCompile(`(^|\s)(-u|--user|--password|--token|--api-key|--oauth2-bearer|--header-auth)(\s|=|$)`)
+	credentialAssignPattern = regexp.MustCompile(`(?i)(^|[\s;&|(])[a-z_]*(token|secret|password|passwd|api_key|apikey)[a-z_]*=`)
+)
+
+// ExportOptions selects what 'anvil config export' writes to a shared group file
+type ExportOptions struct {
+	Groups      []string       // Groups to export, all groups when empty
+	WithSources bool           // Include installation sources of the exported apps
+	Metadata    ImportMetadata // Written as-is when not empty
+}
+
+// ExportSkip records a settings entry left out of an export and why
+type ExportSkip struct {
+	Key    string
+	Reason string
+}
+
+// ExportCatalog builds a shareable import file from settings. Only groups, taps, brew package
+// types and, optionally, sources are exported; taps and sources pointing at local paths or
+// carrying credentials are skipped so the result is free of personal data and can be imported
+// with 'anvil config import'.
+func ExportCatalog(cfg *AnvilConfig, opts ExportOptions) (*ImportConfig, []ExportSkip, error) {
+	if cfg == nil {
+		return nil, nil, fmt.Errorf("cannot export nil config")
+	}
+
+	groupNames := opts.Groups
+	if len(groupNames) == 0 {
+		for groupName := range cfg.Groups {
+			groupNames = append(groupNames, groupName)
+		}
+	}
+	if len(groupNames) == 0 {
+		return nil, nil, fmt.Errorf("no groups defined in settings")
+	}
+
+	catalog := &ImportConfig{Metadata: opts.Metadata, Groups: make(AnvilGroups)}
+	var unknown []string
+	for _, groupName := range groupNames {
+		tools, exists := cfg.Groups[groupName]
+		if !exists {
+			unknown = append(unknown, groupName)
+			continue
+		}
+		catalog.Groups[groupName] = MergeGroupTools(nil, tools)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, nil, fmt.Errorf("unknown group(s): %s", strings.Join(unknown, ", "))
+	}
+
+	// Taps are needed to install tap-qualified apps, but custom tap URLs may be private
+	homeDir, _ := os.UserHomeDir()
+	var skipped []ExportSkip
+	for _, tap := range cfg.Taps {
+		if tap.URL != "" {
+			if reason := personalSourceReason(tap.URL, homeDir); reason != "" {
+				skipped = append(skipped, ExportSkip{Key: "taps." + tap.Name, Reason: reason})
+				continue
+			}
+		}
+		catalog.Taps = append(catalog.Taps, tap)
+	}
+
+	exportedApps := make(map[string]bool)
+	for _, tools := range catalog.Groups {
+		for _, tool := range tools {
+			exportedApps[tool] = true
+		}
+	}
+
+	// Package types keep casks that share a formula's name installable as casks
+	for appName := range exportedApps {
+		if packageType := cfg.Brew[appName].Type; packageType != "" {
+			if catalog.Brew == nil {
+				catalog.Brew = make(map[string]ImportBrewOptions)
+			}
+			catalog.Brew[appName] = ImportBrewOptions{Type: packageType}
+		}
+	}
+
+	if !opts.WithSources {
+		return catalog, skipped, nil
+	}
+
+	// When groups are selected, only their apps' sources are shared
+
+	for appName, source := range cfg.Sources {
+		if len(opts.Groups) > 0 && !exportedApps[appName] {
+			continue
+		}
+		if reason := personalAppSourceReason(source, homeDir); reason != "" {
+			skipped = append(skipped, ExportSkip{Key: "sources." + appName, Reason: reason})
+			continue
+		}
+		if catalog.Sources == nil {
+			catalog.Sources = make(map[string]AppSource)
+		}
+		catalog.Sources[appName] = source
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Key < skipped[j].Key })
+
+	return catalog, skipped, nil
+}
+
+// personalAppSourceReason explains why any of an app's sources must not be shared
+func personalAppSourceReason(source AppSource, homeDir string) string {
+	for _, value := range source.Values() {
+		if reason := personalSourceReason(value, homeDir); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// personalSourceReason explains why a source must not be shared, or returns "" if it is safe
+func personalSourceReason(source, homeDir string) string {
+	switch {
+	case homeDir != "" && strings.Contains(source, homeDir):
+		return "contains your home directory"
+	case strings.HasPrefix(source, "~"), strings.HasPrefix(source, "/"), strings.HasPrefix(source, "./"),
+		strings.HasPrefix(source, "../"), strings.HasPrefix(source, "file://"):
+		return "points at a local path"
+	}
+
+	if strings.ContainsAny(source, " \t\n") {
+		return personalCommandReason(source, homeDir)
+	}
+	return personalURLReason(source)
+}
+
+// personalURLReason explains why a URL must not be shared, or returns "" if it is safe or
+// not a URL
+func personalURLReason(source string) string {
+	parsed, err := url.Parse(source)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ""
+	}
+	if parsed.User != nil {
+		return "contains credentials"
+	}
+	for param := range parsed.Query() {
+		for _, sensitive := range sensitiveQueryParams {
+			if strings.EqualFold(param, sensitive) {
+				return fmt.Sprintf("contains a '%s' query parameter", param)
+			}
+		}
+	}
+	return ""
+}
+
+// personalCommandReason explains why a command source must not be shared: it sends
+// credentials in a header, flag or variable, or downloads from a URL that carries them
+func personalCommandReason(command, homeDir string) string {
+	switch {
+	case credentialHeaderPattern.MatchString(command):
+		return "sends a credential header"
+	case credentialFlagPattern.MatchString(command):
+		return "passes credentials on the command line"
+	case credentialAssignPattern.MatchString(command):
+		return "sets a credential variable"
+	}
+	for _, embedded := range embeddedURLPattern.FindAllString(command, -1) {
+		if reason := personalSourceReason(embedded, homeDir); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
@@ -96,23 +96,27 @@ func AddAppsToGroup(groupName string, apps []string) error {
 			config.Groups = make(map[string][]string)
 		}
 
-		tools := config.Groups[groupName]
-
-		// Use a set to track existing tools for O(1) lookups and deduplication
-		// map[string]struct{} is idiomatic for sets (0 bytes per value)
-		existingSet := make(map[string]struct{}, len(tools))
-		for _, tool := range tools {
-			existingSet[tool] = struct{}{}
-		}
+		config.Groups[groupName] = MergeGroupTools(config.Groups[groupName], apps)
+		return nil
+	})
+}
 
-		for _, app := range apps {
-			if _, exists := existingSet[app]; !exists {
-				tools = append(tools, app)
-				existingSet[app] = struct{}{}
+// MergeGroupTools returns the union of two tool lists, keeping the order of first appearance
+// and dropping duplicates.
+func MergeGroupTools(tools []string, additional []string) []string {
+	// Use a set to track existing tools for O(1) lookups and deduplication
+	// map[string]struct{} is idiomatic for sets (0 bytes per value)
+	existingSet := make(map[string]struct{}, len(tools)+len(additional))
+	merged := make([]string, 0, len(tools)+len(additional))
+
+	for _, list := range [][]string{tools, additional} {
+		for _, tool := range list {
+			if _, exists := existingSet[tool]; !exists {
+				merged = append(merged, tool)
+				existingSet[tool] = struct{}{}
 			}
 		}
+	}
 
-		config.Groups[groupName] = tools
-		return nil
-	})
+	return merged
 }
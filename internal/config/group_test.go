@@ -214,3 +214,32 @@ func TestAddAppsToGroup_TableDriven(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeGroupTools(t *testing.T) {
+	tests := []struct {
+		name       string
+		tools      []string
+		additional []string
+		expected   []string
+	}{
+		{"Merge into empty", nil, []string{"git", "node"}, []string{"git", "node"}},
+		{"Union keeps first appearance order", []string{"git", "node"}, []string{"yarn", "git"}, []string{"git", "node", "yarn"}},
+		{"Duplicates are dropped", []string{"git", "git"}, []string{"node", "node"}, []string{"git", "node"}},
+		{"Nothing to add", []string{"git"}, nil, []string{"git"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := MergeGroupTools(tt.tools, tt.additional)
+
+			if len(merged) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, merged)
+			}
+			for i, tool := range merged {
+				if tool != tt.expected[i] {
+					t.Errorf("Expected tool at index %d to be %s, got %s", i, tt.expected[i], tool)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,457 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect understood by common editors
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is the subset of JSON Schema used to describe anvil settings and import files
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 []string           `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // false or *Schema
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             int                `json:"minItems,omitempty"`
+	MinLength            int                `json:"minLength,omitempty"`
+	MaxLength            int                `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+// SchemaError describes a value that does not conform to a schema
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+// Error implements the error interface
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// schemaDescriptions documents settings keys by their dotted path
+var schemaDescriptions = map[string]string{
+	"version":                    "Anvil version that generated this file",
+	"tools":                      "Tools anvil requires and apps installed individually",
+	"tools.required_tools":       "Tools that must be present for anvil to work",
+	"tools.installed_apps":       "Apps installed individually with 'anvil install <app>'",
+	"groups":                     "Named groups of apps installed together with 'anvil install <group>'",
+	"configs":                    "Maps app names to local config paths used by 'anvil config push'",
+	"sources":                    "Maps app names to download URLs, github:owner/repo releases, go:/cargo:/npm:/pipx:/uv: packages or install commands",
+	"sources.*":                  "A source for every platform, or sources keyed by platform (darwin-arm64, linux, default)",
+	"git":                        "Git identity, auto-populated from local git settings",
+	"git.ssh_key_path":           "Path to the SSH private key used for git operations",
+	"github":                     "GitHub repository used to sync configuration files",
+	"github.config_repo":         "Repository in 'username/repository' format",
+	"github.branch":              "Branch to use (default: main)",
+	"github.local_path":          "Local path where configs are stored and synced",
+	"github.token":               "GitHub token (prefer token_env_var)",
+	"github.token_env_var":       "Environment variable that holds the GitHub token",
+	"taps":                       "Homebrew taps ensured before any install",
+	"taps.*.name":                "Tap name in 'owner/repo' form",
+	"taps.*.url":                 "Git URL of the tap, for taps not hosted on GitHub",
+	"brew_options":               "Maps app names to options used when installing them with Homebrew",
+	"brew_options.*.type":        "Install as a 'cask' or 'formula' instead of detecting it",
+	"brew_options.*.args":        "Extra 'brew install' arguments, e.g. --HEAD or --no-quarantine",
+	"brew_options.*.env":         "Environment variables set for 'brew install'",
+	"services":                   "Maps app names to the brew service action run after install: start, restart or none",
+	"aliases":                    "Maps friendly app names to Homebrew package names, e.g. vscode: visual-studio-code",
+	"hooks":                      "Commands run around installations",
+	"hooks.post_install":         "Maps app names to shell commands run after the app is installed",
+	"import_trust":               "Controls which import files are trusted",
+	"metadata":                   "Describes a shared import file, informational only",
+	"metadata.name":              "Name of the shared catalog",
+	"metadata.description":       "What the shared catalog is for",
+	"metadata.exported_at":       "When the file was exported with 'anvil config export'",
+	"metadata.anvil_version":     "anvil version that exported the file",
+	"import_trust.policy":        "'warn' shows a warning for untrusted imports, 'strict' refuses them",
+	"import_trust.allowed_hosts": "HTTPS hosts imports are trusted from, '*.' matches subdomains",
+	"import_trust.public_keys":   "Ed25519 or minisign public keys used to verify signed import files",
+	"import_trust.allow_local":   "Trust local import files and git+file repositories without a digest or signature",
+	"imports":                    "Origins of imported groups, maintained by 'anvil config import'",
+	"install":                    "Where source installs are placed and which install scripts run",
+	"install.prefix":             "User install prefix; apps go in <prefix>/opt and links in <prefix>/bin (default ~/.local)",
+	"install.mode":               "Linux archive layout: 'prefix' installs in <prefix>/opt with linked executables, 'applications' copies to ~/.local/share/applications",
+	"install.trust_scripts":      "'prompt' previews unpinned install scripts and asks, 'pinned' refuses them, 'all' runs them",
+	"install.script_sha256":      "Maps app names to the sha256 of the install script their source downloads",
+	"source_options":             "Maps app names to the headers and mirrors used to download their source",
+	"source_options.*.headers":   "Request headers sent to the source URL only, not to mirrors; values must reference environment variables, e.g. 'Bearer ${TOKEN}'",
+	"source_options.*.mirrors":   "URLs tried in order before the source; {file} is replaced by the source's file name",
+	"network":                    "Proxy and certificate authorities used for downloads, imports and updates",
+	"network.proxy":              "Proxy URL for all requests (default: HTTPS_PROXY, HTTP_PROXY and NO_PROXY)",
+	"network.ca_bundle":          "PEM file of certificate authorities trusted besides the system ones",
+}
+
+// schemaRefinements adds the naming rules enforced by ConfigValidator by dotted path
+var schemaRefinements = map[string]func(*Schema){
+	"tools.required_tools": refineAppList,
+	"tools.installed_apps": refineAppList,
+	"sources":              refineAppKeys,
+	"configs":              refineAppKeys,
+	"hooks.post_install":   refineAppKeys,
+	"brew_options":         refineAppKeys,
+	"services":             refineAppKeys,
+	"source_options":       refineAppKeys,
+	"aliases": func(s *Schema) {
+		refineAppKeys(s)
+		if values, ok := s.AdditionalProperties.(*Schema); ok {
+			values.Pattern = appNamePattern
+			values.MinLength = 1
+		}
+	},
+	"sources.*": func(s *Schema) {
+		s.Type = []string{"string", "object"}
+		s.MinLength = 1
+		s.Properties = nil
+		s.PropertyNames = &Schema{Pattern: sourcePlatformPattern}
+		s.AdditionalProperties = &Schema{Type: []string{"string"}, MinLength: 1}
+	},
+	"services.*": func(s *Schema) {
+		s.Enum = []string{brew.ServiceStart, brew.ServiceRestart, brew.ServiceNone}
+	},
+	"brew_options.*.type": func(s *Schema) {
+		s.Enum = []string{brew.PackageTypeCask, brew.PackageTypeFormula}
+	},
+	"brew_options.*.env": func(s *Schema) {
+		s.PropertyNames = &Schema{Pattern: envVarNamePattern}
+	},
+	"taps.*": func(s *Schema) {
+		s.Type = []string{"object"}
+	},
+	"taps.*.name": func(s *Schema) {
+		s.Type = []string{"string"}
+		s.Pattern = tapNamePattern
+	},
+	"import_trust.policy": func(s *Schema) {
+		s.Enum = []string{ImportPolicyWarn, ImportPolicyStrict}
+	},
+	"install.mode": func(s *Schema) {
+		s.Enum = []string{InstallModePrefix, InstallModeApplications}
+	},
+	"install.trust_scripts": func(s *Schema) {
+		s.Enum = []string{ScriptTrustPrompt, ScriptTrustPinned, ScriptTrustAll}
+	},
+	"install.script_sha256": func(s *Schema) {
+		refineAppKeys(s)
+		if values, ok := s.AdditionalProperties.(*Schema); ok {
+			values.Pattern = sha256Pattern
+		}
+	},
+	"source_options.*.headers": func(s *Schema) {
+		if values, ok := s.AdditionalProperties.(*Schema); ok {
+			values.Pattern = envReferencePattern
+		}
+	},
+	"source_options.*.mirrors": func(s *Schema) {
+		if s.Items != nil {
+			s.Items.MinLength = 1
+		}
+	},
+	"groups": func(s *Schema) {
+		s.PropertyNames = &Schema{Pattern: groupNamePattern, MaxLength: groupNameMaxLength}
+		if items, ok := s.AdditionalProperties.(*Schema); ok {
+			refineAppList(items)
+			items.Type = []string{"array"}
+			items.MinItems = 1
+		}
+	},
+}
+
+// refineAppKeys restricts map keys to valid application names with non-empty values
+func refineAppKeys(s *Schema) {
+	s.PropertyNames = &Schema{Pattern: appNamePattern, MaxLength: appNameMaxLength}
+	if values, ok := s.AdditionalProperties.(*Schema); ok {
+		if values.Items != nil {
+			values.Type = []string{"array"}
+			values.MinItems = 1
+			values.Items.Type = []string{"string"}
+			values.Items.MinLength = 1
+		}
+	}
+}
+
+// refineAppList restricts list items to valid application names
+func refineAppList(s *Schema) {
+	if s.Items != nil {
+		s.Items.Type = []string{"string"}
+		s.Items.Pattern = appNamePattern
+		s.Items.MinLength = 1
+		s.Items.MaxLength = appNameMaxLength
+	}
+}
+
+// SettingsSchema returns the JSON Schema for settings.yaml
+func SettingsSchema() *Schema {
+	schema := GenerateSchema(reflect.TypeOf(AnvilConfig{}))
+	schema.Title = "Anvil settings"
+	schema.Description = "Schema for ~/.anvil/settings.yaml"
+	return schema
+}
+
+// ImportSchema returns the JSON Schema for files accepted by 'anvil config import'
+func ImportSchema() *Schema {
+	schema := GenerateSchema(reflect.TypeOf(ImportConfig{}))
+	schema.Title = "Anvil import file"
+	schema.Description = "Schema for group files shared with 'anvil config import'"
+	// Import files may be full settings files, only the groups section is read
+	schema.AdditionalProperties = nil
+	return schema
+}
+
+// GenerateSchema builds a JSON Schema from a struct type using its yaml tags
+func GenerateSchema(t reflect.Type) *Schema {
+	schema := schemaForType(t, "")
+	schema.SchemaURI = jsonSchemaDraft
+	// The document itself must be a mapping
+	schema.Type = []string{"object"}
+	return schema
+}
+
+// schemaForType builds the schema for a Go type found at the given settings path
+func schemaForType(t reflect.Type, path string) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	schema := &Schema{Description: schemaDescriptions[path]}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		schema.Type = []string{"object", "null"}
+		schema.Properties = make(map[string]*Schema)
+		schema.AdditionalProperties = false
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "-" || field.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			schema.Properties[name] = schemaForType(field.Type, joinPath(path, name))
+		}
+	case reflect.Map:
+		schema.Type = []string{"object", "null"}
+		schema.AdditionalProperties = schemaForType(t.Elem(), joinPath(path, "*"))
+	case reflect.Slice, reflect.Array:
+		schema.Type = []string{"array", "null"}
+		schema.Items = schemaForType(t.Elem(), joinPath(path, "*"))
+	case reflect.Bool:
+		schema.Type = []string{"boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.Type = []string{"integer"}
+	case reflect.Float32, reflect.Float64:
+		schema.Type = []string{"number"}
+	default:
+		schema.Type = []string{"string", "null"}
+	}
+
+	if refine, ok := schemaRefinements[path]; ok {
+		refine(schema)
+	}
+
+	return schema
+}
+
+// JSON renders the schema as indented JSON
+func (s *Schema) JSON() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Validate checks decoded YAML or JSON data against the schema and returns every violation.
+func (s *Schema) Validate(value interface{}) []SchemaError {
+	var errs []SchemaError
+	s.validate(value, "", &errs)
+	return errs
+}
+
+// validate checks a single value, appending violations to errs
+func (s *Schema) validate(value interface{}, path string, errs *[]SchemaError) {
+	addError := func(format string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	kind := schemaKind(value)
+	if len(s.Type) > 0 && !s.allowsKind(kind) {
+		addError("expected %s, got %s", strings.Join(s.Type, " or "), kind)
+		return
+	}
+
+	switch kind {
+	case "object":
+		s.validateObject(toStringMap(value), path, errs)
+	case "array":
+		items := value.([]interface{})
+		if len(items) < s.MinItems {
+			addError("must contain at least %d item(s)", s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case "string":
+		s.validateString(value.(string), path, errs)
+	}
+}
+
+// validateObject checks an object's properties, property names and additional properties
+func (s *Schema) validateObject(object map[string]interface{}, path string, errs *[]SchemaError) {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := joinPath(path, key)
+
+		if s.PropertyNames != nil {
+			var nameErrs []SchemaError
+			s.PropertyNames.validateString(key, childPath, &nameErrs)
+			for _, nameErr := range nameErrs {
+				nameErr.Message = "invalid key: " + nameErr.Message
+				*errs = append(*errs, nameErr)
+			}
+		}
+
+		if property, ok := s.Properties[key]; ok {
+			property.validate(object[key], childPath, errs)
+			continue
+		}
+
+		switch additional := s.AdditionalProperties.(type) {
+		case *Schema:
+			additional.validate(object[key], childPath, errs)
+		case bool:
+			if !additional {
+				*errs = append(*errs, SchemaError{Path: childPath, Message: "unknown key"})
+			}
+		}
+	}
+}
+
+// validateString checks string length and pattern constraints
+func (s *Schema) validateString(value, path string, errs *[]SchemaError) {
+	addError := func(format string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(value) < s.MinLength {
+		addError("must not be empty")
+		return
+	}
+	if s.MaxLength > 0 && len(value) > s.MaxLength {
+		addError("'%s' is too long (max %d characters)", value, s.MaxLength)
+	}
+	if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(value) {
+		addError("'%s' does not match pattern %s", value, s.Pattern)
+	}
+	if len(s.Enum) > 0 && !containsString(s.Enum, value) {
+		addError("'%s' must be one of: %s", value, strings.Join(s.Enum, ", "))
+	}
+}
+
+// allowsKind reports whether the schema type list accepts the given kind
+func (s *Schema) allowsKind(kind string) bool {
+	for _, allowed := range s.Type {
+		if allowed == kind || (allowed == "number" && kind == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaKind returns the JSON Schema type name of a decoded value
+func schemaKind(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "integer"
+	case float32:
+		return "number"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}, map[interface{}]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// toStringMap normalizes yaml.v2 and JSON objects to string-keyed maps
+func toStringMap(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = item
+		}
+		return result
+	}
+	return nil
+}
+
+// containsString reports whether a list contains a value
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func decodeYAML(t *testing.T, content string) interface{} {
+	t.Helper()
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(content), &data); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	return data
+}
+
+func TestSettingsSchemaAcceptsSampleConfig(t *testing.T) {
+	errs := SettingsSchema().Validate(decodeYAML(t, string(sampleConfigData)))
+	if len(errs) != 0 {
+		t.Errorf("Expected sample settings to match schema, got %v", errs)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   *Schema
+		content  string
+		expected []string
+	}{
+		{
+			name:     "valid import file",
+			schema:   ImportSchema(),
+			content:  "groups:\n  frontend: [node, yarn]\n",
+			expected: nil,
+		},
+		{
+			name:     "import file may contain other settings sections",
+			schema:   ImportSchema(),
+			content:  "version: 1.0.0\ngroups:\n  frontend: [node]\nconfigs: {}\n",
+			expected: nil,
+		},
+		{
+			name:     "invalid group name",
+			schema:   ImportSchema(),
+			content:  "groups:\n  bad name: [node]\n",
+			expected: []string{"groups.bad name: invalid key: 'bad name' does not match pattern"},
+		},
+		{
+			name:     "empty group",
+			schema:   ImportSchema(),
+			content:  "groups:\n  frontend: []\n",
+			expected: []string{"groups.frontend: must contain at least 1 item(s)"},
+		},
+		{
+			name:     "invalid app name",
+			schema:   ImportSchema(),
+			content:  "groups:\n  frontend: [node, 'bad app']\n",
+			expected: []string{"groups.frontend[1]: 'bad app' does not match pattern"},
+		},
+		{
+			name:     "group must be a list",
+			schema:   ImportSchema(),
+			content:  "groups:\n  frontend: node\n",
+			expected: []string{"groups.frontend: expected array, got string"},
+		},
+		{
+			name:     "per-platform sources",
+			schema:   SettingsSchema(),
+			content:  "sources:\n  moom: https://example.com/moom.dmg\n  tool:\n    darwin-arm64: https://example.com/tool.zip\n    default: https://example.com/tool.tar.gz\n",
+			expected: nil,
+		},
+		{
+			name:     "unknown source platform",
+			schema:   SettingsSchema(),
+			content:  "sources:\n  tool:\n    macos-arm64: https://example.com/tool.zip\n",
+			expected: []string{"sources.tool.macos-arm64: invalid key: 'macos-arm64' does not match pattern"},
+		},
+		{
+			name:     "unknown settings key",
+			schema:   SettingsSchema(),
+			content:  "version: 1.0.0\ngithub:\n  repo: user/dotfiles\n",
+			expected: []string{"github.repo: unknown key"},
+		},
+		{
+			name:    "all violations are reported",
+			schema:  SettingsSchema(),
+			content: "tools:\n  required_tools: [git, '']\ngroups:\n  dev: []\n",
+			expected: []string{
+				"groups.dev: must contain at least 1 item(s)",
+				"tools.required_tools[1]: must not be empty",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.schema.Validate(decodeYAML(t, tt.content))
+
+			if len(errs) != len(tt.expected) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.expected), len(errs), errs)
+			}
+			for i, expected := range tt.expected {
+				if !strings.HasPrefix(errs[i].Error(), expected) {
+					t.Errorf("Error %d: expected prefix %q, got %q", i, expected, errs[i].Error())
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaJSON(t *testing.T) {
+	data, err := SettingsSchema().JSON()
+	if err != nil {
+		t.Fatalf("Failed to render schema: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+
+	if decoded["$schema"] != jsonSchemaDraft {
+		t.Errorf("Expected $schema %s, got %v", jsonSchemaDraft, decoded["$schema"])
+	}
+
+	properties, ok := decoded["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected properties in schema")
+	}
+	for _, key := range []string{"version", "tools", "groups", "configs", "sources", "git", "github"} {
+		if _, exists := properties[key]; !exists {
+			t.Errorf("Expected property %s in schema", key)
+		}
+	}
+}
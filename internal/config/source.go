@@ -0,0 +1,140 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SourcePlatformDefault is the platform key of the source used when no other key matches
+const SourcePlatformDefault = "default"
+
+var sourcePlatformRegex = regexp.MustCompile(sourcePlatformPattern)
+
+// appSourceType is checked by checkSourcesSection rather than by its Go shape
+var appSourceType = reflect.TypeOf(AppSource{})
+
+// AppSource is where an app is installed from instead of Homebrew: either one URL or
+// command for every platform, or one per platform keyed by "os-arch" (darwin-arm64),
+// "os" (linux) or "default".
+//
+//	sources:
+//	  moom: https://manytricks.com/download/moom
+//	  tool:
+//	    darwin-arm64: https://example.com/tool-macos-arm64.zip
+//	    linux-amd64: https://example.com/tool-linux-amd64.tar.gz
+//	    default: https://example.com/tool.zip
+type AppSource struct {
+	URL       string            `yaml:"-"` // Source for every platform
+	Platforms map[string]string `yaml:"-"` // Sources by platform key
+}
+
+// NewAppSource returns a source used on every platform
+func NewAppSource(source string) AppSource {
+	return AppSource{URL: source}
+}
+
+// UnmarshalYAML accepts a single source or a mapping of platform keys to sources
+func (s *AppSource) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var source string
+	if err := unmarshal(&source); err == nil {
+		*s = AppSource{URL: source}
+		return nil
+	}
+
+	var platforms map[string]string
+	if err := unmarshal(&platforms); err != nil {
+		return fmt.Errorf("a source must be a URL, a command or a mapping of platforms to sources")
+	}
+	*s = AppSource{Platforms: platforms}
+	return nil
+}
+
+// MarshalYAML writes single sources as a plain value
+func (s AppSource) MarshalYAML() (interface{}, error) {
+	if len(s.Platforms) > 0 {
+		return s.Platforms, nil
+	}
+	return s.URL, nil
+}
+
+// IsZero reports whether no source is set, so omitempty drops it
+func (s AppSource) IsZero() bool {
+	return s.URL == "" && len(s.Platforms) == 0
+}
+
+// IsPerPlatform reports whether the source is keyed by platform
+func (s AppSource) IsPerPlatform() bool {
+	return len(s.Platforms) > 0
+}
+
+// ForPlatform returns the source for an OS and architecture, trying "os-arch", then
+// "os", then "default". It reports false when the platform has no source.
+func (s AppSource) ForPlatform(goos, goarch string) (string, bool) {
+	if !s.IsPerPlatform() {
+		return s.URL, s.URL != ""
+	}
+	for _, key := range []string{goos + "-" + goarch, goos, SourcePlatformDefault} {
+		if source := s.Platforms[key]; source != "" {
+			return source, true
+		}
+	}
+	return "", false
+}
+
+// PlatformKeys returns the configured platform keys, sorted
+func (s AppSource) PlatformKeys() []string {
+	keys := make([]string, 0, len(s.Platforms))
+	for key := range s.Platforms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Values returns every configured source, ordered by platform key
+func (s AppSource) Values() []string {
+	if !s.IsPerPlatform() {
+		return []string{s.URL}
+	}
+	values := make([]string, 0, len(s.Platforms))
+	for _, key := range s.PlatformKeys() {
+		values = append(values, s.Platforms[key])
+	}
+	return values
+}
+
+// String renders the source on one line, e.g. "darwin-arm64: <url>, linux: <url>"
+func (s AppSource) String() string {
+	if !s.IsPerPlatform() {
+		return s.URL
+	}
+	entries := make([]string, 0, len(s.Platforms))
+	for _, key := range s.PlatformKeys() {
+		entries = append(entries, key+": "+s.Platforms[key])
+	}
+	return strings.Join(entries, ", ")
+}
+
+// IsValidSourcePlatform reports whether a platform key is "os-arch", "os" or "default"
+func IsValidSourcePlatform(key string) bool {
+	return sourcePlatformRegex.MatchString(key)
+}
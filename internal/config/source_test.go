@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestAppSourceForPlatform(t *testing.T) {
+	source := AppSource{Platforms: map[string]string{
+		"darwin-arm64": "https://example.com/tool-arm64.dmg",
+		"darwin":       "https://example.com/tool.dmg",
+		"linux-amd64":  "https://example.com/tool-amd64.tar.gz",
+	}}
+
+	tests := []struct {
+		goos, goarch string
+		expected     string
+		found        bool
+	}{
+		{"darwin", "arm64", "https://example.com/tool-arm64.dmg", true},
+		{"darwin", "amd64", "https://example.com/tool.dmg", true},
+		{"linux", "amd64", "https://example.com/tool-amd64.tar.gz", true},
+		{"linux", "arm64", "", false},
+	}
+
+	for _, tt := range tests {
+		got, found := source.ForPlatform(tt.goos, tt.goarch)
+		if got != tt.expected || found != tt.found {
+			t.Errorf("ForPlatform(%s, %s) = %q, %v, expected %q, %v", tt.goos, tt.goarch, got, found, tt.expected, tt.found)
+		}
+	}
+
+	source.Platforms[SourcePlatformDefault] = "https://example.com/tool.zip"
+	if got, _ := source.ForPlatform("linux", "arm64"); got != "https://example.com/tool.zip" {
+		t.Errorf("Expected the default source as fallback, got %q", got)
+	}
+
+	if got, found := NewAppSource("https://example.com/moom").ForPlatform("linux", "arm64"); !found || got != "https://example.com/moom" {
+		t.Errorf("Expected a single source to be used on every platform, got %q, %v", got, found)
+	}
+}
+
+func TestAppSourceYAML(t *testing.T) {
+	content := "sources:\n  moom: https://example.com/moom\n  tool:\n    darwin-arm64: https://example.com/tool.zip\n    linux: https://example.com/tool.tar.gz\n"
+
+	var cfg AnvilConfig
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		t.Fatalf("Failed to parse sources: %v", err)
+	}
+	if cfg.Sources["moom"].URL != "https://example.com/moom" || cfg.Sources["moom"].IsPerPlatform() {
+		t.Errorf("Unexpected single source: %+v", cfg.Sources["moom"])
+	}
+	if !reflect.DeepEqual(cfg.Sources["tool"].PlatformKeys(), []string{"darwin-arm64", "linux"}) {
+		t.Errorf("Unexpected per-platform source: %+v", cfg.Sources["tool"])
+	}
+
+	data, err := yaml.Marshal(struct {
+		Sources map[string]AppSource `yaml:"sources"`
+	}{cfg.Sources})
+	if err != nil {
+		t.Fatalf("Failed to write sources: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected sources to round-trip, got:\n%s", data)
+	}
+
+	if err := yaml.Unmarshal([]byte("sources:\n  tool: [a, b]\n"), &cfg); err == nil {
+		t.Error("Expected a list source to be rejected")
+	}
+}
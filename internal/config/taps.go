@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+)
+
+// RequiredTaps returns the taps needed to install tools: the taps in settings followed by
+// the taps of tap-qualified tool names ("owner/tap/name") or aliases, without duplicates.
+func RequiredTaps(cfg *AnvilConfig, tools []string) []BrewTap {
+	var taps []BrewTap
+	seen := make(map[string]bool)
+	add := func(tap BrewTap) {
+		if key := strings.ToLower(tap.Name); !seen[key] {
+			seen[key] = true
+			taps = append(taps, tap)
+		}
+	}
+
+	var aliases map[string]string
+	if cfg != nil {
+		aliases = cfg.Aliases
+		for _, tap := range cfg.Taps {
+			add(tap)
+		}
+	}
+	for _, tool := range tools {
+		if target, ok := brew.LookupAlias(tool, aliases); ok {
+			tool = target
+		}
+		if tapName, ok := brew.TapFromPackage(tool); ok {
+			add(BrewTap{Name: tapName})
+		}
+	}
+	return taps
+}
+
+// ConfiguredTools returns every app of every group plus the required tools, without duplicates
+func ConfiguredTools(cfg *AnvilConfig) []string {
+	groupNames := make([]string, 0, len(cfg.Groups))
+	for groupName := range cfg.Groups {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+
+	var tools []string
+	for _, groupName := range groupNames {
+		tools = MergeGroupTools(tools, cfg.Groups[groupName])
+	}
+	return MergeGroupTools(tools, cfg.Tools.RequiredTools)
+}
+
+// MissingTaps returns the taps that are not in the list of tapped repositories
+func MissingTaps(taps []BrewTap, tapped []string) []BrewTap {
+	var missing []BrewTap
+	for _, tap := range taps {
+		if !brew.IsTapped(tap.Name, tapped) {
+			missing = append(missing, tap)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequiredTaps(t *testing.T) {
+	cfg := &AnvilConfig{Taps: []BrewTap{{Name: "company/private", URL: "https://git.example.com/tap.git"}}}
+	tools := []string{"git", "nikitabobko/tap/aerospace", "Company/Private/cli", "nikitabobko/tap/other"}
+
+	expected := []BrewTap{
+		{Name: "company/private", URL: "https://git.example.com/tap.git"},
+		{Name: "nikitabobko/tap"},
+	}
+	if taps := RequiredTaps(cfg, tools); !reflect.DeepEqual(taps, expected) {
+		t.Errorf("Expected taps %v, got %v", expected, taps)
+	}
+
+	aliased := &AnvilConfig{Aliases: map[string]string{"terraform": "hashicorp/tap/terraform"}}
+	if taps := RequiredTaps(aliased, []string{"terraform"}); !reflect.DeepEqual(taps, []BrewTap{{Name: "hashicorp/tap"}}) {
+		t.Errorf("Expected the aliased package's tap, got %v", taps)
+	}
+
+	if taps := RequiredTaps(nil, []string{"git"}); len(taps) != 0 {
+		t.Errorf("Expected no taps, got %v", taps)
+	}
+}
+
+func TestConfiguredTools(t *testing.T) {
+	cfg := &AnvilConfig{
+		Groups: AnvilGroups{"window": {"nikitabobko/tap/aerospace", "git"}, "dev": {"git", "jq"}},
+		Tools:  AnvilTools{RequiredTools: []string{"curl", "git"}},
+	}
+	expected := []string{"git", "jq", "nikitabobko/tap/aerospace", "curl"}
+	if tools := ConfiguredTools(cfg); !reflect.DeepEqual(tools, expected) {
+		t.Errorf("Expected tools %v, got %v", expected, tools)
+	}
+}
+
+func TestMissingTaps(t *testing.T) {
+	taps := []BrewTap{{Name: "hashicorp/tap"}, {Name: "nikitabobko/tap"}}
+	expected := []BrewTap{{Name: "nikitabobko/tap"}}
+	if missing := MissingTaps(taps, []string{"homebrew/core", "HashiCorp/tap"}); !reflect.DeepEqual(missing, expected) {
+		t.Errorf("Expected missing %v, got %v", expected, missing)
+	}
+}
@@ -25,6 +25,19 @@ import (
 	"github.com/0xjuanma/palantir"
 )
 
+// Name rules shared by the validator and the JSON Schema
+const (
+	groupNamePattern      = `^[a-zA-Z0-9_-]+$`
+	groupNameMaxLength    = 50
+	appNamePattern        = `^([a-zA-Z0-9_.-]*[a-zA-Z0-9_-][a-zA-Z0-9_.-]*/[a-zA-Z0-9_.-]*[a-zA-Z0-9_-][a-zA-Z0-9_.-]*/)?[a-zA-Z0-9_.@+-]*[a-zA-Z0-9_@+-][a-zA-Z0-9_.@+-]*$` // Optionally tap-qualified, e.g. "owner/tap/name"; no dot-only segments
+	appNameMaxLength      = 100
+	tapNamePattern        = `^[a-zA-Z0-9_.-]+/[a-zA-Z0-9_.-]+$`
+	envVarNamePattern     = `^[A-Za-z_][A-Za-z0-9_]*$`
+	sourcePlatformPattern = `^(default|(darwin|linux)(-(amd64|arm64))?)$` // e.g. "darwin-arm64", "linux" or "default"
+	sha256Pattern         = `^[0-9a-fA-F]{64}$`
+	envReferencePattern   = `\$\{[A-Za-z_][A-Za-z0-9_]*\}|\$[A-Za-z_][A-Za-z0-9_]*` // ${VAR} or $VAR anywhere in a value
+)
+
 // Validator defines the interface for input validation
 type Validator interface {
 	ValidateGroupName(groupName string) error
@@ -79,7 +92,7 @@ func NewConfigValidator(config *AnvilConfig) Validator {
 
 // ValidateGroupName validates a group name
 func (cv *ConfigValidator) ValidateGroupName(groupName string) error {
-	if err := validateString(groupName, "group name", 50, `^[a-zA-Z0-9_-]+$`); err != nil {
+	if err := validateString(groupName, "group name", groupNameMaxLength, groupNamePattern); err != nil {
 		return fmt.Errorf("group name '%s' contains invalid characters. Only alphanumeric, underscore, and dash are allowed", groupName)
 	}
 	return nil
@@ -87,8 +100,8 @@ func (cv *ConfigValidator) ValidateGroupName(groupName string) error {
 
 // ValidateAppName validates an application name
 func (cv *ConfigValidator) ValidateAppName(appName string) error {
-	if err := validateString(appName, "application name", 100, `^[a-zA-Z0-9_.-]+$`); err != nil {
-		return fmt.Errorf("application name '%s' contains invalid characters. Only alphanumeric, underscore, dot, and dash are allowed", appName)
+	if err := validateString(appName, "application name", appNameMaxLength, appNamePattern); err != nil {
+		return fmt.Errorf("application name '%s' contains invalid characters. Only alphanumeric, underscore, dot, dash, '@' and '+' are allowed, optionally prefixed by a tap as 'owner/tap/'", appName)
 	}
 	return nil
 }
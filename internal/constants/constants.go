@@ -18,17 +18,21 @@ package constants
 
 // Command operation constants
 const (
-	OpInit    = "init"
-	OpInstall = "install"
-	OpConfig  = "config"
-	OpImport  = "import"
-	OpPull    = "pull"
-	OpPush    = "push"
-	OpShow    = "show"
-	OpSync    = "sync"
-	OpDoctor  = "doctor"
-	OpClean   = "clean"
-	OpUpdate  = "update"
+	OpInit      = "init"
+	OpInstall   = "install"
+	OpConfig    = "config"
+	OpImport    = "import"
+	OpPull      = "pull"
+	OpPush      = "push"
+	OpShow      = "show"
+	OpSync      = "sync"
+	OpDoctor    = "doctor"
+	OpClean     = "clean"
+	OpUpdate    = "update"
+	OpServices  = "services"
+	OpBrew      = "brew"
+	OpUninstall = "uninstall"
+	OpOutdated  = "outdated"
 )
 
 // System command constants
@@ -40,12 +44,14 @@ const (
 
 // Brew subcommand constants
 const (
-	BrewInstall = "install"
-	BrewList    = "list"
-	BrewInfo    = "info"
-	BrewUpdate  = "update"
-	BrewUpgrade = "upgrade"
-	BrewSearch  = "search"
+	BrewInstall  = "install"
+	BrewList     = "list"
+	BrewInfo     = "info"
+	BrewUpdate   = "update"
+	BrewUpgrade  = "upgrade"
+	BrewSearch   = "search"
+	BrewTap      = "tap"
+	BrewServices = "services"
 )
 
 // Git subcommand constants
@@ -106,6 +112,22 @@ const (
 	ANVIL_CONFIG_FILE = "settings.yaml"
 	ANVIL_CONFIG_DIR  = ".anvil"
 	DOTFILES_DIR      = "dotfiles"
+	BREW_INDEX_FILE   = "brew-index.json"
+	CACHE_DIR         = "cache"
+	AVAILABILITY_FILE = "availability.json"
+	MANIFESTS_DIR     = "manifests"
+)
+
+// Homebrew metadata API
+const (
+	HomebrewAPIURL       = "https://formulae.brew.sh/api"
+	HomebrewAPIDomainEnv = "HOMEBREW_API_DOMAIN"
+)
+
+// GitHub REST API, used to resolve release assets of github: sources
+const (
+	GitHubAPIURL    = "https://api.github.com"
+	GitHubAPIURLEnv = "GITHUB_API_URL"
 )
 
 // Common directory permissions
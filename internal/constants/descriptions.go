@@ -35,6 +35,18 @@ const INSTALL_COMMAND_LONG_DESCRIPTION = `Install development tools individually
 
 Define custom groups in settings.yaml`
 
+const UNINSTALL_COMMAND_LONG_DESCRIPTION = `Remove an app installed from a source in settings.yaml.
+
+Source installs record the files and links they create in ~/.anvil/manifests/<app>.yaml.
+Uninstalling removes those files and links, the manifest and the app's installed_apps entry.
+Homebrew packages are removed with 'brew uninstall'.`
+
+const OUTDATED_COMMAND_LONG_DESCRIPTION = `Check apps installed from sources in settings.yaml for newer versions.
+
+GitHub release sources are compared with the release their tag resolves to now. URL sources
+are checked with a conditional request against the ETag, Last-Modified and size recorded
+at install time. Outdated apps can be reinstalled from their source right away.`
+
 const CONFIG_COMMAND_LONG_DESCRIPTION = `Manage configuration files and dotfiles for your anvil environment.
 
 Configure 'github.config_repo' in settings.yaml to use this command.`
@@ -47,8 +59,41 @@ const PULL_COMMAND_LONG_DESCRIPTION = `Download configuration files from your Gi
 
 Configure 'github.config_repo' in settings.yaml to use this command.`
 
+const SERVICES_COMMAND_LONG_DESCRIPTION = `List, start, stop and restart the Homebrew services of your apps.
+
+Without arguments, lists the services of every app in settings.yaml. Pass a group
+or app name to narrow the list, or use start, stop and restart on a group or app.
+Apps in the 'services' section are started or restarted automatically after install.`
+
+const BREW_COMMAND_LONG_DESCRIPTION = `Manage anvil's Homebrew integration.
+
+anvil keeps an offline index of Homebrew formula and cask metadata to tell casks from
+formulae, validate app names during import, find app bundles and describe apps in
+'anvil install --tree'. A small built-in index is used until 'anvil brew index update'
+downloads the full index to ~/.anvil/brew-index.json.`
+
 const SHOW_COMMAND_LONG_DESCRIPTION = `Display configuration files and settings with intelligent formatting.`
 
+const VALIDATE_COMMAND_LONG_DESCRIPTION = `Check a settings file for problems without modifying it.
+
+Reports YAML syntax errors, unknown keys, duplicate apps, invalid group names,
+nonexistent config paths and malformed repository URLs with file:line:column positions.
+Defaults to ~/.anvil/settings.yaml and exits non-zero when problems are found.`
+
+const SCHEMA_COMMAND_LONG_DESCRIPTION = `Print the JSON Schema for settings.yaml or import files.
+
+Point your editor at the schema for completion and inline validation.
+The same schema is used by 'anvil config import' to validate imported groups.`
+
+const EXPORT_COMMAND_LONG_DESCRIPTION = `Export groups as a shareable file for 'anvil config import'.
+
+Only groups, taps and, with --with-sources, their installation sources are exported.
+Git identity, tokens, config paths and sources pointing at local paths or carrying
+credentials are never included. Prints to stdout unless --output is given.
+
+With --format brewfile the groups are written as a Brewfile, so machines without
+anvil can install them with 'brew bundle'. Groups can also be given as arguments.`
+
 const SYNC_COMMAND_LONG_DESCRIPTION = `Apply pulled configuration files to their local destinations with automatic archiving.
 
 Safely applies configs with automatic backup of existing files.`
@@ -57,14 +102,18 @@ const DOCTOR_COMMAND_LONG_DESCRIPTION = `Run health checks to validate your anvi
 
 Health Check Categories:
 
-ENVIRONMENT (3 checks)
+ENVIRONMENT (4 checks)
   • anvil-init       - Verify anvil initialization is complete
   • settings-valid   - Validate settings.yaml structure and content
   • directory-structure - Check ~/.anvil directory structure
+  • local-bin-path   - Check ~/.local/bin is on PATH for source installs
 
-DEPENDENCIES (2 checks)
+DEPENDENCIES (5 checks)
   • homebrew         - Verify Homebrew installation and updates (auto-fixable)
   • required-tools   - Check git and curl are installed
+  • homebrew-taps    - Check configured and inferred taps are tapped (auto-fixable)
+  • brew-services    - Check declared services are running (auto-fixable)
+  • source-manifests - Check files of apps installed from sources still exist
 
 CONFIGURATION (3 checks)
   • git-config       - Validate git user.name and user.email (auto-fixable)
@@ -80,8 +129,8 @@ Each check can be run independently by name or grouped by category.
 Add --fix flag to auto-fix issues where supported.
 
 Examples:
-  anvil doctor                    # Run all 11 checks
-  anvil doctor environment        # Run category (3 checks)
+  anvil doctor                    # Run all 15 checks
+  anvil doctor environment        # Run category (4 checks)
   anvil doctor git-config         # Run specific check
   anvil doctor git-config --fix   # Run check and auto-fix
   anvil doctor --fix              # Run all checks and auto-fix issues`
@@ -93,7 +142,7 @@ What it does:
 • Removes temporary files, archives, and downloaded configurations
 • Cleans temp/ and archive/ directories
 • Removes dotfiles/ directory for clean git state
-• Preserves settings.yaml file
+• Preserves settings.yaml file and install manifests
 
 Safe operation that never deletes your main configuration file.`
 
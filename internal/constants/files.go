@@ -23,13 +23,28 @@ const (
 	ExtZIP      = ".zip"
 	ExtTarGz    = ".tar.gz"
 	ExtTarBz2   = ".tar.bz2"
+	ExtTarXz    = ".tar.xz"
+	ExtTarZst   = ".tar.zst"
+	ExtTar      = ".tar"
+	ExtGz       = ".gz"
 	ExtDEB      = ".deb"
 	ExtRPM      = ".rpm"
 	ExtAppImage = ".AppImage"
-	ExtDefault  = ".zip"
 )
 
-// SupportedFileExtensions lists all supported installation file extensions
+// FileTypeBinary identifies a downloaded file that is a bare executable rather than an archive
+const FileTypeBinary = "binary"
+
+// SupportedFileExtensions lists all supported installation file extensions. Compound
+// extensions come before the single extensions they end with.
 var SupportedFileExtensions = []string{
-	ExtDMG, ExtPKG, ExtZIP, ExtTarGz, ExtDEB, ExtRPM, ExtAppImage, ExtTarBz2,
+	ExtDMG, ExtPKG, ExtZIP, ExtTarGz, ExtTarBz2, ExtTarXz, ExtTarZst, ExtTar, ExtGz, ExtDEB, ExtRPM, ExtAppImage,
+}
+
+// FileExtensionAliases maps short archive extensions to the extension they stand for
+var FileExtensionAliases = map[string]string{
+	".tgz":  ExtTarGz,
+	".tbz2": ExtTarBz2,
+	".txz":  ExtTarXz,
+	".tzst": ExtTarZst,
 }
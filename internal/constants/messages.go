@@ -37,6 +37,7 @@ const (
 	StatusFailed              = "Failed"
 	StatusInstalling          = "Installing..."
 	StatusPending             = "Pending"
+	StatusSkipped             = "Skipped"
 	StatusConfigurationSynced = "configuration synced successfully"
 	StatusSettingsSynced      = "settings synced successfully"
 	StatusUpToDate            = "Configuration up-to-date (no changes)"
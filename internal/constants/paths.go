@@ -29,4 +29,7 @@ const (
 const (
 	DownloadsDirName     = "Downloads"
 	AnvilDownloadsSubdir = "anvil-downloads"
+	UserInstallPrefix    = "~/.local" // Prefix for rootless archive and binary installs
+	UserInstallAppsDir   = "opt"      // Prefix subdirectory holding one directory per app
+	UserInstallBinDir    = "bin"      // Prefix subdirectory holding links to app executables
 )
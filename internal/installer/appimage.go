@@ -0,0 +1,310 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/png" // Registers PNG for icon sizes
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/system"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+	"github.com/0xjuanma/anvil/internal/utils"
+)
+
+const (
+	appImageExtractTimeout = 2 * time.Minute
+	appImageRootDir        = "squashfs-root"           // Directory --appimage-extract extracts into
+	appImageDirIcon        = ".DirIcon"                // Icon every AppImage carries at its root
+	desktopEntryPrefix     = "anvil-"                  // Prefix of desktop entries and icons anvil installs
+	desktopExecReserved    = " \t\n\"'\\><~|&;$*?#()`" // Characters that must be quoted in Exec=
+)
+
+// hicolorSizes are the icon sizes of the hicolor theme; PNG icons of other sizes go to the
+// unthemed icons directory instead
+var hicolorSizes = map[int]bool{16: true, 22: true, 24: true, 32: true, 48: true, 64: true, 96: true, 128: true, 192: true, 256: true, 512: true}
+
+// addAppImageToDesktop runs the desktop integration of an installed AppImage, warning
+// instead of failing the install when the AppImage cannot provide it
+func addAppImageToDesktop(appImagePath, appName string) {
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Adding %s to desktop launchers", appName))
+	spinner.Start()
+	if err := integrateAppImage(appImagePath, appName); err != nil {
+		spinner.Warning(fmt.Sprintf("Installed %s, but could not add it to desktop launchers: %v", appName, err))
+		return
+	}
+	spinner.Success(fmt.Sprintf("Added %s to desktop launchers", appName))
+}
+
+// integrateAppImage adds an installed AppImage to desktop launchers: the desktop entry and
+// icon embedded in it are extracted and installed in the user's data directory, with Exec=
+// pointing at the AppImage. Both are recorded in the app's manifest.
+func integrateAppImage(appImagePath, appName string) error {
+	workDir, err := os.MkdirTemp("", "anvil-appimage-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), appImageExtractTimeout)
+	defer cancel()
+
+	root := filepath.Join(workDir, appImageRootDir)
+	if err := extractFromAppImage(ctx, appImagePath, workDir, "*.desktop"); err != nil {
+		return err
+	}
+	desktopFiles, _ := filepath.Glob(filepath.Join(root, "*.desktop"))
+	if len(desktopFiles) == 0 {
+		return fmt.Errorf("%s has no desktop entry", filepath.Base(appImagePath))
+	}
+	sort.Strings(desktopFiles)
+	entry, err := os.ReadFile(desktopFiles[0])
+	if err != nil {
+		return fmt.Errorf("failed to read desktop entry: %w", err)
+	}
+
+	dataDir := userDataDirectory()
+	entryName := desktopEntryPrefix + strings.ReplaceAll(appName, "/", "-")
+
+	iconName := ""
+	if icon := extractAppImageIcon(ctx, appImagePath, workDir, desktopEntryValue(entry, "Icon")); icon != "" {
+		iconPath, err := installDesktopIcon(icon, dataDir, entryName)
+		if err != nil {
+			return err
+		}
+		recordFile(appName, iconPath)
+		iconName = entryName
+	}
+
+	applicationsDir := filepath.Join(dataDir, "applications")
+	if err := utils.EnsureDirectory(applicationsDir); err != nil {
+		return fmt.Errorf("failed to create %s: %w", applicationsDir, err)
+	}
+	desktopPath := filepath.Join(applicationsDir, entryName+".desktop")
+	if err := os.WriteFile(desktopPath, rewriteDesktopEntry(entry, appImagePath, iconName), 0644); err != nil {
+		return fmt.Errorf("failed to write desktop entry: %w", err)
+	}
+	recordFile(appName, desktopPath)
+
+	refreshDesktopDatabase(applicationsDir)
+	return nil
+}
+
+// extractFromAppImage extracts the AppImage paths matching pattern into
+// <workDir>/squashfs-root using the AppImage runtime
+func extractFromAppImage(ctx context.Context, appImagePath, workDir, pattern string) error {
+	result, err := system.RunCommandInDirectoryWithTimeout(ctx, workDir, appImagePath, "--appimage-extract", pattern)
+	if err != nil {
+		return fmt.Errorf("failed to extract from %s: %w", filepath.Base(appImagePath), err)
+	}
+	if !result.Success {
+		return fmt.Errorf("failed to extract from %s: %s", filepath.Base(appImagePath), strings.TrimSpace(result.Error+" "+result.Output))
+	}
+	return nil
+}
+
+// extractAppImageIcon extracts the icon named by the desktop entry, or the .DirIcon, and
+// returns its path, or "" when the AppImage has none. Icons at the AppImage root are
+// often links into usr/share/icons, so link targets are extracted as well.
+func extractAppImageIcon(ctx context.Context, appImagePath, workDir, iconName string) string {
+	root := filepath.Join(workDir, appImageRootDir)
+	iconName = strings.TrimSuffix(strings.TrimSuffix(filepath.Base(iconName), ".png"), ".svg")
+
+	var candidates []string
+	if iconName != "" && iconName != "." {
+		candidates = append(candidates, iconName+".svg", iconName+".png")
+	}
+	candidates = append(candidates, appImageDirIcon)
+
+	for _, candidate := range candidates {
+		path := filepath.Join(root, candidate)
+		if extractFromAppImage(ctx, appImagePath, workDir, candidate) != nil {
+			continue
+		}
+		// Follow links inside the AppImage, extracting each target
+		for i := 0; i < 4; i++ {
+			if _, err := os.Stat(path); err == nil {
+				break
+			}
+			target, err := os.Readlink(path)
+			if err != nil {
+				break
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			relative, err := filepath.Rel(root, target)
+			if err != nil || strings.HasPrefix(relative, "..") {
+				break
+			}
+			if extractFromAppImage(ctx, appImagePath, workDir, relative) != nil {
+				break
+			}
+			path = target
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+	}
+	return ""
+}
+
+// installDesktopIcon installs an icon as <name>.png or <name>.svg in the hicolor theme of
+// dataDir, or in the unthemed icons directory for PNGs of other sizes, and returns its path
+func installDesktopIcon(iconPath, dataDir, name string) (string, error) {
+	data, err := os.ReadFile(iconPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read icon: %w", err)
+	}
+
+	iconsDir := filepath.Join(dataDir, "icons")
+	var dest string
+	switch {
+	case bytes.Contains(data[:min(len(data), 1024)], []byte("<svg")):
+		dest = filepath.Join(iconsDir, "hicolor", "scalable", "apps", name+".svg")
+	default:
+		config, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil || format != "png" {
+			return "", fmt.Errorf("unsupported icon %s: expected PNG or SVG", filepath.Base(iconPath))
+		}
+		dest = filepath.Join(iconsDir, name+".png")
+		if config.Width == config.Height && hicolorSizes[config.Width] {
+			size := fmt.Sprintf("%dx%d", config.Width, config.Height)
+			dest = filepath.Join(iconsDir, "hicolor", size, "apps", name+".png")
+		}
+	}
+
+	if err := utils.EnsureDirectory(filepath.Dir(dest)); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(dest), err)
+	}
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to install icon: %w", err)
+	}
+	return dest, nil
+}
+
+// desktopEntryValue returns the value of a key in the [Desktop Entry] group
+func desktopEntryValue(entry []byte, key string) string {
+	group := ""
+	for _, line := range strings.Split(string(entry), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			group = line
+			continue
+		}
+		if name, value, ok := strings.Cut(line, "="); ok && group == "[Desktop Entry]" && strings.TrimSpace(name) == key {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// rewriteDesktopEntry points every Exec= and TryExec= of a desktop entry at the installed
+// AppImage, keeping the arguments, and sets Icon= to the installed icon when there is one
+func rewriteDesktopEntry(entry []byte, appImagePath, iconName string) []byte {
+	lines := strings.Split(string(entry), "\n")
+	for i, line := range lines {
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(name) {
+		case "Exec":
+			lines[i] = "Exec=" + desktopExecQuote(appImagePath) + desktopExecArgs(strings.TrimSpace(value))
+		case "TryExec":
+			lines[i] = "TryExec=" + appImagePath
+		case "Icon":
+			if iconName != "" {
+				lines[i] = "Icon=" + iconName
+			}
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// desktopExecArgs returns what follows the program in an Exec= value, with its leading space
+func desktopExecArgs(exec string) string {
+	if strings.HasPrefix(exec, `"`) {
+		for i := 1; i < len(exec); i++ {
+			switch exec[i] {
+			case '\\':
+				i++
+			case '"':
+				return exec[i+1:]
+			}
+		}
+		return ""
+	}
+	if i := strings.IndexAny(exec, " \t"); i >= 0 {
+		return exec[i:]
+	}
+	return ""
+}
+
+// desktopExecQuote quotes a path for Exec= following the desktop entry specification:
+// paths with reserved characters are double quoted with ", `, $ and \ escaped, and since
+// Exec= is also a string value, those escapes are escaped again. % is doubled.
+func desktopExecQuote(path string) string {
+	path = strings.ReplaceAll(path, "%", "%%")
+	if !strings.ContainsAny(path, desktopExecReserved) {
+		return path
+	}
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, c := range path {
+		switch c {
+		case '\\':
+			quoted.WriteString(`\\\\`)
+		case '"', '`', '$':
+			quoted.WriteString(`\\`)
+			quoted.WriteRune(c)
+		default:
+			quoted.WriteRune(c)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// userDataDirectory returns $XDG_DATA_HOME, or ~/.local/share when it is not set
+func userDataDirectory() string {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+		return dataHome
+	}
+	homeDir, _ := system.HomeDir()
+	return filepath.Join(homeDir, ".local", "share")
+}
+
+// refreshDesktopDatabase updates the launcher cache of a desktop entries directory when
+// update-desktop-database is available. Launchers that watch the directory do not need it.
+func refreshDesktopDatabase(applicationsDir string) {
+	if system.CommandExists("update-desktop-database") {
+		system.RunCommand("update-desktop-database", applicationsDir)
+	}
+}
+
+// isDesktopEntry reports whether a recorded path is a desktop entry anvil installed
+func isDesktopEntry(path string) bool {
+	return filepath.Ext(path) == ".desktop" && strings.HasPrefix(filepath.Base(path), desktopEntryPrefix)
+}
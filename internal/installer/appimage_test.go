@@ -0,0 +1,188 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/manifest"
+)
+
+// fakeAppImage writes an executable that extracts the files of contentDir matching
+// --appimage-extract's pattern into ./squashfs-root, like the AppImage runtime
+func fakeAppImage(t *testing.T, dir, contentDir string) string {
+	t.Helper()
+	script := `#!/bin/sh
+[ "$1" = "--appimage-extract" ] || exit 1
+root="$PWD/squashfs-root"
+cd "` + contentDir + `" || exit 1
+find . -mindepth 1 | while read -r path; do
+	path="${path#./}"
+	case "$path" in
+	$2)
+		mkdir -p "$root/$(dirname "$path")"
+		cp -P "$path" "$root/$path"
+		;;
+	esac
+done
+`
+	return writeFixture(t, dir, "Tool-1.2.0-x86_64.AppImage", []byte(script), 0755)
+}
+
+// pngIcon returns a square PNG of the given size
+func pngIcon(t *testing.T, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, size, size))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestInstallAppImageDesktopIntegration(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("AppImages are Linux only")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+
+	content := t.TempDir()
+	writeFixture(t, content, "tool.desktop", []byte("[Desktop Entry]\nName=Tool\nExec=AppRun --no-sandbox %U\nTryExec=tool\nIcon=tool\nType=Application\n\n[Desktop Action new]\nName=New Window\nExec=tool --new-window\n"), 0644)
+	iconDir := filepath.Join(content, "usr", "share", "icons", "hicolor", "64x64", "apps")
+	if err := os.MkdirAll(iconDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFixture(t, iconDir, "tool.png", pngIcon(t, 64), 0644)
+	if err := os.Symlink("usr/share/icons/hicolor/64x64/apps/tool.png", filepath.Join(content, "tool.png")); err != nil {
+		t.Fatal(err)
+	}
+	appImage := fakeAppImage(t, t.TempDir(), content)
+
+	record := manifest.New("tool", "https://example.com/Tool-1.2.0-x86_64.AppImage")
+	if err := installRecorded(record, func() error { return installAppImage(appImage, "tool") }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	installed := filepath.Join(home, "Applications", "Tool-1.2.0-x86_64.AppImage")
+	desktopPath := filepath.Join(home, ".local", "share", "applications", "anvil-tool.desktop")
+	iconPath := filepath.Join(home, ".local", "share", "icons", "hicolor", "64x64", "apps", "anvil-tool.png")
+
+	entry, err := os.ReadFile(desktopPath)
+	if err != nil {
+		t.Fatalf("Expected a desktop entry: %v", err)
+	}
+	for _, expected := range []string{
+		"Exec=" + installed + " --no-sandbox %U\n",
+		"TryExec=" + installed + "\n",
+		"Icon=anvil-tool\n",
+		"Exec=" + installed + " --new-window\n",
+	} {
+		if !strings.Contains(string(entry), expected) {
+			t.Errorf("Expected %q in the desktop entry:\n%s", expected, entry)
+		}
+	}
+	if _, err := os.Stat(iconPath); err != nil {
+		t.Errorf("Expected the icon in the hicolor theme: %v", err)
+	}
+
+	saved, err := manifest.Load("tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(saved.Files, ",") != strings.Join([]string{installed, iconPath, desktopPath}, ",") {
+		t.Errorf("Expected the AppImage, icon and desktop entry to be recorded, got %v", saved.Files)
+	}
+
+	if err := Uninstall("tool"); err != nil {
+		t.Fatalf("Unexpected uninstall error: %v", err)
+	}
+	for _, path := range []string{installed, iconPath, desktopPath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+}
+
+func TestInstallAppImageWithoutDesktopEntry(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("AppImages are Linux only")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	appImage := fakeAppImage(t, t.TempDir(), t.TempDir())
+
+	// The AppImage is still installed, only the desktop integration is skipped
+	if err := installAppImage(appImage, "tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, "Applications", filepath.Base(appImage))); err != nil {
+		t.Errorf("Expected the AppImage to be installed: %v", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(home, ".local", "share", "applications", "*")); len(entries) != 0 {
+		t.Errorf("Expected no desktop entry, got %v", entries)
+	}
+}
+
+func TestInstallDesktopIcon(t *testing.T) {
+	dataDir := t.TempDir()
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		content  []byte
+		expected string
+	}{
+		{"theme size", pngIcon(t, 128), "icons/hicolor/128x128/apps/anvil-tool.png"},
+		{"other size", pngIcon(t, 100), "icons/anvil-tool.png"},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`), "icons/hicolor/scalable/apps/anvil-tool.svg"},
+	}
+	for _, tt := range tests {
+		icon := writeFixture(t, dir, "icon", tt.content, 0644)
+		got, err := installDesktopIcon(icon, dataDir, "anvil-tool")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != filepath.Join(dataDir, tt.expected) {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+
+	if _, err := installDesktopIcon(writeFixture(t, dir, "icon", []byte("GIF89a"), 0644), dataDir, "anvil-tool"); err == nil {
+		t.Error("Expected an unsupported icon to be refused")
+	}
+}
+
+func TestRewriteDesktopEntry(t *testing.T) {
+	entry := []byte("[Desktop Entry]\nExec=\"/opt/my app/run\" --flag %F\nIcon=app\n")
+
+	got := string(rewriteDesktopEntry(entry, "/home/me/My Apps/tool%1.AppImage", ""))
+	expected := "[Desktop Entry]\nExec=\"/home/me/My Apps/tool%%1.AppImage\" --flag %F\nIcon=app\n"
+	if got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if quoted := desktopExecQuote(`/a/b$c"d`); quoted != `"/a/b\\$c\\"d"` {
+		t.Errorf("Expected reserved characters to be escaped, got %s", quoted)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"fmt"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+// InstallWithBrew installs an app with Homebrew using its configured brew_options.
+// Aliased app names install the package they refer to.
+func InstallWithBrew(appName string) error {
+	packageName, opts, err := brewPackage(appName)
+	if err != nil {
+		return err
+	}
+	return brew.InstallPackageWithOptions(packageName, opts)
+}
+
+// BrewInstallPlan describes the brew command used for an app when it has brew_options or
+// an alias, or returns "" when the app is installed with the default command
+func BrewInstallPlan(appName string) string {
+	packageName, opts, err := brewPackage(appName)
+	if err != nil || (opts.IsEmpty() && packageName == appName) {
+		return ""
+	}
+	return brew.InstallCommand(packageName, opts)
+}
+
+// brewPackage resolves the package an app name installs and its brew_options, which
+// may be configured under either the app name or the package name
+func brewPackage(appName string) (string, brew.InstallOptions, error) {
+	packageName := config.ResolveAppName(appName)
+	opts, err := config.AppBrewOptions(appName)
+	if err == nil && opts.IsEmpty() && packageName != appName {
+		opts, err = config.AppBrewOptions(packageName)
+	}
+	if err != nil {
+		return "", brew.InstallOptions{}, fmt.Errorf("failed to load brew options: %w", err)
+	}
+	return packageName, opts, nil
+}
@@ -39,6 +39,8 @@ import (
 type InstallationResult struct {
 	ToolName  string
 	Success   bool
+	Skipped   bool // No source for this platform, Error explains why
+	Installed bool // Newly and fully installed, so its post-install hooks are due
 	Error     error
 	Duration  time.Duration
 	StartTime time.Time
@@ -50,6 +52,7 @@ type InstallationStats struct {
 	TotalTools      int
 	SuccessfulTools int
 	FailedTools     int
+	SkippedTools    int
 	TotalDuration   time.Duration
 	AverageDuration time.Duration
 	MaxDuration     time.Duration
@@ -64,6 +67,7 @@ type ConcurrentInstaller struct {
 	dryRun        bool
 	timeout       time.Duration
 	retryAttempts int
+	availability  *brew.AvailabilitySnapshot
 }
 
 // NewConcurrentInstaller creates a new concurrent installer
@@ -120,6 +124,9 @@ func (ci *ConcurrentInstaller) InstallTools(ctx context.Context, tools []string)
 		ci.printProgress(result, len(results), len(tools))
 	}
 
+	// Hooks are interactive shell commands, so they run one at a time once all installs are done
+	ci.runPostInstallHooks(tools, results)
+
 	// Calculate statistics
 	stats := ci.calculateStats(results, startTime)
 
@@ -178,7 +185,7 @@ func (ci *ConcurrentInstaller) installWithTimeout(ctx context.Context, tool stri
 		}
 
 		// Use unified availability checking logic (ensures consistency with other installation methods)
-		if brew.IsApplicationAvailable(tool) {
+		if IsAvailable(tool, ci.availability) {
 			ci.output.PrintAlreadyAvailable("Worker %d: %s is already available", workerID, tool)
 			return InstallationResult{
 				ToolName:  tool,
@@ -189,9 +196,27 @@ func (ci *ConcurrentInstaller) installWithTimeout(ctx context.Context, tool stri
 			}
 		}
 
+		// Apps whose sources do not cover this platform are skipped rather than failed
+		if _, _, err := SourceURL(tool); IsSourceSkip(err) {
+			ci.output.PrintWarning("Worker %d: SKIP %s: %v", workerID, tool, err)
+			return InstallationResult{
+				ToolName:  tool,
+				Skipped:   true,
+				Error:     err,
+				StartTime: startTime,
+				EndTime:   time.Now(),
+				Duration:  time.Since(startTime),
+			}
+		}
+
 		// Handle dry-run consistently with other installation methods
 		if ci.dryRun {
-			ci.output.PrintInfo("Worker %d: Would install %s", workerID, tool)
+			if plan := InstallPlan(tool); plan != "" {
+				ci.output.PrintInfo("Worker %d: Would install %s (%s)", workerID, tool, plan)
+			} else {
+				ci.output.PrintInfo("Worker %d: Would install %s", workerID, tool)
+			}
+			PrintPostInstallHooksPlan(ci.output, tool)
 			return InstallationResult{
 				ToolName:  tool,
 				Success:   true,
@@ -202,13 +227,15 @@ func (ci *ConcurrentInstaller) installWithTimeout(ctx context.Context, tool stri
 		}
 
 		// Install the tool
-		err := ci.installSingleTool(toolCtx, tool, workerID)
+		completed, err := ci.installSingleTool(toolCtx, tool, workerID)
 		if err == nil {
 			endTime := time.Now()
+			brew.InvalidateAvailabilityCache()
 			ci.output.PrintSuccess(fmt.Sprintf("Worker %d: %s installed successfully", workerID, tool))
 			return InstallationResult{
 				ToolName:  tool,
 				Success:   true,
+				Installed: completed,
 				StartTime: startTime,
 				EndTime:   endTime,
 				Duration:  endTime.Sub(startTime),
@@ -243,35 +270,38 @@ func (ci *ConcurrentInstaller) installWithTimeout(ctx context.Context, tool stri
 	}
 }
 
-// installSingleTool installs a single tool (similar to the original logic)
-func (ci *ConcurrentInstaller) installSingleTool(ctx context.Context, tool string, workerID int) error {
+// installSingleTool installs a single tool (similar to the original logic). It reports
+// whether the install completed, which is not the case when only extraction succeeded.
+func (ci *ConcurrentInstaller) installSingleTool(ctx context.Context, tool string, workerID int) (bool, error) {
 	// Check if source is configured for this app (user explicitly configured it)
 	sourceURL, exists, sourceErr := SourceURL(tool)
 	if sourceErr != nil {
 		ci.output.PrintWarning("Worker %d: Failed to check source URL for %s: %v", workerID, tool, sourceErr)
 		// Fall back to brew if we can't check source
-		return brew.InstallPackageDirectly(tool)
-	}
-
-	// If source exists, try it first (user explicitly configured it)
-	if exists && sourceURL != "" {
+		if err := InstallWithBrew(tool); err != nil {
+			return false, err
+		}
+	} else if exists && sourceURL != "" {
+		// If source exists, try it first (user explicitly configured it)
 		ci.output.PrintInfo("Worker %d: Installing %s from configured source", workerID, tool)
 		if err := InstallFromSource(tool, sourceURL); err != nil {
 			// Check if extraction succeeded but installation failed
 			if _, ok := err.(*ExtractionSucceededError); ok {
 				// Extraction succeeded, don't fall back to brew
 				// User message already shown in InstallFromSource
-				return nil
+				return false, nil
 			}
 			// Source installation failed, fall back to brew
-			ci.output.PrintInfo("Worker %d: Source installation failed, falling back to brew for %s", workerID, tool)
-			return brew.InstallPackageDirectly(tool)
+			ci.output.PrintWarning("Worker %d: Source installation failed for %s: %v", workerID, tool, err)
+			ci.output.PrintInfo("Worker %d: Falling back to brew for %s", workerID, tool)
+			if err := InstallWithBrew(tool); err != nil {
+				return false, err
+			}
 		}
-		// Source installation succeeded, continue with post-install steps
 	} else {
 		// No source configured, use brew (default for majority of apps)
-		if err := brew.InstallPackageDirectly(tool); err != nil {
-			return errors.NewInstallationError(constants.OpInstall, tool, err)
+		if err := InstallWithBrew(tool); err != nil {
+			return false, errors.NewInstallationError(constants.OpInstall, tool, err)
 		}
 	}
 
@@ -287,6 +317,11 @@ func (ci *ConcurrentInstaller) installSingleTool(ctx context.Context, tool strin
 		}
 	}
 
+	// Start or restart the app's brew service if configured
+	if err := ApplyServiceAction(tool); err != nil {
+		ci.output.PrintWarning("Worker %d: %v", workerID, err)
+	}
+
 	// Handle config check for git
 	if tool == "git" {
 		if err := ci.checkToolConfiguration(tool); err != nil {
@@ -294,7 +329,25 @@ func (ci *ConcurrentInstaller) installSingleTool(ctx context.Context, tool strin
 		}
 	}
 
-	return nil
+	return true, nil
+}
+
+// runPostInstallHooks runs the post-install hooks of the tools installed in this run, one
+// tool at a time in the order they were requested
+func (ci *ConcurrentInstaller) runPostInstallHooks(tools []string, results []InstallationResult) {
+	installed := make(map[string]bool, len(results))
+	for _, result := range results {
+		installed[result.ToolName] = result.Installed
+	}
+	for _, tool := range tools {
+		if !installed[tool] {
+			continue
+		}
+		installed[tool] = false // Run once even if the tool was listed twice
+		if err := RunPostInstallHooks(tool); err != nil {
+			ci.output.PrintWarning("%v", err)
+		}
+	}
 }
 
 // printPostInstallInstructions prints post-install instructions for a tool
@@ -329,7 +382,9 @@ func (ci *ConcurrentInstaller) checkGitConfiguration() error {
 // printProgress prints installation progress
 func (ci *ConcurrentInstaller) printProgress(result InstallationResult, completed, total int) {
 	status := "✓"
-	if !result.Success {
+	if result.Skipped {
+		status = "⊘"
+	} else if !result.Success {
 		status = "✗"
 	}
 
@@ -349,9 +404,12 @@ func (ci *ConcurrentInstaller) calculateStats(results []InstallationResult, star
 	for _, result := range results {
 		durations = append(durations, result.Duration)
 
-		if result.Success {
+		switch {
+		case result.Skipped:
+			stats.SkippedTools++
+		case result.Success:
 			stats.SuccessfulTools++
-		} else {
+		default:
 			stats.FailedTools++
 		}
 	}
@@ -389,10 +447,19 @@ func (ci *ConcurrentInstaller) printSummary(stats *InstallationStats, results []
 		stats.AverageDuration.Round(time.Millisecond))
 	ci.output.PrintInfo("Used %d concurrent workers", stats.ConcurrentJobs)
 
+	if stats.SkippedTools > 0 {
+		ci.output.PrintWarning("Skipped installations:")
+		for _, result := range results {
+			if result.Skipped {
+				ci.output.PrintWarning("  • %s: %v", result.ToolName, result.Error)
+			}
+		}
+	}
+
 	if stats.FailedTools > 0 {
 		ci.output.PrintWarning("Failed installations:")
 		for _, result := range results {
-			if !result.Success {
+			if !result.Success && !result.Skipped {
 				ci.output.PrintError("  • %s: %v", result.ToolName, result.Error)
 			}
 		}
@@ -411,6 +478,12 @@ func (ci *ConcurrentInstaller) SetTimeout(timeout time.Duration) {
 	ci.timeout = timeout
 }
 
+// SetAvailability makes availability checks use a snapshot taken once for all tools
+// instead of checking the system for each tool
+func (ci *ConcurrentInstaller) SetAvailability(snapshot *brew.AvailabilitySnapshot) {
+	ci.availability = snapshot
+}
+
 // SetRetryAttempts sets the number of retry attempts for failed installations
 func (ci *ConcurrentInstaller) SetRetryAttempts(attempts int) {
 	ci.retryAttempts = attempts
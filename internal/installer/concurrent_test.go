@@ -19,7 +19,10 @@ package installer
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 )
@@ -492,3 +495,40 @@ func BenchmarkConcurrentInstaller_calculateStats(b *testing.B) {
 		installer.calculateStats(results, startTime)
 	}
 }
+
+func TestConcurrentInstallerRunsHooksSerially(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks need sh")
+	}
+	home := writeSettings(t, "hooks:\n  post_install:\n"+
+		"    first:\n      - echo first >> \"$HOME/hooks\"\n"+
+		"    second:\n      - echo second >> \"$HOME/hooks\"\n"+
+		"    present:\n      - echo present >> \"$HOME/hooks\"\n")
+
+	installer := NewConcurrentInstaller(2, &MockOutputHandler{}, false)
+	// Results arrive in completion order; already available apps have no hooks due
+	results := []InstallationResult{
+		{ToolName: "second", Success: true, Installed: true},
+		{ToolName: "present", Success: true},
+		{ToolName: "first", Success: true, Installed: true},
+	}
+	installer.runPostInstallHooks([]string{"first", "present", "second", "first"}, results)
+
+	data, err := os.ReadFile(filepath.Join(home, "hooks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(data)); strings.Join(got, " ") != "first second" {
+		t.Errorf("Expected hooks to run once per installed tool in request order, got %v", got)
+	}
+}
+
+func TestPrintPostInstallHooksPlan(t *testing.T) {
+	writeSettings(t, "hooks:\n  post_install:\n    neovim:\n      - nvim --headless +qa\n")
+
+	output := &MockOutputHandler{}
+	PrintPostInstallHooksPlan(output, "neovim")
+	if len(output.messages) != 1 || !strings.Contains(output.messages[0], "nvim --headless +qa") {
+		t.Errorf("Expected the hook to be listed, got %v", output.messages)
+	}
+}
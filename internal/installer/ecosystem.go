@@ -0,0 +1,347 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/system"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+)
+
+// Language package managers that sources can install with, e.g. "cargo:ripgrep"
+const (
+	ecosystemGo    = "go"
+	ecosystemCargo = "cargo"
+	ecosystemNpm   = "npm"
+	ecosystemPipx  = "pipx"
+	ecosystemUv    = "uv"
+)
+
+// latestVersion is the version that installs the newest release
+const latestVersion = "latest"
+
+// goMajorVersionSuffix matches the /vN suffix of Go module paths
+var goMajorVersionSuffix = regexp.MustCompile(`^v\d+$`)
+
+// ecosystemSource is a parsed "<ecosystem>:<package>[@version]" source
+type ecosystemSource struct {
+	Ecosystem string
+	Package   string
+	Version   string // Pinned version; empty or "latest" for the newest release
+}
+
+// ecosystem installs packages with a language package manager and reports which are installed
+type ecosystem struct {
+	command     string
+	installArgs func(src ecosystemSource) []string
+	// installedVersion reports whether a package is installed and, when it can tell, its version
+	installedVersion func(src ecosystemSource) (string, bool)
+}
+
+// ecosystems are the package managers sources can name
+var ecosystems = map[string]ecosystem{
+	ecosystemGo: {
+		command: "go",
+		installArgs: func(src ecosystemSource) []string {
+			version := src.Version
+			if version == "" {
+				version = latestVersion
+			}
+			return []string{"install", src.Package + "@" + version}
+		},
+		installedVersion: goInstalledVersion,
+	},
+	ecosystemCargo: {
+		command: "cargo",
+		installArgs: func(src ecosystemSource) []string {
+			if src.IsPinned() {
+				return []string{"install", src.Package, "--version", src.Version}
+			}
+			return []string{"install", src.Package}
+		},
+		installedVersion: cargoInstalledVersion,
+	},
+	ecosystemNpm: {
+		command: "npm",
+		installArgs: func(src ecosystemSource) []string {
+			if src.IsPinned() {
+				return []string{"install", "-g", src.Package + "@" + src.Version}
+			}
+			return []string{"install", "-g", src.Package}
+		},
+		installedVersion: npmInstalledVersion,
+	},
+	ecosystemPipx: {
+		command: "pipx",
+		installArgs: func(src ecosystemSource) []string {
+			// --force replaces an installed version that does not match the pin
+			if src.IsPinned() {
+				return []string{"install", "--force", src.Package + "==" + src.Version}
+			}
+			return []string{"install", src.Package}
+		},
+		installedVersion: pipxInstalledVersion,
+	},
+	ecosystemUv: {
+		command: "uv",
+		installArgs: func(src ecosystemSource) []string {
+			if src.IsPinned() {
+				return []string{"tool", "install", "--force", src.Package + "==" + src.Version}
+			}
+			return []string{"tool", "install", src.Package}
+		},
+		installedVersion: uvInstalledVersion,
+	},
+}
+
+// isEcosystemSource checks if the source names a language package manager
+func isEcosystemSource(source string) bool {
+	scheme, _, ok := strings.Cut(strings.TrimSpace(source), ":")
+	_, known := ecosystems[scheme]
+	return ok && known
+}
+
+// parseEcosystemSource parses "<ecosystem>:<package>[@version]". Scoped npm packages such
+// as "@scope/cli" keep their leading @.
+func parseEcosystemSource(source string) (*ecosystemSource, error) {
+	scheme, spec, _ := strings.Cut(strings.TrimSpace(source), ":")
+	if _, known := ecosystems[scheme]; !known {
+		return nil, fmt.Errorf("unknown package manager in %q", source)
+	}
+
+	pkg, version := spec, ""
+	if at := strings.LastIndex(spec, "@"); at > 0 {
+		pkg, version = spec[:at], spec[at+1:]
+	}
+	if pkg == "" || strings.ContainsAny(pkg, " \t") || strings.HasSuffix(spec, "@") {
+		return nil, fmt.Errorf("invalid %s source %q (expected %s:<package>[@version])", scheme, source, scheme)
+	}
+	return &ecosystemSource{Ecosystem: scheme, Package: pkg, Version: version}, nil
+}
+
+// IsPinned reports whether the source asks for a specific version
+func (s ecosystemSource) IsPinned() bool {
+	return s.Version != "" && s.Version != latestVersion
+}
+
+// Command returns the command line that installs the source
+func (s ecosystemSource) Command() string {
+	eco := ecosystems[s.Ecosystem]
+	return strings.Join(append([]string{eco.command}, eco.installArgs(s)...), " ")
+}
+
+// IsInstalled reports whether the package is installed, at the pinned version if there is one.
+// Pinned packages whose installed version cannot be determined count as installed.
+func (s ecosystemSource) IsInstalled() bool {
+	version, installed := ecosystems[s.Ecosystem].installedVersion(s)
+	if !installed || !s.IsPinned() || version == "" {
+		return installed
+	}
+	return strings.TrimPrefix(version, "v") == strings.TrimPrefix(s.Version, "v")
+}
+
+// installFromEcosystem installs a package with its language package manager
+func installFromEcosystem(appName, source string) error {
+	src, err := parseEcosystemSource(source)
+	if err != nil {
+		return err
+	}
+
+	eco := ecosystems[src.Ecosystem]
+	if !system.CommandExists(eco.command) {
+		return fmt.Errorf("%s is not installed, so %s cannot be installed with it", eco.command, appName)
+	}
+
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Installing %s with %s", appName, eco.command))
+	spinner.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.ToolInstallTimeout)
+	defer cancel()
+	result, err := system.RunCommandWithTimeout(ctx, eco.command, eco.installArgs(*src)...)
+	if err != nil || !result.Success {
+		spinner.Error(fmt.Sprintf("Failed to install %s", appName))
+		return fmt.Errorf("'%s' failed: %s", src.Command(), strings.TrimSpace(result.Output))
+	}
+
+	spinner.Success(fmt.Sprintf("Installed %s with %s", appName, eco.command))
+	return nil
+}
+
+// ecosystemSourceFor returns the parsed ecosystem source configured for an app on this
+// platform, or nil when the app has none
+func ecosystemSourceFor(appName string) *ecosystemSource {
+	source, exists, err := SourceURL(appName)
+	if err != nil || !exists || !isEcosystemSource(source) {
+		return nil
+	}
+	src, err := parseEcosystemSource(source)
+	if err != nil {
+		return nil
+	}
+	return src
+}
+
+// IsAvailable reports whether an app is already installed. Apps with a language package
+// manager source are looked up with that package manager, honoring version pins; others
+// are checked through the availability snapshot, which may be nil.
+func IsAvailable(appName string, availability *brew.AvailabilitySnapshot) bool {
+	if src := ecosystemSourceFor(appName); src != nil {
+		return src.IsInstalled()
+	}
+	return availability.IsAvailable(config.ResolveAppName(appName))
+}
+
+// InstallPlan describes how an app would be installed for dry runs: the package manager
+// command of ecosystem sources, the script run by script sources, the brew command of
+// apps with brew options, or ""
+func InstallPlan(appName string) string {
+	if src := ecosystemSourceFor(appName); src != nil {
+		return src.Command()
+	}
+	if plan := remoteScriptPlan(appName); plan != "" {
+		return plan
+	}
+	return BrewInstallPlan(appName)
+}
+
+// goBinaryName returns the name go install gives the binary of a package path, skipping
+// a /vN major version suffix
+func goBinaryName(pkg string) string {
+	name := path.Base(pkg)
+	if goMajorVersionSuffix.MatchString(name) {
+		name = path.Base(path.Dir(pkg))
+	}
+	return name
+}
+
+// goBinDir returns where go install puts binaries: GOBIN, the first GOPATH entry's bin
+// directory, or ~/go/bin
+func goBinDir() string {
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		return gobin
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "bin")
+	}
+	homeDir, _ := system.HomeDir()
+	return filepath.Join(homeDir, "go", "bin")
+}
+
+// goInstalledVersion looks for the package's binary in the go bin directory and reads
+// the module version from its build info
+func goInstalledVersion(src ecosystemSource) (string, bool) {
+	binary := filepath.Join(goBinDir(), goBinaryName(src.Package))
+	if _, err := os.Stat(binary); err != nil {
+		return "", false
+	}
+
+	result, err := system.RunCommand("go", "version", "-m", binary)
+	if err != nil || !result.Success {
+		return "", true
+	}
+	for _, line := range strings.Split(result.Output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == "mod" {
+			return fields[2], true
+		}
+	}
+	return "", true
+}
+
+// cargoInstalledVersion reads 'cargo install --list', whose package lines look like
+// "ripgrep v14.1.0:"
+func cargoInstalledVersion(src ecosystemSource) (string, bool) {
+	result, err := system.RunCommand("cargo", "install", "--list")
+	if err != nil || !result.Success {
+		return "", false
+	}
+	for _, line := range strings.Split(result.Output, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(line), ":"))
+		if len(fields) >= 2 && fields[0] == src.Package {
+			return fields[1], true
+		}
+	}
+	return "", false
+}
+
+// npmInstalledVersion reads the globally installed packages from 'npm ls -g --json'
+func npmInstalledVersion(src ecosystemSource) (string, bool) {
+	result, err := system.RunCommand("npm", "ls", "-g", "--depth=0", "--json")
+	if err != nil && result == nil {
+		return "", false
+	}
+
+	// npm ls exits non-zero for problems such as extraneous packages but still lists them
+	var listing struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(result.Output), &listing); err != nil {
+		return "", false
+	}
+	dependency, installed := listing.Dependencies[src.Package]
+	return dependency.Version, installed
+}
+
+// pipxInstalledVersion reads 'pipx list --short', which prints "black 24.1.0" lines
+func pipxInstalledVersion(src ecosystemSource) (string, bool) {
+	result, err := system.RunCommand("pipx", "list", "--short")
+	if err != nil || !result.Success {
+		return "", false
+	}
+	return findListedVersion(result.Output, src.Package)
+}
+
+// uvInstalledVersion reads 'uv tool list', which prints "black v24.1.0" lines followed
+// by "- black" lines for the tool's executables
+func uvInstalledVersion(src ecosystemSource) (string, bool) {
+	result, err := system.RunCommand("uv", "tool", "list")
+	if err != nil || !result.Success {
+		return "", false
+	}
+	return findListedVersion(result.Output, src.Package)
+}
+
+// findListedVersion finds the "<name> <version>" line of a package in a listing.
+// Python package names compare case-insensitively with - and _ treated alike.
+func findListedVersion(output, pkg string) (string, bool) {
+	normalize := func(name string) string {
+		return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && normalize(fields[0]) == normalize(pkg) {
+			return fields[1], true
+		}
+	}
+	return "", false
+}
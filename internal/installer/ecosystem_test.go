@@ -0,0 +1,265 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeEcosystem puts a shell script named command first on PATH. The script appends its
+// arguments to a log file, whose path is returned, and runs body.
+func fakeEcosystem(t *testing.T, command, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake package managers are shell scripts")
+	}
+
+	binDir := filepath.Join(t.TempDir(), "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(binDir, command+".log")
+	script := "#!/bin/sh\necho \"$*\" >> " + logPath + "\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, command), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+// loggedCalls returns the argument lines a fake package manager logged
+func loggedCalls(t *testing.T, logPath string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestParseEcosystemSource(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    ecosystemSource
+		command string
+		wantErr bool
+	}{
+		{
+			source:  "go:golang.org/x/tools/gopls@latest",
+			want:    ecosystemSource{Ecosystem: "go", Package: "golang.org/x/tools/gopls", Version: "latest"},
+			command: "go install golang.org/x/tools/gopls@latest",
+		},
+		{
+			source:  "go:github.com/go-delve/delve/cmd/dlv",
+			want:    ecosystemSource{Ecosystem: "go", Package: "github.com/go-delve/delve/cmd/dlv"},
+			command: "go install github.com/go-delve/delve/cmd/dlv@latest",
+		},
+		{
+			source:  "cargo:ripgrep@14.1.0",
+			want:    ecosystemSource{Ecosystem: "cargo", Package: "ripgrep", Version: "14.1.0"},
+			command: "cargo install ripgrep --version 14.1.0",
+		},
+		{
+			source:  "npm:@scope/cli",
+			want:    ecosystemSource{Ecosystem: "npm", Package: "@scope/cli"},
+			command: "npm install -g @scope/cli",
+		},
+		{
+			source:  "npm:@scope/cli@2.0.0",
+			want:    ecosystemSource{Ecosystem: "npm", Package: "@scope/cli", Version: "2.0.0"},
+			command: "npm install -g @scope/cli@2.0.0",
+		},
+		{
+			source:  "pipx:black@24.1.0",
+			want:    ecosystemSource{Ecosystem: "pipx", Package: "black", Version: "24.1.0"},
+			command: "pipx install --force black==24.1.0",
+		},
+		{
+			source:  "uv:ruff",
+			want:    ecosystemSource{Ecosystem: "uv", Package: "ruff"},
+			command: "uv tool install ruff",
+		},
+		{source: "cargo:", wantErr: true},
+		{source: "pipx:black@", wantErr: true},
+		{source: "npm:bad package", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if !isEcosystemSource(tt.source) {
+				t.Fatalf("Expected %q to be an ecosystem source", tt.source)
+			}
+			got, err := parseEcosystemSource(tt.source)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, *got)
+			}
+			if got.Command() != tt.command {
+				t.Errorf("Expected command %q, got %q", tt.command, got.Command())
+			}
+		})
+	}
+
+	for _, source := range []string{"https://example.com/tool.tar.gz", "github:owner/repo", "curl -fsSL https://example.com/install.sh | sh", "gem:rails"} {
+		if isEcosystemSource(source) {
+			t.Errorf("Expected %q not to be an ecosystem source", source)
+		}
+	}
+}
+
+func TestGoBinaryName(t *testing.T) {
+	tests := map[string]string{
+		"golang.org/x/tools/gopls":                   "gopls",
+		"github.com/go-delve/delve/cmd/dlv":          "dlv",
+		"github.com/golangci/golangci-lint/v2":       "golangci-lint",
+		"github.com/golangci/golangci-lint/cmd/v2ok": "v2ok",
+	}
+	for pkg, expected := range tests {
+		if got := goBinaryName(pkg); got != expected {
+			t.Errorf("goBinaryName(%q) = %q, expected %q", pkg, got, expected)
+		}
+	}
+}
+
+func TestEcosystemIsInstalled(t *testing.T) {
+	fakeEcosystem(t, "cargo", `printf 'ripgrep v14.1.0:\n    rg\nfd-find v9.0.0:\n    fd\n'`)
+	fakeEcosystem(t, "npm", `echo '{"dependencies":{"@scope/cli":{"version":"2.0.0"}}}'; exit 1`)
+	fakeEcosystem(t, "pipx", `echo 'black 24.1.0'`)
+	fakeEcosystem(t, "uv", `printf 'ruff v0.5.0\n- ruff\n'`)
+
+	tests := []struct {
+		source    string
+		installed bool
+	}{
+		{"cargo:ripgrep", true},
+		{"cargo:ripgrep@14.1.0", true},
+		{"cargo:ripgrep@13.0.0", false},
+		{"cargo:rg", false},
+		{"npm:@scope/cli", true},
+		{"npm:@scope/cli@latest", true},
+		{"npm:@scope/cli@1.0.0", false},
+		{"npm:other", false},
+		{"pipx:Black", true},
+		{"pipx:black@24.1.0", true},
+		{"uv:ruff@0.5.0", true},
+		{"uv:black", false},
+	}
+	for _, tt := range tests {
+		src, err := parseEcosystemSource(tt.source)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q: %v", tt.source, err)
+		}
+		if got := src.IsInstalled(); got != tt.installed {
+			t.Errorf("%s: expected installed=%v, got %v", tt.source, tt.installed, got)
+		}
+	}
+}
+
+func TestGoIsInstalled(t *testing.T) {
+	gobin := t.TempDir()
+	t.Setenv("GOBIN", gobin)
+	fakeEcosystem(t, "go", `printf '%s: go1.23.6\n\tpath\tgolang.org/x/tools/gopls\n\tmod\tgolang.org/x/tools/gopls\tv0.16.1\th1:abc=\n' "$3"`)
+
+	src, _ := parseEcosystemSource("go:golang.org/x/tools/gopls@v0.16.1")
+	if src.IsInstalled() {
+		t.Error("Expected gopls not to be installed before its binary exists")
+	}
+
+	if err := os.WriteFile(filepath.Join(gobin, "gopls"), []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !src.IsInstalled() {
+		t.Error("Expected gopls v0.16.1 to be installed")
+	}
+	pinned, _ := parseEcosystemSource("go:golang.org/x/tools/gopls@v0.17.0")
+	if pinned.IsInstalled() {
+		t.Error("Expected a different pinned version not to count as installed")
+	}
+}
+
+func TestInstallFromSourceEcosystem(t *testing.T) {
+	writeSettings(t, sourcesSettings(nil))
+	logPath := fakeEcosystem(t, "pipx", "exit 0")
+
+	if err := InstallFromSource("black", "pipx:black@24.1.0"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	calls := loggedCalls(t, logPath)
+	if len(calls) != 1 || calls[0] != "install --force black==24.1.0" {
+		t.Errorf("Expected one pinned pipx install, got %v", calls)
+	}
+
+	failing := fakeEcosystem(t, "cargo", "echo 'error: could not find `nope` in registry'; exit 101")
+	err := InstallFromSource("nope", "cargo:nope")
+	if err == nil || !strings.Contains(err.Error(), "could not find") {
+		t.Errorf("Expected the cargo error to be reported, got %v", err)
+	}
+	if calls := loggedCalls(t, failing); len(calls) != 1 || calls[0] != "install nope" {
+		t.Errorf("Expected one cargo install, got %v", calls)
+	}
+}
+
+func TestInstallFromSourceMissingEcosystem(t *testing.T) {
+	writeSettings(t, sourcesSettings(nil))
+	t.Setenv("PATH", t.TempDir())
+
+	err := InstallFromSource("ruff", "uv:ruff")
+	if err == nil || !strings.Contains(err.Error(), "uv is not installed") {
+		t.Errorf("Expected a missing uv error, got %v", err)
+	}
+}
+
+func TestIsAvailableAndInstallPlanForEcosystemSources(t *testing.T) {
+	writeSettings(t, sourcesSettings(map[string]string{
+		"ripgrep": "cargo:ripgrep@14.1.0",
+		"fd":      "cargo:fd-find",
+	}))
+	logPath := fakeEcosystem(t, "cargo", `[ "$2" = "--list" ] && printf 'fd-find v9.0.0:\n    fd\n'; exit 0`)
+
+	if !IsAvailable("fd", nil) {
+		t.Error("Expected fd to be available through cargo")
+	}
+	if IsAvailable("ripgrep", nil) {
+		t.Error("Expected ripgrep not to be available")
+	}
+	if plan := InstallPlan("ripgrep"); plan != "cargo install ripgrep --version 14.1.0" {
+		t.Errorf("Unexpected install plan %q", plan)
+	}
+
+	// Dry-run planning and availability only list packages, they never install
+	for _, call := range loggedCalls(t, logPath) {
+		if call != "install --list" {
+			t.Errorf("Unexpected cargo call %q", call)
+		}
+	}
+}
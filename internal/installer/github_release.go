@@ -0,0 +1,489 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/manifest"
+	"github.com/0xjuanma/anvil/internal/network"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+)
+
+const (
+	githubReleasePrefix  = "github:"
+	latestReleaseTag     = "latest"
+	releaseLookupTimeout = 30 * time.Second
+	releasesPerPage      = 100
+)
+
+// Names used for each OS and architecture in release asset names
+var (
+	assetOSNames = map[string][]string{
+		"darwin":  {"darwin", "macos", "mac", "osx", "apple"},
+		"linux":   {"linux"},
+		"windows": {"windows", "win", "win32", "win64"},
+	}
+	assetArchNames = map[string][]string{
+		"amd64": {"amd64", "x64"},
+		"arm64": {"arm64", "aarch64"},
+		"386":   {"386", "i386", "i686", "x86"},
+		"arm":   {"arm", "armv6", "armv7", "armhf"},
+	}
+	// Installable extensions for each OS, most preferred first
+	assetExtensions = map[string][]string{
+		"darwin": {constants.ExtDMG, constants.ExtPKG, constants.ExtZIP, constants.ExtTarGz, constants.ExtTarXz, constants.ExtTarZst, constants.ExtTarBz2},
+		"linux":  {constants.ExtTarGz, constants.ExtTarXz, constants.ExtTarZst, constants.ExtTarBz2, constants.ExtAppImage, constants.ExtDEB, constants.ExtRPM, constants.ExtZIP},
+	}
+	// Extensions that only exist on one OS identify assets without an OS in their name
+	assetExtensionOS = map[string]string{
+		constants.ExtDMG:      "darwin",
+		constants.ExtPKG:      "darwin",
+		constants.ExtDEB:      "linux",
+		constants.ExtRPM:      "linux",
+		constants.ExtAppImage: "linux",
+	}
+	assetNameSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// githubReleaseSource is a parsed "github:owner/repo[@tag][#patterns]" source
+type githubReleaseSource struct {
+	Owner    string
+	Repo     string
+	Tag      string         // Exact tag or glob; empty for the latest release
+	Patterns []assetPattern // Asset patterns, tried in order; empty to pick automatically
+}
+
+// assetPattern is an asset name glob, optionally restricted to an OS and architecture
+// with an "os/arch=" prefix. {os} and {arch} in the glob match any common spelling of
+// the current OS and architecture.
+type assetPattern struct {
+	OS   string
+	Arch string
+	Glob string
+}
+
+// githubRelease is the part of a GitHub release API response anvil uses
+type githubRelease struct {
+	TagName    string        `json:"tag_name"`
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []githubAsset `json:"assets"`
+}
+
+// githubAsset is a file attached to a GitHub release
+type githubAsset struct {
+	Name               string `json:"name"`
+	URL                string `json:"url"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// releaseClient looks up releases with the GitHub REST API
+type releaseClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// isGitHubReleaseSource checks if the source refers to a GitHub release
+func isGitHubReleaseSource(source string) bool {
+	return strings.HasPrefix(strings.TrimSpace(source), githubReleasePrefix)
+}
+
+// parseGitHubReleaseSource parses "github:owner/repo[@tag][#[os/arch=]glob,...]". The tag
+// may be a glob such as "v1.*"; asset patterns are comma separated.
+func parseGitHubReleaseSource(source string) (*githubReleaseSource, error) {
+	spec := strings.TrimPrefix(strings.TrimSpace(source), githubReleasePrefix)
+	spec, patterns, _ := strings.Cut(spec, "#")
+	location, tag, _ := strings.Cut(spec, "@")
+
+	owner, repo, ok := strings.Cut(location, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("invalid GitHub release source %q (expected github:owner/repo[@tag][#asset-pattern])", source)
+	}
+	if tag == latestReleaseTag {
+		tag = ""
+	}
+	if _, err := path.Match(tag, ""); err != nil {
+		return nil, fmt.Errorf("invalid tag pattern %q in %q: %w", tag, source, err)
+	}
+
+	parsed := &githubReleaseSource{Owner: owner, Repo: repo, Tag: tag}
+	for _, entry := range strings.Split(patterns, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern := assetPattern{Glob: entry}
+		if platform, glob, hasPlatform := strings.Cut(entry, "="); hasPlatform {
+			pattern.OS, pattern.Arch, _ = strings.Cut(platform, "/")
+			pattern.Glob = glob
+		}
+		if _, err := path.Match(pattern.Glob, ""); err != nil || pattern.Glob == "" {
+			return nil, fmt.Errorf("invalid asset pattern %q in %q", entry, source)
+		}
+		parsed.Patterns = append(parsed.Patterns, pattern)
+	}
+	return parsed, nil
+}
+
+// installFromGitHubRelease resolves the release asset for this OS and architecture,
+// downloads it and installs it like any other downloaded source
+func installFromGitHubRelease(appName, source string) error {
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Resolving %s release", appName))
+	spinner.Start()
+
+	releaseSource, err := parseGitHubReleaseSource(source)
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Invalid source for %s", appName))
+		return err
+	}
+
+	httpClient, err := network.Client()
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Failed to resolve %s release", appName))
+		return err
+	}
+	client := newReleaseClient(httpClient, githubAPIURL(), githubToken())
+	ctx, cancel := context.WithTimeout(context.Background(), releaseLookupTimeout)
+	defer cancel()
+
+	release, asset, err := client.resolveAsset(ctx, releaseSource, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Failed to resolve %s release", appName))
+		return fmt.Errorf("failed to resolve release for %s: %w", appName, err)
+	}
+	spinner.Success(fmt.Sprintf("Found %s %s (%s)", appName, release.TagName, asset.Name))
+
+	spinner = charm.NewDotsSpinner(fmt.Sprintf("Downloading %s", asset.Name))
+	spinner.Start()
+	downloadURL, headers := client.assetDownload(asset)
+	download, err := downloadSource(downloadURL, appName, asset.Name, headers)
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Failed to download %s", appName))
+		return fmt.Errorf("failed to download %s: %w", appName, err)
+	}
+	spinner.Success(fmt.Sprintf("Downloaded %s", asset.Name))
+
+	record := manifest.New(appName, source)
+	record.URL = asset.BrowserDownloadURL
+	record.Version = release.TagName
+	download.recordValidators(record)
+	return installRecorded(record, func() error {
+		return installDownloadedSource(download.Path, appName)
+	})
+}
+
+// githubAPIURL returns the GitHub API base URL, which GITHUB_API_URL overrides for
+// GitHub Enterprise servers
+func githubAPIURL() string {
+	if apiURL := os.Getenv(constants.GitHubAPIURLEnv); apiURL != "" {
+		return strings.TrimRight(apiURL, "/")
+	}
+	return constants.GitHubAPIURL
+}
+
+// githubToken returns the token configured in the github section, if any
+func githubToken() string {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return ""
+	}
+	return cfg.GitHub.AccessToken()
+}
+
+// newReleaseClient creates a release client for the API at baseURL. httpClient should
+// carry the network settings, see network.Client.
+func newReleaseClient(httpClient *http.Client, baseURL, token string) *releaseClient {
+	return &releaseClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// resolveAsset finds the release matching the source's tag and the asset to install on goos/goarch
+func (c *releaseClient) resolveAsset(ctx context.Context, source *githubReleaseSource, goos, goarch string) (*githubRelease, *githubAsset, error) {
+	release, err := c.findRelease(ctx, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	asset, err := selectAsset(release.Assets, source.Patterns, goos, goarch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s: %w", source.Owner+"/"+source.Repo, release.TagName, err)
+	}
+	return release, asset, nil
+}
+
+// findRelease returns the latest release, the release with an exact tag, or the newest
+// published release whose tag matches a glob
+func (c *releaseClient) findRelease(ctx context.Context, source *githubReleaseSource) (*githubRelease, error) {
+	repoPath := fmt.Sprintf("/repos/%s/%s/releases", url.PathEscape(source.Owner), url.PathEscape(source.Repo))
+
+	if source.Tag == "" || !strings.ContainsAny(source.Tag, "*?[") {
+		endpoint := repoPath + "/latest"
+		if source.Tag != "" {
+			endpoint = repoPath + "/tags/" + url.PathEscape(source.Tag)
+		}
+		var release githubRelease
+		if err := c.get(ctx, endpoint, &release); err != nil {
+			return nil, err
+		}
+		return &release, nil
+	}
+
+	var releases []githubRelease
+	if err := c.get(ctx, fmt.Sprintf("%s?per_page=%d", repoPath, releasesPerPage), &releases); err != nil {
+		return nil, err
+	}
+	// Releases are listed newest first
+	for i := range releases {
+		if releases[i].Draft || releases[i].Prerelease {
+			continue
+		}
+		if matched, _ := path.Match(source.Tag, releases[i].TagName); matched {
+			return &releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no release of %s/%s has a tag matching %q", source.Owner, source.Repo, source.Tag)
+}
+
+// get requests an API endpoint and decodes the JSON response into v
+func (c *releaseClient) get(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "anvil-cli/1.0")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query GitHub releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("release not found (%s); private repositories need a token in the github section of settings.yaml", endpoint)
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("GitHub API refused the request (HTTP %d); configure a token to raise the rate limit", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read GitHub response: %w", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse GitHub response: %w", err)
+	}
+	return nil
+}
+
+// assetDownload returns the URL and headers to download an asset with. With a token the
+// asset is fetched through the API, which also works for private repositories.
+func (c *releaseClient) assetDownload(asset *githubAsset) (string, map[string]string) {
+	if c.token == "" || asset.URL == "" {
+		return asset.BrowserDownloadURL, nil
+	}
+	return asset.URL, map[string]string{
+		"Accept":        "application/octet-stream",
+		"Authorization": "Bearer " + c.token,
+	}
+}
+
+// selectAsset picks the asset to install on goos/goarch: the best match of the first
+// applicable pattern that matches anything, or without patterns the installable asset
+// built for this platform
+func selectAsset(assets []githubAsset, patterns []assetPattern, goos, goarch string) (*githubAsset, error) {
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("release has no assets")
+	}
+
+	if len(patterns) > 0 {
+		for _, pattern := range patterns {
+			if (pattern.OS != "" && pattern.OS != goos) || (pattern.Arch != "" && pattern.Arch != goarch) {
+				continue
+			}
+			var best *githubAsset
+			bestRank := 0
+			for i := range assets {
+				if !pattern.matches(assets[i].Name, goos, goarch) {
+					continue
+				}
+				if rank := extensionRank(assets[i].Name, goos); best == nil || rank < bestRank {
+					best, bestRank = &assets[i], rank
+				}
+			}
+			if best != nil {
+				return best, nil
+			}
+		}
+		return nil, fmt.Errorf("no asset matches the configured patterns for %s/%s (assets: %s)", goos, goarch, assetNames(assets))
+	}
+
+	var best *githubAsset
+	bestScore := 0
+	for i := range assets {
+		score, ok := platformScore(assets[i].Name, goos, goarch)
+		if ok && (best == nil || score < bestScore) {
+			best, bestScore = &assets[i], score
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no installable asset for %s/%s (assets: %s); add an asset pattern to the source", goos, goarch, assetNames(assets))
+	}
+	return best, nil
+}
+
+// matches reports whether an asset name matches the pattern's glob, case-insensitively
+func (p assetPattern) matches(name, goos, goarch string) bool {
+	globs := []string{strings.ToLower(p.Glob)}
+	globs = expandPlaceholder(globs, "{os}", append([]string{goos}, assetOSNames[goos]...))
+	globs = expandPlaceholder(globs, "{arch}", append([]string{goarch}, archSpellings(goarch)...))
+
+	for _, glob := range globs {
+		if matched, _ := path.Match(glob, strings.ToLower(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// expandPlaceholder replaces a placeholder in each glob with every one of its values
+func expandPlaceholder(globs []string, placeholder string, values []string) []string {
+	var expanded []string
+	for _, glob := range globs {
+		if !strings.Contains(glob, placeholder) {
+			expanded = append(expanded, glob)
+			continue
+		}
+		for _, value := range values {
+			expanded = append(expanded, strings.ReplaceAll(glob, placeholder, value))
+		}
+	}
+	return expanded
+}
+
+// archSpellings returns the names an architecture appears as in asset names, including
+// the x86_64 spellings that assetTokens normalizes
+func archSpellings(goarch string) []string {
+	spellings := assetArchNames[goarch]
+	if goarch == "amd64" {
+		spellings = append(append([]string{}, spellings...), "x86_64", "x86-64")
+	}
+	return spellings
+}
+
+// platformScore rates an asset for goos/goarch, lower being better: assets built for the
+// exact architecture beat universal ones, then the preferred extension wins. Assets for
+// another OS or architecture, or that cannot be installed, are rejected.
+func platformScore(name, goos, goarch string) (int, bool) {
+	rank := extensionRank(name, goos)
+	extensions := assetExtensions[goos]
+	if rank >= len(extensions) {
+		return 0, false
+	}
+
+	tokens := assetTokens(name)
+	if !hasAnyToken(tokens, assetOSNames[goos]) {
+		if assetExtensionOS[extensions[rank]] != goos {
+			return 0, false
+		}
+	}
+	for otherOS, names := range assetOSNames {
+		if otherOS != goos && hasAnyToken(tokens, names) && !hasAnyToken(tokens, assetOSNames[goos]) {
+			return 0, false
+		}
+	}
+
+	archScore := 1
+	if hasAnyToken(tokens, assetArchNames[goarch]) {
+		archScore = 0
+	} else {
+		for otherArch, names := range assetArchNames {
+			if otherArch != goarch && hasAnyToken(tokens, names) {
+				return 0, false
+			}
+		}
+	}
+	return archScore*len(extensions) + rank, true
+}
+
+// extensionRank returns the position of the name's extension in the OS preference list,
+// or the list length when it is not installable there
+func extensionRank(name, goos string) int {
+	lowerName := strings.ToLower(name)
+	extensions := assetExtensions[goos]
+	for i, ext := range extensions {
+		if strings.HasSuffix(lowerName, strings.ToLower(ext)) {
+			return i
+		}
+	}
+	return len(extensions)
+}
+
+// assetTokens splits an asset name into lowercase words, treating x86_64 as amd64
+func assetTokens(name string) map[string]bool {
+	lowerName := strings.ToLower(name)
+	lowerName = strings.NewReplacer("x86_64", "amd64", "x86-64", "amd64").Replace(lowerName)
+
+	tokens := make(map[string]bool)
+	for _, token := range assetNameSeparators.Split(lowerName, -1) {
+		if token != "" {
+			tokens[token] = true
+		}
+	}
+	return tokens
+}
+
+// hasAnyToken reports whether any of the names is one of the tokens
+func hasAnyToken(tokens map[string]bool, names []string) bool {
+	for _, name := range names {
+		if tokens[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// assetNames lists asset names for error messages
+func assetNames(assets []githubAsset) string {
+	names := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		names = append(names, asset.Name)
+	}
+	return strings.Join(names, ", ")
+}
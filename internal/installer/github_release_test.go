@@ -0,0 +1,282 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// releaseAPIStub serves a small set of releases the way the GitHub API does
+func releaseAPIStub(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	// Asset URLs point back at the server, whose address is known once it has started
+	var serverURL string
+	asset := func(name string) githubAsset {
+		return githubAsset{
+			Name:               name,
+			URL:                serverURL + "/repos/acme/tool/releases/assets/" + name,
+			BrowserDownloadURL: serverURL + "/download/" + name,
+		}
+	}
+	releases := func() []githubRelease {
+		return []githubRelease{
+			{TagName: "v2.0.0-rc.1", Prerelease: true, Assets: []githubAsset{asset("tool_2.0.0-rc.1_linux_amd64.tar.gz")}},
+			{TagName: "v1.5.0", Assets: []githubAsset{
+				asset("tool_1.5.0_darwin_arm64.zip"),
+				asset("tool_1.5.0_linux_x86_64.tar.gz"),
+				asset("checksums.txt"),
+			}},
+			{TagName: "v1.4.0", Assets: []githubAsset{asset("tool_1.4.0_linux_amd64.tar.gz")}},
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/tool/releases", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(releases())
+	})
+	mux.HandleFunc("/repos/acme/tool/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(releases()[1])
+	})
+	mux.HandleFunc("/repos/acme/tool/releases/tags/", func(w http.ResponseWriter, r *http.Request) {
+		tag := strings.TrimPrefix(r.URL.Path, "/repos/acme/tool/releases/tags/")
+		for _, release := range releases() {
+			if release.TagName == tag {
+				json.NewEncoder(w).Encode(release)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/repos/acme/tool/releases/assets/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token || r.Header.Get("Accept") != "application/octet-stream" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("asset from api"))
+	})
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("asset from browser url"))
+	})
+
+	server := httptest.NewServer(mux)
+	serverURL = server.URL
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseGitHubReleaseSource(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected *githubReleaseSource
+		wantErr  bool
+	}{
+		{"github:acme/tool", &githubReleaseSource{Owner: "acme", Repo: "tool"}, false},
+		{"github:acme/tool@latest", &githubReleaseSource{Owner: "acme", Repo: "tool"}, false},
+		{"github:acme/tool@v1.*", &githubReleaseSource{Owner: "acme", Repo: "tool", Tag: "v1.*"}, false},
+		{
+			"github:acme/tool@v1.5.0#darwin=*-universal.dmg, linux/arm64=*_{os}_{arch}.tar.gz,*.zip",
+			&githubReleaseSource{Owner: "acme", Repo: "tool", Tag: "v1.5.0", Patterns: []assetPattern{
+				{OS: "darwin", Glob: "*-universal.dmg"},
+				{OS: "linux", Arch: "arm64", Glob: "*_{os}_{arch}.tar.gz"},
+				{Glob: "*.zip"},
+			}},
+			false,
+		},
+		{"github:acme", nil, true},
+		{"github:acme/tool/extra", nil, true},
+		{"github:acme/tool#[", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseGitHubReleaseSource(tt.source)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseGitHubReleaseSource(%q) expected an error", tt.source)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseGitHubReleaseSource(%q) unexpected error: %v", tt.source, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseGitHubReleaseSource(%q) = %+v, expected %+v", tt.source, got, tt.expected)
+		}
+	}
+}
+
+func TestSelectAssetAutomatically(t *testing.T) {
+	assets := []githubAsset{
+		{Name: "tool_1.0.0_checksums.txt"},
+		{Name: "tool_1.0.0_windows_amd64.zip"},
+		{Name: "tool_1.0.0_linux_arm64.tar.gz"},
+		{Name: "tool_1.0.0_linux_x86_64.tar.gz"},
+		{Name: "tool_1.0.0_amd64.deb"},
+		{Name: "tool_1.0.0_darwin_all.zip"},
+		{Name: "Tool-1.0.0-arm64.dmg"},
+	}
+
+	tests := []struct {
+		goos, goarch string
+		expected     string
+	}{
+		{"linux", "amd64", "tool_1.0.0_linux_x86_64.tar.gz"},
+		{"linux", "arm64", "tool_1.0.0_linux_arm64.tar.gz"},
+		{"darwin", "arm64", "Tool-1.0.0-arm64.dmg"},
+		{"darwin", "amd64", "tool_1.0.0_darwin_all.zip"},
+	}
+
+	for _, tt := range tests {
+		got, err := selectAsset(assets, nil, tt.goos, tt.goarch)
+		if err != nil {
+			t.Errorf("selectAsset(%s/%s) unexpected error: %v", tt.goos, tt.goarch, err)
+			continue
+		}
+		if got.Name != tt.expected {
+			t.Errorf("selectAsset(%s/%s) = %s, expected %s", tt.goos, tt.goarch, got.Name, tt.expected)
+		}
+	}
+
+	if _, err := selectAsset([]githubAsset{{Name: "tool_windows_amd64.zip"}}, nil, "linux", "amd64"); err == nil {
+		t.Error("Expected an error when no asset is built for the platform")
+	}
+}
+
+func TestSelectAssetWithPatterns(t *testing.T) {
+	assets := []githubAsset{
+		{Name: "tool-macos-universal.dmg"},
+		{Name: "tool-macos-universal.zip"},
+		{Name: "tool-Linux-aarch64.tar.gz"},
+		{Name: "tool-Linux-x86_64.tar.gz"},
+	}
+	patterns := []assetPattern{
+		{OS: "darwin", Glob: "tool-macos-universal.*"},
+		{OS: "linux", Glob: "tool-{os}-{arch}.tar.gz"},
+	}
+
+	tests := []struct {
+		goos, goarch string
+		expected     string
+	}{
+		{"darwin", "arm64", "tool-macos-universal.dmg"},
+		{"linux", "amd64", "tool-Linux-x86_64.tar.gz"},
+		{"linux", "arm64", "tool-Linux-aarch64.tar.gz"},
+	}
+
+	for _, tt := range tests {
+		got, err := selectAsset(assets, patterns, tt.goos, tt.goarch)
+		if err != nil {
+			t.Errorf("selectAsset(%s/%s) unexpected error: %v", tt.goos, tt.goarch, err)
+			continue
+		}
+		if got.Name != tt.expected {
+			t.Errorf("selectAsset(%s/%s) = %s, expected %s", tt.goos, tt.goarch, got.Name, tt.expected)
+		}
+	}
+
+	if _, err := selectAsset(assets, []assetPattern{{Glob: "*.deb"}}, "linux", "amd64"); err == nil {
+		t.Error("Expected an error when no asset matches the patterns")
+	}
+}
+
+func TestReleaseClientResolveAsset(t *testing.T) {
+	server := releaseAPIStub(t, "")
+	client := newReleaseClient(server.Client(), server.URL, "")
+
+	tests := []struct {
+		source      string
+		expectedTag string
+		wantErr     bool
+	}{
+		{"github:acme/tool", "v1.5.0", false},
+		{"github:acme/tool@v1.4.0", "v1.4.0", false},
+		{"github:acme/tool@v2.*", "", true}, // only a prerelease matches
+		{"github:acme/tool@v1.*", "v1.5.0", false},
+		{"github:acme/tool@v9.0.0", "", true},
+	}
+
+	for _, tt := range tests {
+		source, err := parseGitHubReleaseSource(tt.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release, asset, err := client.resolveAsset(context.Background(), source, "linux", "amd64")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("resolveAsset(%q) expected an error", tt.source)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("resolveAsset(%q) unexpected error: %v", tt.source, err)
+			continue
+		}
+		if release.TagName != tt.expectedTag || !strings.Contains(asset.Name, "linux") {
+			t.Errorf("resolveAsset(%q) = %s %s, expected tag %s", tt.source, release.TagName, asset.Name, tt.expectedTag)
+		}
+	}
+}
+
+func TestReleaseAssetDownload(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := releaseAPIStub(t, "secret")
+	source, err := parseGitHubReleaseSource("github:acme/tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		token    string
+		expected string
+	}{
+		{"", "asset from browser url"},
+		{"secret", "asset from api"},
+	}
+
+	for _, tt := range tests {
+		client := newReleaseClient(server.Client(), server.URL, tt.token)
+		_, asset, err := client.resolveAsset(context.Background(), source, "darwin", "arm64")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		downloadURL, headers := client.assetDownload(asset)
+		download, err := downloadSource(downloadURL, "tool", asset.Name, headers)
+		if err != nil {
+			t.Fatalf("Download with token %q failed: %v", tt.token, err)
+		}
+		filePath := download.Path
+		if !strings.HasSuffix(filePath, "tool_1.5.0_darwin_arm64.zip") {
+			t.Errorf("Expected the asset name to be kept, got %s", filePath)
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, content)
+		}
+	}
+}
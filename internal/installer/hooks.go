@@ -0,0 +1,74 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+	"github.com/0xjuanma/palantir"
+)
+
+// RunPostInstallHooks runs the post-install commands configured for an app, in order.
+// It stops at the first failing command.
+func RunPostInstallHooks(appName string) error {
+	hooks, err := postInstallHooks(appName)
+	if err != nil {
+		return fmt.Errorf("failed to load post-install hooks: %w", err)
+	}
+
+	for i, hook := range hooks {
+		spinner := charm.NewLineSpinner(fmt.Sprintf("Running post-install hook %d/%d for %s", i+1, len(hooks), appName))
+		spinner.Start()
+
+		cmd := exec.Command("sh", "-c", hook)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			spinner.Error(fmt.Sprintf("Post-install hook failed for %s", appName))
+			return fmt.Errorf("post-install hook '%s' failed: %w", hook, err)
+		}
+		spinner.Success(fmt.Sprintf("Post-install hook %d/%d completed for %s", i+1, len(hooks), appName))
+	}
+
+	return nil
+}
+
+// PrintPostInstallHooksPlan lists the post-install hooks an install would run, for dry runs
+func PrintPostInstallHooksPlan(output palantir.OutputHandler, appName string) {
+	hooks, err := postInstallHooks(appName)
+	if err != nil {
+		return
+	}
+	for i, hook := range hooks {
+		output.PrintInfo("  Would run post-install hook %d/%d for %s: %s", i+1, len(hooks), appName, hook)
+	}
+}
+
+// postInstallHooks returns the post-install commands configured for an app
+func postInstallHooks(appName string) ([]string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Hooks.PostInstall[appName], nil
+}
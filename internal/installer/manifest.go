@@ -0,0 +1,153 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/0xjuanma/anvil/internal/manifest"
+	"github.com/0xjuanma/palantir"
+)
+
+// Manifests being recorded by source installs in progress, keyed by app name. Concurrent
+// group installs record different apps, so each install only touches its own entry.
+var (
+	recordingsMu sync.Mutex
+	recordings   = make(map[string]*manifest.Manifest)
+)
+
+// versionInFileName matches a version such as 1.2.3 or v2.0 in a downloaded file name
+var versionInFileName = regexp.MustCompile(`v?\d+(\.\d+)+`)
+
+// startRecording starts recording what an install of record.App creates
+func startRecording(record *manifest.Manifest) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	recordings[record.App] = record
+}
+
+// stopRecording stops recording an app's install and returns the manifest, if any
+func stopRecording(appName string) *manifest.Manifest {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	record := recordings[appName]
+	delete(recordings, appName)
+	return record
+}
+
+// recordFile records a file or directory created by an app's install
+func recordFile(appName, path string) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	if record := recordings[appName]; record != nil {
+		record.AddFile(path)
+	}
+}
+
+// recordSymlink records a link created by an app's install
+func recordSymlink(appName, path string) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	if record := recordings[appName]; record != nil {
+		record.AddSymlink(path)
+	}
+}
+
+// installRecorded runs a source install while recording what it creates. On success the
+// manifest is saved, and files from the previous install that were not recreated are
+// removed, so a reinstall replaces the old version cleanly.
+func installRecorded(record *manifest.Manifest, install func() error) error {
+	if record.Version == "" {
+		record.Version = versionInFileName.FindString(filepath.Base(record.URL))
+	}
+
+	previous, err := manifest.Load(record.App)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		palantir.GetGlobalOutputHandler().PrintWarning("Ignoring unreadable manifest of %s: %v", record.App, err)
+	}
+
+	startRecording(record)
+	err = install()
+	record = stopRecording(record.App)
+	if err != nil {
+		return err
+	}
+
+	if previous != nil {
+		if err := previous.RemovePaths(previous.StalePaths(record)); err != nil {
+			palantir.GetGlobalOutputHandler().PrintWarning("Could not remove files of the previous %s install: %v", record.App, err)
+		}
+	}
+	if err := record.Save(); err != nil {
+		return fmt.Errorf("installed %s but could not save its manifest: %w", record.App, err)
+	}
+	return nil
+}
+
+// removeIfRecorded removes a path about to be recreated when the app's previous install
+// recorded it, so files the new version dropped do not linger in merged copies
+func removeIfRecorded(appName, path string) error {
+	previous, err := manifest.Load(appName)
+	if err != nil {
+		return nil
+	}
+	for _, file := range previous.Files {
+		if file == path {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to remove previous %s: %w", path, err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// recordedSymlink reports whether the app's previous install recorded a link at path
+func recordedSymlink(appName, path string) bool {
+	previous, err := manifest.Load(appName)
+	if err != nil {
+		return false
+	}
+	for _, link := range previous.Symlinks {
+		if link == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Uninstall removes an app installed from a source using its manifest, then the manifest
+func Uninstall(appName string) error {
+	record, err := manifest.Load(appName)
+	if err != nil {
+		return err
+	}
+	if err := record.RemoveInstalled(); err != nil {
+		return err
+	}
+	for _, file := range record.Files {
+		if isDesktopEntry(file) {
+			refreshDesktopDatabase(filepath.Dir(file))
+		}
+	}
+	return manifest.Delete(appName)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/manifest"
+)
+
+func TestInstallRecordedUpgradeAndUninstall(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fixtures are Linux executables")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	appDir := filepath.Join(home, ".local", "opt", "tool")
+	binDir := filepath.Join(home, ".local", "bin")
+	install := func(version string, executables ...string) error {
+		files := make(map[string][]byte)
+		for _, name := range executables {
+			files["tool-"+version+"/"+name] = fakeELF
+		}
+		extractDir := extractedFixture(t, files)
+		record := manifest.New("tool", "https://example.com/tool")
+		record.URL = fmt.Sprintf("https://example.com/tool-%s-linux-amd64.tar.gz", version)
+		return installRecorded(record, func() error {
+			return installToPrefix(extractDir, "tool")
+		})
+	}
+
+	if err := install("1.0.0", "tool", "tool-legacy"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	record, err := manifest.Load("tool")
+	if err != nil {
+		t.Fatalf("Expected a manifest after the install: %v", err)
+	}
+	if record.Version != "1.0.0" || len(record.Files) != 1 || record.Files[0] != appDir || len(record.Symlinks) != 2 {
+		t.Errorf("Unexpected manifest: %+v", record)
+	}
+
+	// The new version no longer ships tool-legacy, so its link is removed
+	if err := install("2.0.0", "tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(binDir, "tool-legacy")); !os.IsNotExist(err) {
+		t.Error("Expected the stale tool-legacy link to be removed")
+	}
+	assertLinked(t, binDir, "tool", filepath.Join(appDir, "tool"))
+	if record, _ := manifest.Load("tool"); record == nil || record.Version != "2.0.0" || len(record.Symlinks) != 1 {
+		t.Errorf("Expected the manifest to describe the new version, got %+v", record)
+	}
+
+	// A failed install keeps the previous manifest
+	failing := manifest.New("tool", "https://example.com/tool")
+	if err := installRecorded(failing, func() error { return fmt.Errorf("download failed") }); err == nil {
+		t.Fatal("Expected the install error to be returned")
+	}
+	if record, _ := manifest.Load("tool"); record == nil || record.Version != "2.0.0" {
+		t.Errorf("Expected the previous manifest to be kept, got %+v", record)
+	}
+
+	if err := Uninstall("tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, path := range []string{appDir, filepath.Join(binDir, "tool"), manifest.Path("tool")} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+}
+
+func TestRecordingWithoutInstallInProgress(t *testing.T) {
+	// Installs outside installRecorded, such as brew fallbacks, record nothing
+	recordFile("untracked", "/tmp/untracked")
+	recordSymlink("untracked", "/tmp/untracked-link")
+	if record := stopRecording("untracked"); record != nil {
+		t.Errorf("Expected no recording, got %+v", record)
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/0xjuanma/anvil/internal/manifest"
+	"github.com/0xjuanma/anvil/internal/network"
+)
+
+// UpdateCheck is the result of checking an app installed from a source for a newer version
+type UpdateCheck struct {
+	App       string
+	Source    string // Source in settings.yaml the app would be reinstalled from
+	Installed string // Installed version, or the install time when the version is unknown
+	Latest    string // Newer release, or what changed upstream, when outdated
+	Outdated  bool
+	Err       error // Why the check could not tell whether the app is outdated
+}
+
+// CheckForUpdates checks the given apps, or every app with an install manifest, for newer
+// versions of their source. Release sources are compared by tag; URL sources with a
+// conditional request against the validators recorded at install time.
+func CheckForUpdates(apps []string) ([]UpdateCheck, error) {
+	records, err := manifest.List()
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]UpdateCheck, 0, len(records))
+	if len(apps) == 0 {
+		for _, record := range records {
+			checks = append(checks, UpdateCheck{App: record.App})
+		}
+	} else {
+		for _, app := range apps {
+			checks = append(checks, UpdateCheck{App: app})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(check *UpdateCheck) {
+			defer wg.Done()
+			record, err := manifest.Load(check.App)
+			if errors.Is(err, os.ErrNotExist) {
+				check.Err = fmt.Errorf("no install manifest; only apps anvil installed from URL or GitHub release sources can be checked")
+				return
+			}
+			if err != nil {
+				check.Err = err
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), releaseLookupTimeout)
+			defer cancel()
+			*check = checkForUpdate(ctx, record)
+		}(&checks[i])
+	}
+	wg.Wait()
+	return checks, nil
+}
+
+// checkForUpdate checks a single installed app against its source in settings
+func checkForUpdate(ctx context.Context, record *manifest.Manifest) UpdateCheck {
+	check := UpdateCheck{App: record.App, Installed: record.Version}
+	if check.Installed == "" {
+		check.Installed = "installed " + record.InstalledAt
+	}
+
+	source, exists, err := SourceURL(record.App)
+	switch {
+	case err != nil:
+		check.Err = err
+		return check
+	case !exists:
+		check.Err = fmt.Errorf("no longer has a source in settings.yaml")
+		return check
+	}
+	check.Source = source
+
+	if source != record.Source {
+		check.Outdated, check.Latest = true, "source changed"
+		return check
+	}
+
+	if isGitHubReleaseSource(source) {
+		check.Latest, check.Err = latestRelease(ctx, source)
+		check.Outdated = check.Err == nil && check.Latest != record.Version
+	} else {
+		check.Latest, check.Err = checkSourceURL(ctx, record)
+		check.Outdated = check.Err == nil && check.Latest != ""
+	}
+	if !check.Outdated {
+		check.Latest = ""
+	}
+	return check
+}
+
+// latestRelease returns the tag of the release a GitHub release source resolves to now
+func latestRelease(ctx context.Context, source string) (string, error) {
+	releaseSource, err := parseGitHubReleaseSource(source)
+	if err != nil {
+		return "", err
+	}
+	httpClient, err := network.Client()
+	if err != nil {
+		return "", err
+	}
+
+	client := newReleaseClient(httpClient, githubAPIURL(), githubToken())
+	release, err := client.findRelease(ctx, releaseSource)
+	if err != nil {
+		return "", err
+	}
+	return release.TagName, nil
+}
+
+// checkSourceURL asks the server whether the file downloaded at install time changed,
+// with a conditional HEAD request, or GET for servers that refuse HEAD. It returns what
+// changed, or "" when the file is unchanged.
+func checkSourceURL(ctx context.Context, record *manifest.Manifest) (string, error) {
+	if record.ETag == "" && record.LastModified == "" && record.ContentLength == 0 {
+		return "", fmt.Errorf("no ETag, Last-Modified or size was recorded at install time; reinstall with 'anvil install %s --upgrade' to track it", record.App)
+	}
+
+	client, _, headers, err := sourceNetwork(record.App)
+	if err != nil {
+		return "", err
+	}
+	if record.URL != record.Source {
+		headers = nil // Downloaded from a mirror, which never gets the source's headers
+	}
+
+	resp, err := conditionalRequest(ctx, client, http.MethodHead, record, headers)
+	if err == nil && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		resp, err = conditionalRequest(ctx, client, http.MethodGet, record, headers)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %w", record.URL, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return "", nil
+	case http.StatusOK:
+		return compareValidators(record, resp)
+	default:
+		return "", fmt.Errorf("failed to check %s: HTTP error %d: %s", record.URL, resp.StatusCode, resp.Status)
+	}
+}
+
+// conditionalRequest requests the recorded URL with the recorded validators, so servers
+// answer 304 Not Modified when the file is unchanged. The body is never read.
+func conditionalRequest(ctx context.Context, client *http.Client, method string, record *manifest.Manifest, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, record.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "anvil-cli/1.0")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if record.ETag != "" {
+		req.Header.Set("If-None-Match", record.ETag)
+	}
+	if record.LastModified != "" {
+		req.Header.Set("If-Modified-Since", record.LastModified)
+	}
+
+	resp, err := scopedClient(client, headers).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// compareValidators compares a full response with the validators recorded at install
+// time, for servers that ignore conditional requests. It returns what changed, or ""
+// when the file is unchanged.
+func compareValidators(record *manifest.Manifest, resp *http.Response) (string, error) {
+	changed := ""
+	switch etag, modified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"); {
+	case record.ETag != "" && etag != "":
+		if weakETag(etag) != weakETag(record.ETag) {
+			changed = "new upload"
+		}
+	case record.LastModified != "" && modified != "":
+		installed, installedErr := http.ParseTime(record.LastModified)
+		current, currentErr := http.ParseTime(modified)
+		if installedErr != nil || currentErr != nil {
+			return "", fmt.Errorf("cannot compare Last-Modified '%s' with '%s'", record.LastModified, modified)
+		}
+		if current.After(installed) {
+			changed = "modified " + current.UTC().Format("2006-01-02")
+		}
+	case record.ContentLength > 0 && resp.ContentLength > 0:
+		if resp.ContentLength != record.ContentLength {
+			changed = fmt.Sprintf("size changed from %d to %d bytes", record.ContentLength, resp.ContentLength)
+		}
+	default:
+		return "", fmt.Errorf("%s sent no ETag, Last-Modified or size to compare with", record.URL)
+	}
+
+	// A version in the redirected file name says more than what changed
+	if changed != "" && resp.Request != nil {
+		if version := versionInFileName.FindString(path.Base(resp.Request.URL.Path)); version != "" && version != record.Version {
+			changed = version
+		}
+	}
+	return changed, nil
+}
+
+// weakETag returns an ETag without its weak validator prefix, so W/"x" and "x" compare equal
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
@@ -0,0 +1,257 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/manifest"
+)
+
+// upstream simulates a download server whose file can be replaced during a test
+type upstream struct {
+	mu           sync.Mutex
+	body         string
+	etag         string
+	lastModified time.Time
+	conditional  bool   // Whether If-None-Match is answered with 304 Not Modified
+	refuseHead   bool   // Whether HEAD requests are refused
+	redirectTo   string // Path every other download path redirects to
+	releaseTag   string // Tag of the latest GitHub release
+	methods      []string
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/releases/latest"):
+		w.Write([]byte(`{"tag_name": "` + u.releaseTag + `"}`))
+		return
+	case u.redirectTo != "" && r.URL.Path != u.redirectTo:
+		http.Redirect(w, r, u.redirectTo, http.StatusFound)
+		return
+	}
+
+	u.methods = append(u.methods, r.Method)
+	if r.Method == http.MethodHead && u.refuseHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if u.etag != "" {
+		w.Header().Set("ETag", u.etag)
+	}
+	if !u.lastModified.IsZero() {
+		w.Header().Set("Last-Modified", u.lastModified.UTC().Format(http.TimeFormat))
+	}
+	if u.conditional && u.etag != "" && r.Header.Get("If-None-Match") == u.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(u.body)))
+	if r.Method == http.MethodGet {
+		w.Write([]byte(u.body))
+	}
+}
+
+// update replaces the file served upstream
+func (u *upstream) update(fn func(u *upstream)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	fn(u)
+	u.methods = nil
+}
+
+// installFromUpstream downloads an app's source like an install does and saves its manifest
+func installFromUpstream(t *testing.T, appName, sourceURL string) *manifest.Manifest {
+	t.Helper()
+	download, err := downloadURLSource(sourceURL, appName)
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	record := manifest.New(appName, sourceURL)
+	record.URL = download.URL
+	download.recordValidators(record)
+	if err := record.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+// checkOne checks a single app and fails the test if the check could not tell
+func checkOne(t *testing.T, appName string) UpdateCheck {
+	t.Helper()
+	checks, err := CheckForUpdates([]string{appName})
+	if err != nil || len(checks) != 1 {
+		t.Fatalf("Expected one check, got %v (%v)", checks, err)
+	}
+	if checks[0].Err != nil {
+		t.Fatalf("Unexpected check error: %v", checks[0].Err)
+	}
+	return checks[0]
+}
+
+func TestCheckForUpdatesURLSources(t *testing.T) {
+	t.Run("conditional request detects a new ETag", func(t *testing.T) {
+		server := &upstream{body: "v1", etag: `"v1"`, conditional: true}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+		sourceURL := ts.URL + "/tool.tar.gz"
+		writeSettings(t, sourcesSettings(map[string]string{"tool": sourceURL}))
+
+		record := installFromUpstream(t, "tool", sourceURL)
+		if record.ETag != `"v1"` || record.ContentLength != 2 {
+			t.Errorf("Expected the ETag and size to be recorded, got %+v", record)
+		}
+
+		server.update(func(u *upstream) {})
+		if check := checkOne(t, "tool"); check.Outdated {
+			t.Errorf("Expected tool to be up to date, got %+v", check)
+		}
+		if strings.Join(server.methods, ",") != http.MethodHead {
+			t.Errorf("Expected a single HEAD request, got %v", server.methods)
+		}
+
+		server.update(func(u *upstream) { u.body, u.etag = "v2!", `"v2"` })
+		check := checkOne(t, "tool")
+		if !check.Outdated || check.Latest != "new upload" || check.Source != sourceURL {
+			t.Errorf("Expected a new upload to be detected, got %+v", check)
+		}
+	})
+
+	t.Run("last modified is compared when conditionals are ignored", func(t *testing.T) {
+		installed := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+		server := &upstream{body: "v1", lastModified: installed}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+		sourceURL := ts.URL + "/tool"
+		writeSettings(t, sourcesSettings(map[string]string{"tool": sourceURL}))
+		installFromUpstream(t, "tool", sourceURL)
+
+		if check := checkOne(t, "tool"); check.Outdated {
+			t.Errorf("Expected tool to be up to date, got %+v", check)
+		}
+
+		server.update(func(u *upstream) { u.lastModified = installed.Add(48 * time.Hour) })
+		if check := checkOne(t, "tool"); !check.Outdated || check.Latest != "modified 2026-09-03" {
+			t.Errorf("Expected a newer modification to be detected, got %+v", check)
+		}
+	})
+
+	t.Run("servers refusing HEAD get a GET and sizes are compared", func(t *testing.T) {
+		server := &upstream{body: "v1", refuseHead: true}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+		sourceURL := ts.URL + "/tool"
+		writeSettings(t, sourcesSettings(map[string]string{"tool": sourceURL}))
+		installFromUpstream(t, "tool", sourceURL)
+
+		server.update(func(u *upstream) { u.body = "v1.1" })
+		check := checkOne(t, "tool")
+		if !check.Outdated || !strings.Contains(check.Latest, "size changed from 2 to 4 bytes") {
+			t.Errorf("Expected a size change to be detected, got %+v", check)
+		}
+		if strings.Join(server.methods, ",") != "HEAD,GET" {
+			t.Errorf("Expected HEAD then GET, got %v", server.methods)
+		}
+	})
+
+	t.Run("version from a redirected file name", func(t *testing.T) {
+		server := &upstream{body: "v1", etag: `"a"`, redirectTo: "/files/tool-1.0.0.tar.gz"}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+		sourceURL := ts.URL + "/latest"
+		writeSettings(t, sourcesSettings(map[string]string{"tool": sourceURL}))
+		installFromUpstream(t, "tool", sourceURL)
+
+		server.update(func(u *upstream) { u.etag, u.redirectTo = `"b"`, "/files/tool-1.1.0.tar.gz" })
+		if check := checkOne(t, "tool"); !check.Outdated || check.Latest != "1.1.0" {
+			t.Errorf("Expected the new version to be reported, got %+v", check)
+		}
+	})
+}
+
+func TestCheckForUpdatesReleaseSources(t *testing.T) {
+	server := &upstream{releaseTag: "v1.0.0"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	t.Setenv("GITHUB_API_URL", ts.URL)
+
+	source := "github:owner/tool"
+	writeSettings(t, sourcesSettings(map[string]string{"tool": source}))
+	record := manifest.New("tool", source)
+	record.Version = "v1.0.0"
+	if err := record.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if check := checkOne(t, "tool"); check.Outdated || check.Installed != "v1.0.0" {
+		t.Errorf("Expected tool to be up to date, got %+v", check)
+	}
+
+	server.update(func(u *upstream) { u.releaseTag = "v1.1.0" })
+	if check := checkOne(t, "tool"); !check.Outdated || check.Latest != "v1.1.0" {
+		t.Errorf("Expected the new release to be reported, got %+v", check)
+	}
+}
+
+func TestCheckForUpdatesUnknown(t *testing.T) {
+	writeSettings(t, sourcesSettings(map[string]string{"moved": "https://example.com/new/tool.tar.gz", "bare": "https://example.com/bare"}))
+	for _, record := range []*manifest.Manifest{
+		{App: "moved", Source: "https://example.com/old/tool.tar.gz"},
+		{App: "bare", Source: "https://example.com/bare", URL: "https://example.com/bare"},
+		{App: "removed", Source: "https://example.com/removed"},
+	} {
+		if err := record.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checks, err := CheckForUpdates(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(map[string]UpdateCheck)
+	for _, check := range checks {
+		results[check.App] = check
+	}
+	if len(checks) != 3 || checks[0].App != "bare" {
+		t.Fatalf("Expected every manifest in order, got %+v", checks)
+	}
+
+	if check := results["moved"]; !check.Outdated || check.Latest != "source changed" {
+		t.Errorf("Expected a changed source to be outdated, got %+v", check)
+	}
+	if check := results["bare"]; check.Err == nil || !strings.Contains(check.Err.Error(), "--upgrade") {
+		t.Errorf("Expected missing validators to be reported, got %+v", check)
+	}
+	if check := results["removed"]; check.Err == nil || !strings.Contains(check.Err.Error(), "no longer has a source") {
+		t.Errorf("Expected a removed source to be reported, got %+v", check)
+	}
+
+	checks, _ = CheckForUpdates([]string{"unknown"})
+	if len(checks) != 1 || checks[0].Err == nil {
+		t.Errorf("Expected an app without a manifest to be reported, got %+v", checks)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+	"github.com/0xjuanma/anvil/internal/utils"
+)
+
+// installOptions returns the install section of settings.yaml, or the defaults
+func installOptions() config.AnvilInstallOptions {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return config.AnvilInstallOptions{}
+	}
+	return cfg.Install
+}
+
+// prepareAppDirectory empties the app's directory under the install prefix and returns it.
+// Names that would resolve outside <prefix>/opt, such as "..", are refused since the
+// directory is removed first.
+func prepareAppDirectory(options config.AnvilInstallOptions, appName string) (string, error) {
+	appDir := options.AppDir(appName)
+	appsDir := filepath.Join(options.PrefixDir(), constants.UserInstallAppsDir)
+	relative, err := filepath.Rel(filepath.Clean(appsDir), filepath.Clean(appDir))
+	if appName == "" || appName == "." || appName == ".." || err != nil ||
+		relative == "." || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid app name '%s': its install directory must be inside %s", appName, appsDir)
+	}
+	if err := os.RemoveAll(appDir); err != nil {
+		return "", fmt.Errorf("failed to remove previous install of %s: %w", appName, err)
+	}
+	if err := utils.EnsureDirectory(appDir); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", appDir, err)
+	}
+	return appDir, nil
+}
+
+// installToPrefix copies extracted archive contents to <prefix>/opt/<app>, marks the
+// executables it ships as executable and links them into <prefix>/bin
+func installToPrefix(extractDir, appName string) error {
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Installing %s", appName))
+	spinner.Start()
+
+	options := installOptions()
+	appDir, err := prepareAppDirectory(options, appName)
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Failed to install %s", appName))
+		return err
+	}
+
+	recordFile(appName, appDir)
+
+	copyOptions := utils.DefaultCopyOptions()
+	copyOptions.PreservePerms = true
+	if err := utils.CopyDirectory(contentRoot(extractDir), appDir, copyOptions); err != nil {
+		spinner.Error(fmt.Sprintf("Failed to install %s", appName))
+		return fmt.Errorf("failed to copy %s to %s: %w", appName, appDir, err)
+	}
+
+	executables := findExecutables(appDir)
+	if len(executables) == 0 {
+		spinner.Warning(fmt.Sprintf("Installed %s to %s, but found no executables to link", appName, appDir))
+		return nil
+	}
+
+	links, err := linkExecutables(appName, appDir, executables, options.BinDir())
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Failed to link %s executables", appName))
+		return err
+	}
+
+	spinner.Success(fmt.Sprintf("Installed %s to %s (%s)", appName, appDir, strings.Join(links, ", ")))
+	return nil
+}
+
+// contentRoot returns the single top-level directory archives usually wrap their
+// contents in, or dir itself
+func contentRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
+
+// findExecutables returns the executables an installed app ships: every file in its
+// bin directory if it has one, otherwise the binaries and executable scripts at its top
+// level. Binaries built for another OS and shared libraries are ignored.
+func findExecutables(appDir string) []string {
+	binDir := filepath.Join(appDir, constants.UserInstallBinDir)
+	if info, err := os.Stat(binDir); err == nil && info.IsDir() {
+		return executableFiles(binDir, true)
+	}
+	return executableFiles(appDir, false)
+}
+
+// executableFiles lists the executables directly inside dir. Scripts without the
+// executable bit count only when inBinDir is set.
+func executableFiles(dir string, inBinDir bool) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var executables []string
+	for _, entry := range entries {
+		filePath := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(filePath)
+		if err != nil || !info.Mode().IsRegular() || isSharedLibrary(entry.Name()) {
+			continue
+		}
+		switch fileExecutableOS(filePath) {
+		case runtime.GOOS:
+		case "any":
+			if !inBinDir && info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		executables = append(executables, filePath)
+	}
+	sort.Strings(executables)
+	return executables
+}
+
+// isSharedLibrary reports whether a file name is a shared library rather than a program
+func isSharedLibrary(name string) bool {
+	return strings.HasSuffix(name, ".so") || strings.Contains(name, ".so.") || strings.HasSuffix(name, ".dylib")
+}
+
+// linkExecutables marks each executable as executable and links it into binDir under
+// its own name, replacing links anvil made for the app before. It records the links for
+// the app and returns their names.
+func linkExecutables(appName, appDir string, executables []string, binDir string) ([]string, error) {
+	if err := utils.EnsureDirectory(binDir); err != nil {
+		return nil, fmt.Errorf("failed to create bin directory: %w", err)
+	}
+
+	links := make([]string, 0, len(executables))
+	for _, executable := range executables {
+		if err := os.Chmod(executable, 0755); err != nil {
+			return links, fmt.Errorf("failed to mark %s as executable: %w", executable, err)
+		}
+
+		name := filepath.Base(executable)
+		linkPath := filepath.Join(binDir, name)
+		if err := removeOwnLink(appName, appDir, linkPath); err != nil {
+			return links, err
+		}
+		if err := os.Symlink(executable, linkPath); err != nil {
+			return links, fmt.Errorf("failed to link %s: %w", linkPath, err)
+		}
+		recordSymlink(appName, linkPath)
+		links = append(links, name)
+	}
+	return links, nil
+}
+
+// removeOwnLink removes whatever is at linkPath when it is a link anvil made for the app:
+// one its previous manifest recorded or one pointing into appDir. Anything else, such as
+// a program the user put in the bin directory, is left alone and reported.
+func removeOwnLink(appName, appDir, linkPath string) error {
+	info, err := os.Lstat(linkPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check %s: %w", linkPath, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 || (!linksInto(linkPath, appDir) && !recordedSymlink(appName, linkPath)) {
+		return fmt.Errorf("refusing to overwrite %s: it was not linked by anvil for %s", linkPath, appName)
+	}
+	if err := os.Remove(linkPath); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", linkPath, err)
+	}
+	return nil
+}
+
+// linksInto reports whether the link at linkPath points inside dir
+func linksInto(linkPath, dir string) bool {
+	target, err := os.Readlink(linkPath)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(linkPath), target)
+	}
+	relative, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(target))
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,277 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/manifest"
+)
+
+// extractedFixture lays out files the way an extracted archive would, with mode 0644
+// unless the name is listed in executable
+func extractedFixture(t *testing.T, files map[string][]byte, executable ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		mode := os.FileMode(0644)
+		for _, exec := range executable {
+			if exec == name {
+				mode = 0755
+			}
+		}
+		if err := os.WriteFile(filePath, content, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// assertLinked checks that binDir/name links to target and that target is executable
+func assertLinked(t *testing.T, binDir, name, target string) {
+	t.Helper()
+	linkPath := filepath.Join(binDir, name)
+	got, err := os.Readlink(linkPath)
+	if err != nil {
+		t.Errorf("Expected %s to be a link: %v", linkPath, err)
+		return
+	}
+	if got != target {
+		t.Errorf("Expected %s to link to %s, got %s", linkPath, target, got)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Errorf("Expected %s to exist: %v", target, err)
+		return
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		t.Errorf("Expected %s to be executable, got %v", target, info.Mode())
+	}
+}
+
+func TestInstallToPrefixLinksBinDirectory(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fixtures are Linux executables")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Archives such as nodejs ship a bin directory next to libraries and data, and
+	// zip archives lose the executable bit
+	extractDir := extractedFixture(t, map[string][]byte{
+		"tool-1.0.0/bin/tool":          fakeELF,
+		"tool-1.0.0/bin/tool-env":      []byte("#!/bin/sh\nexec tool \"$@\"\n"),
+		"tool-1.0.0/lib/libtool.so.1":  fakeELF,
+		"tool-1.0.0/share/doc/LICENSE": []byte("MIT\n"),
+	})
+
+	if err := installToPrefix(extractDir, "tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	appDir := filepath.Join(home, ".local", "opt", "tool")
+	binDir := filepath.Join(home, ".local", "bin")
+	assertLinked(t, binDir, "tool", filepath.Join(appDir, "bin", "tool"))
+	assertLinked(t, binDir, "tool-env", filepath.Join(appDir, "bin", "tool-env"))
+	if _, err := os.Stat(filepath.Join(appDir, "share", "doc", "LICENSE")); err != nil {
+		t.Errorf("Expected the rest of the archive to be installed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(binDir, "libtool.so.1")); !os.IsNotExist(err) {
+		t.Error("Expected libraries not to be linked")
+	}
+}
+
+func TestInstallToPrefixRefusesForeignFiles(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fixtures are Linux executables")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	binDir := filepath.Join(home, ".local", "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	extractDir := extractedFixture(t, map[string][]byte{"tool": fakeELF}, "tool")
+
+	// A program the user installed by hand
+	userTool := filepath.Join(binDir, "tool")
+	if err := os.WriteFile(userTool, []byte("#!/bin/sh\necho mine\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := installToPrefix(extractDir, "tool")
+	if err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("Expected the regular file to be refused, got %v", err)
+	}
+	if content, _ := os.ReadFile(userTool); string(content) != "#!/bin/sh\necho mine\n" {
+		t.Errorf("Expected %s to be left alone, got %q", userTool, content)
+	}
+
+	// A link into another app's directory
+	if err := os.Remove(userTool); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(home, ".local", "opt", "other", "tool")
+	if err := os.Symlink(other, userTool); err != nil {
+		t.Fatal(err)
+	}
+	if err := installToPrefix(extractDir, "tool"); err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("Expected the foreign link to be refused, got %v", err)
+	}
+
+	// A link the previous install recorded is replaced even if it points elsewhere
+	previous := manifest.New("tool", "https://example.com/tool")
+	previous.AddSymlink(userTool)
+	if err := previous.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := installToPrefix(extractDir, "tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertLinked(t, binDir, "tool", filepath.Join(home, ".local", "opt", "tool", "tool"))
+
+	// Links into the app's own directory are replaced on reinstall
+	if err := manifest.Delete("tool"); err != nil {
+		t.Fatal(err)
+	}
+	if err := installToPrefix(extractDir, "tool"); err != nil {
+		t.Fatalf("Unexpected error on reinstall: %v", err)
+	}
+}
+
+func TestPrepareAppDirectoryStaysInsidePrefix(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	options := config.AnvilInstallOptions{}
+	sentinel := filepath.Join(home, ".local", "keep")
+	if err := os.MkdirAll(sentinel, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"", ".", "..", "../keep", "tool/../../keep"} {
+		if _, err := prepareAppDirectory(options, name); err == nil {
+			t.Errorf("Expected %q to be refused", name)
+		}
+	}
+	if _, err := os.Stat(sentinel); err != nil {
+		t.Errorf("Expected directories outside the prefix to survive: %v", err)
+	}
+
+	appDir, err := prepareAppDirectory(options, "tool")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if appDir != filepath.Join(home, ".local", "opt", "tool") {
+		t.Errorf("Expected the app directory under ~/.local/opt, got %s", appDir)
+	}
+}
+
+func TestInstallToPrefixTopLevelExecutables(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fixtures are Linux executables")
+	}
+	// A custom prefix from settings.yaml
+	home := writeSettings(t, "install:\n  prefix: ~/tools\n")
+	prefix := filepath.Join(home, "tools")
+
+	first := extractedFixture(t, map[string][]byte{
+		"tool":      fakeELF,
+		"old-file":  []byte("from the previous version\n"),
+		"README.md": []byte("# tool\n"),
+	})
+	if err := installToPrefix(first, "tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Reinstalling replaces the previous version
+	second := extractedFixture(t, map[string][]byte{
+		"tool":        fakeELF,
+		"tool-daemon": fakeELF,
+		"install.sh":  []byte("#!/bin/sh\n"), // not executable, so not a command
+		"darwin-tool": fakeMachO,
+	})
+	if err := installToPrefix(second, "tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	appDir := filepath.Join(prefix, "opt", "tool")
+	binDir := filepath.Join(prefix, "bin")
+	assertLinked(t, binDir, "tool", filepath.Join(appDir, "tool"))
+	assertLinked(t, binDir, "tool-daemon", filepath.Join(appDir, "tool-daemon"))
+	for _, name := range []string{"install.sh", "darwin-tool"} {
+		if _, err := os.Lstat(filepath.Join(binDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be linked", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(appDir, "old-file")); !os.IsNotExist(err) {
+		t.Error("Expected the previous version to be removed")
+	}
+}
+
+func TestFindExecutables(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fixtures are Linux executables")
+	}
+
+	appDir := extractedFixture(t, map[string][]byte{
+		"tool":          fakeELF,
+		"libtool.so":    fakeELF,
+		"run.sh":        []byte("#!/bin/sh\n"),
+		"configure.sh":  []byte("#!/bin/sh\n"),
+		"tool.darwin":   fakeMachO,
+		"CHANGELOG.md":  []byte("# Changelog\n"),
+		"completions/x": []byte("complete -F _tool tool\n"),
+	}, "run.sh")
+
+	expected := []string{filepath.Join(appDir, "run.sh"), filepath.Join(appDir, "tool")}
+	if got := findExecutables(appDir); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestHandleExtractedContentsLinuxApplicationsMode(t *testing.T) {
+	home := writeSettings(t, "install:\n  mode: applications\n")
+	extractDir := extractedFixture(t, map[string][]byte{
+		"tool-1.0.0/bin/tool":    fakeELF,
+		"tool-1.0.0/README.md":   []byte("tool\n"),
+		"tool-1.0.0/lib/tool.so": fakeELF,
+	}, "tool-1.0.0/bin/tool")
+
+	if err := handleExtractedContentsLinux(extractDir, "tool"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	destDir := filepath.Join(home, ".local", "share", "applications", "tool-1.0.0")
+	if _, err := os.Stat(filepath.Join(destDir, "bin", "tool")); err != nil {
+		t.Errorf("Expected the archive to be copied to %s: %v", destDir, err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".local", "opt", "tool")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be installed under the prefix")
+	}
+	if _, err := os.Lstat(filepath.Join(home, ".local", "bin", "tool")); !os.IsNotExist(err) {
+		t.Error("Expected no executables to be linked")
+	}
+}
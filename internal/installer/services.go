@@ -0,0 +1,47 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"fmt"
+
+	"github.com/0xjuanma/anvil/internal/brew"
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+)
+
+// ApplyServiceAction runs the brew service action configured for an app in the services section.
+// Apps without a service setting, or set to "none", are left untouched.
+func ApplyServiceAction(appName string) error {
+	action, err := config.AppServiceAction(appName)
+	if err != nil {
+		return fmt.Errorf("failed to load service settings: %w", err)
+	}
+	if action == "" || action == brew.ServiceNone {
+		return nil
+	}
+
+	packageName := config.ResolveAppName(appName)
+	spinner := charm.NewLineSpinner(fmt.Sprintf("Running brew services %s %s", action, brew.ServiceName(packageName)))
+	spinner.Start()
+	if err := brew.RunServiceAction(action, packageName); err != nil {
+		spinner.Error(fmt.Sprintf("Failed to %s service for %s", action, appName))
+		return err
+	}
+	spinner.Success(fmt.Sprintf("Service %s for %s completed", action, appName))
+	return nil
+}
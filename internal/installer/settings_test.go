@@ -0,0 +1,49 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSettings makes a temporary directory the test's home, writes content to its
+// .anvil/settings.yaml and returns the home directory
+func writeSettings(t *testing.T, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".anvil"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".anvil", "settings.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+// sourcesSettings returns a sources section mapping apps to their sources
+func sourcesSettings(sources map[string]string) string {
+	var settings strings.Builder
+	settings.WriteString("sources:\n")
+	for app, source := range sources {
+		settings.WriteString("  " + app + ": \"" + source + "\"\n")
+	}
+	return settings.String()
+}
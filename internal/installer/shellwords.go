@@ -0,0 +1,342 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// shellToken is a word or control operator of a POSIX shell command
+type shellToken struct {
+	Word     string // Word with its quoting removed; expansions are kept verbatim
+	Operator string // Control or redirection operator (|, &&, ;, >, ...), empty for words
+	// Substitutions holds the commands of the $(...) and `...` substitutions in the word
+	Substitutions []string
+	// Expands is set when the word has parameter expansions or substitutions outside
+	// single quotes, an unquoted tilde prefix or glob character, or is a VAR=value
+	// assignment before a command, so its value is only known when a shell runs it
+	Expands bool
+}
+
+// shellOperators are the operators recognized outside quotes, longest first
+var shellOperators = []string{"&&", "||", ">>", "<<", "|", "&", ";", "<", ">", "(", ")"}
+
+// shellRedirections are the operators followed by a file name rather than a new command
+var shellRedirections = map[string]bool{">>": true, "<<": true, "<": true, ">": true}
+
+// splitShellWords splits a command into words and operators following POSIX shell
+// quoting: single quotes are literal, double quotes allow $ expansions and backslash
+// escapes, and unquoted backslashes escape the next character. Comments are dropped and
+// newlines become ; operators.
+func splitShellWords(command string) ([]shellToken, error) {
+	var (
+		tokens  []shellToken
+		current shellToken
+		inWord  bool
+		word    strings.Builder
+		// literal is set while the word only has unquoted characters, assignment once
+		// such a word reached NAME=, and commandStart while no command word was seen
+		literal      = true
+		assignment   bool
+		commandStart = true
+	)
+
+	endWord := func() {
+		if inWord {
+			if assignment && commandStart {
+				current.Expands = true
+			} else {
+				commandStart = false
+			}
+			current.Word = word.String()
+			tokens = append(tokens, current)
+		}
+		current, inWord = shellToken{}, false
+		literal, assignment = true, false
+		word.Reset()
+	}
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t':
+			endWord()
+
+		case c == '\n':
+			endWord()
+			tokens = append(tokens, shellToken{Operator: ";"})
+			commandStart = true
+
+		case c == '#' && !inWord:
+			for i < len(command) && command[i] != '\n' {
+				i++
+			}
+			i--
+
+		case c == '\\':
+			literal = false
+			if i+1 < len(command) {
+				i++
+				if command[i] == '\n' { // Line continuation
+					continue
+				}
+				word.WriteByte(command[i])
+			}
+			inWord = true
+
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			inWord, literal = true, false
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+
+		case c == '"':
+			inWord, literal = true, false
+			next, err := readDoubleQuoted(command, i+1, &word, &current)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+
+		case c == '$' || c == '`':
+			inWord, literal = true, false
+			next, err := readExpansion(command, i, &word, &current)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+
+		case (c == '<' || c == '>') && strings.HasPrefix(command[i+1:], "("):
+			// Process substitution such as <(curl ...)
+			inWord, literal = true, false
+			inner, end, err := readParenthesized(command, i+2)
+			if err != nil {
+				return nil, err
+			}
+			word.WriteString(command[i : end+1])
+			current.Substitutions = append(current.Substitutions, inner)
+			current.Expands = true
+			i = end
+
+		default:
+			if op := shellOperatorAt(command, i); op != "" {
+				endWord()
+				tokens = append(tokens, shellToken{Operator: op})
+				if !shellRedirections[op] {
+					commandStart = true
+				}
+				i += len(op) - 1
+				continue
+			}
+			switch {
+			case c == '*' || c == '?' || c == '[':
+				current.Expands = true
+			case c == '~' && !inWord:
+				current.Expands = true
+			case c == '=' && literal && !assignment && isShellName(word.String()):
+				assignment = true
+			}
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	endWord()
+	return tokens, nil
+}
+
+// shellOperatorAt returns the operator starting at index i, if any
+func shellOperatorAt(command string, i int) string {
+	for _, op := range shellOperators {
+		if strings.HasPrefix(command[i:], op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// readDoubleQuoted reads a double-quoted string starting after its opening quote into
+// word and returns the index of the closing quote
+func readDoubleQuoted(command string, start int, word *strings.Builder, token *shellToken) (int, error) {
+	for i := start; i < len(command); i++ {
+		switch c := command[i]; c {
+		case '"':
+			return i, nil
+		case '\\':
+			if i+1 < len(command) && strings.IndexByte("$`\"\\\n", command[i+1]) >= 0 {
+				i++
+				if command[i] != '\n' {
+					word.WriteByte(command[i])
+				}
+				continue
+			}
+			word.WriteByte(c)
+		case '$', '`':
+			next, err := readExpansion(command, i, word, token)
+			if err != nil {
+				return 0, err
+			}
+			i = next
+		default:
+			word.WriteByte(c)
+		}
+	}
+	return 0, fmt.Errorf("unterminated double quote")
+}
+
+// readExpansion copies a $ or ` expansion starting at index i verbatim into word,
+// recording command substitutions, and returns the index of its last character
+func readExpansion(command string, i int, word *strings.Builder, token *shellToken) (int, error) {
+	if command[i] == '`' {
+		end := strings.IndexByte(command[i+1:], '`')
+		if end < 0 {
+			return 0, fmt.Errorf("unterminated backquote")
+		}
+		end += i + 1
+		word.WriteString(command[i : end+1])
+		token.Substitutions = append(token.Substitutions, command[i+1:end])
+		token.Expands = true
+		return end, nil
+	}
+
+	switch {
+	case strings.HasPrefix(command[i:], "$("):
+		inner, end, err := readParenthesized(command, i+2)
+		if err != nil {
+			return 0, err
+		}
+		word.WriteString(command[i : end+1])
+		if !strings.HasPrefix(inner, "(") { // $((...)) is arithmetic, not a command
+			token.Substitutions = append(token.Substitutions, inner)
+		}
+		token.Expands = true
+		return end, nil
+	case strings.HasPrefix(command[i:], "${"):
+		end := strings.IndexByte(command[i:], '}')
+		if end < 0 {
+			return 0, fmt.Errorf("unterminated parameter expansion")
+		}
+		word.WriteString(command[i : i+end+1])
+		token.Expands = true
+		return i + end, nil
+	case i+1 < len(command) && isShellNameChar(command[i+1]), i+1 < len(command) && strings.IndexByte("?#@*!$-", command[i+1]) >= 0:
+		word.WriteByte('$')
+		token.Expands = true
+		return i, nil
+	}
+
+	// A lone $ is literal
+	word.WriteByte('$')
+	return i, nil
+}
+
+// readParenthesized reads up to the parenthesis closing the one before start, skipping
+// quoted text and nested parentheses. It returns the text inside and the closing index.
+func readParenthesized(command string, start int) (string, int, error) {
+	depth := 1
+	for i := start; i < len(command); i++ {
+		switch command[i] {
+		case '\\':
+			i++
+		case '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return "", 0, fmt.Errorf("unterminated single quote")
+			}
+			i += end + 1
+		case '"':
+			for i++; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' {
+					i++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return command[start:i], i, nil
+			}
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated command substitution")
+}
+
+// isShellName reports whether name is a valid shell variable name
+func isShellName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isShellNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isShellNameChar reports whether c can start a shell variable name or is a positional digit
+func isShellNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// splitPipeline splits tokens at | operators into the words of each command. ok is false
+// when the tokens use any other operator.
+func splitPipeline(tokens []shellToken) (stages [][]shellToken, ok bool) {
+	var stage []shellToken
+	for _, token := range tokens {
+		switch token.Operator {
+		case "":
+			stage = append(stage, token)
+		case "|":
+			if len(stage) == 0 {
+				return nil, false
+			}
+			stages = append(stages, stage)
+			stage = nil
+		default:
+			return nil, false
+		}
+	}
+	if len(stage) == 0 {
+		return nil, false
+	}
+	return append(stages, stage), true
+}
+
+// isSimpleCommand reports whether tokens are a single command without operators or
+// expansions, which can run without a shell
+func isSimpleCommand(tokens []shellToken) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	for _, token := range tokens {
+		if token.Operator != "" || token.Expands {
+			return false
+		}
+	}
+	return true
+}
+
+// commandName returns the base name of the program a command word runs
+func commandName(token shellToken) string {
+	return filepath.Base(token.Word)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// tokenStrings renders tokens as words and operators for comparison
+func tokenStrings(tokens []shellToken) []string {
+	var out []string
+	for _, token := range tokens {
+		if token.Operator != "" {
+			out = append(out, "op:"+token.Operator)
+		} else {
+			out = append(out, token.Word)
+		}
+	}
+	return out
+}
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		expected []string
+	}{
+		{"plain words", "brew install --cask  firefox", []string{"brew", "install", "--cask", "firefox"}},
+		{"single quotes are literal", `echo 'a "b" $HOME'`, []string{"echo", `a "b" $HOME`}},
+		{"double quotes keep spaces", `git clone "https://example.com/my repo.git" "$HOME/src"`, []string{"git", "clone", "https://example.com/my repo.git", "$HOME/src"}},
+		{"escapes", `echo a\ b "c\"d" 'e\f'`, []string{"echo", "a b", `c"d`, `e\f`}},
+		{"adjacent quoting joins", `echo ab'c d'"e"`, []string{"echo", "abc de"}},
+		{"empty quoted word", `sh -c 'echo hi' ''`, []string{"sh", "-c", "echo hi", ""}},
+		{"pipeline", "curl -fsSL https://x.sh/install|sh -s -- -y", []string{"curl", "-fsSL", "https://x.sh/install", "op:|", "sh", "-s", "--", "-y"}},
+		{"lists and redirections", "make && make install >/dev/null 2>&1; echo done", []string{"make", "op:&&", "make", "install", "op:>", "/dev/null", "2", "op:>", "op:&", "1", "op:;", "echo", "done"}},
+		{"quoted operators are words", `echo "a | b" 'c && d'`, []string{"echo", "a | b", "c && d"}},
+		{"comments", "echo hi # not this", []string{"echo", "hi"}},
+		{"hash inside a word", "echo a#b", []string{"echo", "a#b"}},
+		{"command substitution", `sh -c "$(curl -fsSL "https://x.sh/install.sh")" "" --unattended`, []string{"sh", "-c", `$(curl -fsSL "https://x.sh/install.sh")`, "", "--unattended"}},
+		{"process substitution", "bash <(curl -fsSL https://x.sh/i.sh)", []string{"bash", "<(curl -fsSL https://x.sh/i.sh)"}},
+		{"nested parentheses", `echo "$(echo $(date) ")")"`, []string{"echo", `$(echo $(date) ")")`}},
+		{"newlines separate commands", "echo a\necho b", []string{"echo", "a", "op:;", "echo", "b"}},
+		{"line continuation", "echo a \\\n  b", []string{"echo", "a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := splitShellWords(tt.command)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := tokenStrings(tokens); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSplitShellWordsExpansions(t *testing.T) {
+	tokens, err := splitShellWords(`echo plain '$HOME' "$HOME" ${PATH} $(date) ` + "`date`" + ` $((1+1)) cost$`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expands := []bool{false, false, false, true, true, true, true, true, false}
+	substitutions := []int{0, 0, 0, 0, 0, 1, 1, 0, 0}
+	if len(tokens) != len(expands) {
+		t.Fatalf("Expected %d tokens, got %q", len(expands), tokenStrings(tokens))
+	}
+	for i, token := range tokens {
+		if token.Expands != expands[i] {
+			t.Errorf("%q: expected Expands=%v", token.Word, expands[i])
+		}
+		if len(token.Substitutions) != substitutions[i] {
+			t.Errorf("%q: expected %d substitution(s), got %q", token.Word, substitutions[i], token.Substitutions)
+		}
+	}
+}
+
+func TestSplitShellWordsShellOnlyWords(t *testing.T) {
+	tests := []struct {
+		command string
+		expands []bool
+	}{
+		{"ls ~/bin ~user a~b '~/x'", []bool{false, true, true, false, false}},
+		{"rm -f *.tmp file? [ab].txt '*' \\*", []bool{false, false, true, true, true, false, false}},
+		{"GOFLAGS=-mod=mod CGO_ENABLED=0 go install example.com/tool@latest", []bool{true, true, false, false, false}},
+		{"echo a=b; X=1 make", []bool{false, false, false, true, false}},
+		{"'X=1' make \"Y\"=2 1X=3", []bool{false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			tokens, err := splitShellWords(tt.command)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(tokens) != len(tt.expands) {
+				t.Fatalf("Expected %d tokens, got %q", len(tt.expands), tokenStrings(tokens))
+			}
+			for i, token := range tokens {
+				if token.Operator == "" && token.Expands != tt.expands[i] {
+					t.Errorf("%q: expected Expands=%v", token.Word, tt.expands[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitShellWordsErrors(t *testing.T) {
+	for _, command := range []string{
+		`echo 'unterminated`,
+		`echo "unterminated`,
+		`sh -c "$(curl https://x.sh"`,
+		"echo `date",
+		"echo ${HOME",
+	} {
+		if _, err := splitShellWords(command); err == nil {
+			t.Errorf("Expected an error for %q", command)
+		}
+	}
+}
+
+func TestIsShellCommand(t *testing.T) {
+	tests := map[string]bool{
+		`sh -c "$(curl -fsSL https://example.com/install.sh)"`: true,
+		"curl -fsSL https://example.com/install.sh | bash":     true,
+		"wget -qO- https://example.com/install.sh | sh":        true,
+		"brew install --cask moom":                             true,
+		"https://example.com/download/moom":                    false,
+		"https://example.com/get?os=mac&arch=arm64":            false,
+		"https://example.com/my app.dmg":                       false,
+		"example.com/tool.tar.gz":                              false,
+		`sh -c 'unterminated`:                                  false,
+	}
+	for source, expected := range tests {
+		if got := isShellCommand(source); got != expected {
+			t.Errorf("isShellCommand(%q) = %v, expected %v", source, got, expected)
+		}
+	}
+}
+
+func TestParseShellCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		// Quoted arguments stay whole instead of being split on spaces
+		{`git clone "https://example.com/my repo.git" '/tmp/a b'`, []string{"git", "clone", "https://example.com/my repo.git", "/tmp/a b"}},
+		{`bash -c 'echo "hello world"'`, []string{"bash", "-c", `echo "hello world"`}},
+		{"curl -fsSL https://example.com/a.tar.gz | tar xz", []string{"sh", "-c", "curl -fsSL https://example.com/a.tar.gz | tar xz"}},
+		{"echo $HOME", []string{"sh", "-c", "echo $HOME"}},
+		// Tilde, glob and assignment words need a shell as well
+		{"~/bin/setup --yes", []string{"sh", "-c", "~/bin/setup --yes"}},
+		{"cp build/*.so /usr/local/lib", []string{"sh", "-c", "cp build/*.so /usr/local/lib"}},
+		{"CGO_ENABLED=0 go install example.com/tool@latest", []string{"sh", "-c", "CGO_ENABLED=0 go install example.com/tool@latest"}},
+		{"go install -ldflags=-s example.com/tool@latest", []string{"go", "install", "-ldflags=-s", "example.com/tool@latest"}},
+	}
+	for _, tt := range tests {
+		cmd, err := parseShellCommand(tt.command)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.command, err)
+		}
+		if !reflect.DeepEqual(cmd.Args, tt.expected) {
+			t.Errorf("%q: expected %q, got %q", tt.command, tt.expected, cmd.Args)
+		}
+	}
+
+	if _, err := parseShellCommand("   "); err == nil {
+		t.Error("Expected an error for an empty command")
+	}
+}
@@ -18,13 +18,22 @@ package installer
 
 import (
 	"fmt"
+	"runtime"
 
 	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/manifest"
 	"github.com/0xjuanma/anvil/internal/terminal/charm"
 )
 
-// InstallFromSource installs an application from a source URL or command
+// InstallFromSource installs an application from a source URL, GitHub release, language
+// package manager or command
 func InstallFromSource(appName, source string) error {
+	if isEcosystemSource(source) {
+		return installFromEcosystem(appName, source)
+	}
+	if isGitHubReleaseSource(source) {
+		return installFromGitHubRelease(appName, source)
+	}
 	// Check if source is a shell command (curl/wget style) or a URL
 	if isShellCommand(source) {
 		return installFromCommand(appName, source)
@@ -38,50 +47,67 @@ func installFromURL(appName, sourceURL string) error {
 	spinner := charm.NewDotsSpinner(fmt.Sprintf("Downloading %s from source", appName))
 	spinner.Start()
 
-	downloadedFile, err := downloadFile(sourceURL, appName)
+	download, err := downloadURLSource(sourceURL, appName)
 	if err != nil {
 		spinner.Error(fmt.Sprintf("Failed to download %s", appName))
 		return fmt.Errorf("failed to download %s: %w", appName, err)
 	}
 	spinner.Success(fmt.Sprintf("Downloaded %s", appName))
 
-	spinner = charm.NewDotsSpinner(fmt.Sprintf("Installing %s", appName))
+	record := manifest.New(appName, sourceURL)
+	record.URL = download.URL
+	download.recordValidators(record)
+	return installRecorded(record, func() error {
+		return installDownloadedSource(download.Path, appName)
+	})
+}
+
+// installDownloadedSource installs a downloaded source file, reporting where it was
+// extracted to when only the automatic installation failed
+func installDownloadedSource(downloadedFile, appName string) error {
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Installing %s", appName))
 	spinner.Start()
 
-		if err := installDownloadedFile(downloadedFile, appName); err != nil {
-			// Check if extraction succeeded but installation failed
-			if extractErr, ok := err.(*ExtractionSucceededError); ok {
-				spinner.Warning("Extraction succeeded, but automatic installation failed")
-				// Provide helpful feedback about where the app was extracted
-				fmt.Printf("\n✓ %s was successfully downloaded and extracted to:\n", appName)
-				fmt.Printf("  %s\n", extractErr.ExtractDir)
-				fmt.Printf("\nPlease manually move the application to your Applications folder.\n\n")
-				// Return error so caller can handle it appropriately (won't fall back to brew)
-				return extractErr
-			}
-			spinner.Error(fmt.Sprintf("Failed to install %s", appName))
-			return fmt.Errorf("failed to install %s: %w", appName, err)
+	if err := installDownloadedFile(downloadedFile, appName); err != nil {
+		// Check if extraction succeeded but installation failed
+		if extractErr, ok := err.(*ExtractionSucceededError); ok {
+			spinner.Warning("Extraction succeeded, but automatic installation failed")
+			// Provide helpful feedback about where the app was extracted
+			fmt.Printf("\n✓ %s was successfully downloaded and extracted to:\n", appName)
+			fmt.Printf("  %s\n", extractErr.ExtractDir)
+			fmt.Printf("\nPlease manually move the application to your Applications folder.\n\n")
+			// Return error so caller can handle it appropriately (won't fall back to brew)
+			return extractErr
 		}
+		spinner.Error(fmt.Sprintf("Failed to install %s", appName))
+		return fmt.Errorf("failed to install %s: %w", appName, err)
+	}
 
 	spinner.Success(fmt.Sprintf("%s installed successfully", appName))
 	return nil
 }
 
 
-// SourceURL returns the source URL for an app if it exists
+// SourceURL returns the source URL for an app on this platform if one is configured.
+// Apps with per-platform sources that do not cover this platform return a *SourceSkipError.
 func SourceURL(appName string) (string, bool, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return "", false, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	if cfg.Sources == nil {
+	source, exists := cfg.Sources[appName]
+	if !exists || source.IsZero() {
 		return "", false, nil
 	}
 
-	sourceURL, exists := cfg.Sources[appName]
-	if !exists || sourceURL == "" {
-		return "", false, nil
+	sourceURL, ok := source.ForPlatform(runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return "", true, &SourceSkipError{
+			AppName:   appName,
+			Platform:  runtime.GOOS + "-" + runtime.GOARCH,
+			Platforms: source.PlatformKeys(),
+		}
 	}
 
 	return sourceURL, true, nil
@@ -25,20 +25,31 @@ import (
 	"github.com/0xjuanma/anvil/internal/terminal/charm"
 )
 
-// isShellCommand checks if the source is a shell command rather than a URL
+// isShellCommand checks if the source is a shell command rather than a URL. Sources of
+// a single word, and those starting with a URL, are downloaded instead.
 func isShellCommand(source string) bool {
-	trimmed := strings.TrimSpace(source)
-	// Check for common shell command patterns
-	return strings.HasPrefix(trimmed, "sh -c") ||
-		strings.HasPrefix(trimmed, "bash -c") ||
-		strings.HasPrefix(trimmed, "curl") ||
-		strings.HasPrefix(trimmed, "wget") ||
-		strings.Contains(trimmed, "$(curl") ||
-		strings.Contains(trimmed, "$(wget")
+	tokens, err := splitShellWords(strings.TrimSpace(source))
+	if err != nil || len(tokens) < 2 || tokens[0].Operator != "" {
+		return false
+	}
+	return !strings.Contains(tokens[0].Word, "://")
 }
 
-// installFromCommand executes a shell command to install an application
+// installFromCommand executes a shell command to install an application. Commands that
+// pipe a downloaded script into a shell fetch the script first so it can be verified.
 func installFromCommand(appName, command string) error {
+	tokens, err := splitShellWords(strings.TrimSpace(command))
+	if err != nil {
+		return fmt.Errorf("invalid command for %s: %w", appName, err)
+	}
+
+	if script := parseRemoteScript(tokens); script != nil {
+		return installFromRemoteScript(appName, script)
+	}
+	if err := checkUnreviewedDownload(appName, tokens); err != nil {
+		return err
+	}
+
 	spinner := charm.NewDotsSpinner(fmt.Sprintf("Installing %s from command", appName))
 	spinner.Start()
 
@@ -61,54 +72,26 @@ func installFromCommand(appName, command string) error {
 	return nil
 }
 
-// parseShellCommand parses a shell command string into an exec.Cmd
+// parseShellCommand parses a shell command string into an exec.Cmd. Single commands run
+// directly with their quoting resolved; pipelines, lists, redirections and expansions
+// run through sh.
 func parseShellCommand(command string) (*exec.Cmd, error) {
 	trimmed := strings.TrimSpace(command)
-
-	// Handle sh -c or bash -c commands
-	if strings.HasPrefix(trimmed, "sh -c") || strings.HasPrefix(trimmed, "bash -c") {
-		shell := "sh"
-		if strings.HasPrefix(trimmed, "bash") {
-			shell = "bash"
-		}
-		cmdStr := extractCommandFromShC(trimmed)
-		return exec.Command(shell, "-c", cmdStr), nil
+	tokens, err := splitShellWords(trimmed)
+	if err != nil {
+		return nil, err
 	}
-
-	// Direct command execution
-	parts := strings.Fields(trimmed)
-	if len(parts) == 0 {
+	if len(tokens) == 0 {
 		return nil, fmt.Errorf("empty command")
 	}
 
-	return exec.Command(parts[0], parts[1:]...), nil
-}
-
-// extractCommandFromShC extracts the command string from "sh -c 'command'" format
-func extractCommandFromShC(fullCommand string) string {
-	// Find the position after "sh -c" or "bash -c"
-	prefix := "sh -c"
-	if strings.HasPrefix(fullCommand, "bash") {
-		prefix = "bash -c"
-	}
-
-	// Find the command part (after the shell and -c)
-	startIdx := len(prefix)
-	for startIdx < len(fullCommand) && (fullCommand[startIdx] == ' ' || fullCommand[startIdx] == '\'') {
-		startIdx++
+	if !isSimpleCommand(tokens) {
+		return exec.Command("sh", "-c", trimmed), nil
 	}
 
-	// Extract the command, handling quotes
-	command := fullCommand[startIdx:]
-	command = strings.TrimSpace(command)
-
-	// Remove surrounding quotes if present
-	if len(command) >= 2 {
-		if (command[0] == '\'' && command[len(command)-1] == '\'') ||
-			(command[0] == '"' && command[len(command)-1] == '"') {
-			command = command[1 : len(command)-1]
-		}
+	args := make([]string, len(tokens))
+	for i, token := range tokens {
+		args[i] = token.Word
 	}
-
-	return command
+	return exec.Command(args[0], args[1:]...), nil
 }
@@ -27,81 +27,197 @@ import (
 	"strings"
 	"time"
 
+	"github.com/0xjuanma/anvil/internal/config"
 	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/manifest"
+	"github.com/0xjuanma/anvil/internal/network"
 	"github.com/0xjuanma/anvil/internal/system"
 	"github.com/0xjuanma/anvil/internal/utils"
 )
 
+// maxRedirects matches the redirect limit of net/http's default policy
+const maxRedirects = 10
+
+// sourceDownload is a file downloaded for an app's source, with the HTTP validators the
+// server sent for it
+type sourceDownload struct {
+	Path          string
+	URL           string // URL that served the file: a mirror or the source itself
+	ETag          string
+	LastModified  string
+	ContentLength int64
+}
+
+// recordValidators stores the download's HTTP validators in a manifest so later checks
+// can tell whether the file changed upstream
+func (d *sourceDownload) recordValidators(record *manifest.Manifest) {
+	record.ETag = d.ETag
+	record.LastModified = d.LastModified
+	record.ContentLength = d.ContentLength
+}
+
 // downloadFile downloads a file from URL to a temporary location
 func downloadFile(fileURL, appName string) (string, error) {
+	download, err := downloadURLSource(fileURL, appName)
+	if err != nil {
+		return "", err
+	}
+	return download.Path, nil
+}
+
+// downloadURLSource downloads the file an app's source URL points at, sending that URL,
+// and only that URL, the headers from source_options
+func downloadURLSource(fileURL, appName string) (*sourceDownload, error) {
+	_, _, sourceHeaders, err := sourceNetwork(appName)
+	if err != nil {
+		return nil, err
+	}
+	return downloadSource(fileURL, appName, getFileNameFromURL(fileURL, appName), sourceHeaders)
+}
+
+// downloadSource downloads a file to the app's downloads directory under the given file
+// name. The mirrors configured in source_options are tried first, in order, then fileURL.
+// The request headers are sent to fileURL only; mirrors get none.
+func downloadSource(fileURL, appName, fileName string, headers map[string]string) (*sourceDownload, error) {
+	client, options, _, err := sourceNetwork(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	homeDir, _ := system.HomeDir()
+	// Organize downloads by app name: ~/Downloads/anvil-downloads/{appName}/
+	downloadsDir := filepath.Join(homeDir, constants.DownloadsDirName, constants.AnvilDownloadsSubdir, appName)
+	if err := utils.EnsureDirectory(downloadsDir); err != nil {
+		return nil, fmt.Errorf("failed to create downloads directory: %w", err)
+	}
+	filePath := filepath.Join(downloadsDir, filepath.Base(fileName))
+
+	candidates := network.CandidateURLs(fileURL, fileName, options.Mirrors)
+	if len(candidates) == 1 {
+		return fetchToFile(client, fileURL, headers, filePath)
+	}
+
+	var failures []string
+	for _, candidate := range candidates {
+		var requestHeaders map[string]string
+		if candidate == fileURL {
+			requestHeaders = headers
+		}
+		download, err := fetchToFile(client, candidate, requestHeaders, filePath)
+		if err == nil {
+			return download, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", candidate, err))
+	}
+	return nil, fmt.Errorf("all %d download URLs failed:\n  %s", len(candidates), strings.Join(failures, "\n  "))
+}
+
+// sourceNetwork returns the HTTP client for an app's downloads along with its
+// source_options and their resolved headers
+func sourceNetwork(appName string) (*http.Client, config.SourceOptions, map[string]string, error) {
+	var options config.SourceOptions
+	var networkOptions config.AnvilNetworkOptions
+	if cfg, err := config.LoadConfig(); err == nil {
+		options, networkOptions = cfg.SourceOptions[appName], cfg.Network
+	}
+
+	headers, err := network.ResolveHeaders(options.Headers)
+	if err != nil {
+		return nil, options, nil, fmt.Errorf("invalid source_options for %s: %w", appName, err)
+	}
+	client, err := network.NewClient(networkOptions)
+	if err != nil {
+		return nil, options, nil, err
+	}
+	return client, options, headers, nil
+}
+
+// fetchToFile downloads fileURL into filePath with the given request headers
+func fetchToFile(client *http.Client, fileURL string, headers map[string]string, filePath string) (*sourceDownload, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "anvil-cli/1.0")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := scopedClient(client, headers).Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to download file: %w", err)
+		return nil, fmt.Errorf("failed to download file: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
 	}
 
-	homeDir, _ := system.HomeDir()
-	// Organize downloads by app name: ~/Downloads/anvil-downloads/{appName}/
-	downloadsDir := filepath.Join(homeDir, constants.DownloadsDirName, constants.AnvilDownloadsSubdir, appName)
-	if err := utils.EnsureDirectory(downloadsDir); err != nil {
-		return "", fmt.Errorf("failed to create downloads directory: %w", err)
-	}
-
-	fileName := getFileNameFromURL(fileURL, appName)
-	filePath := filepath.Join(downloadsDir, fileName)
-
 	file, err := os.Create(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+		return nil, fmt.Errorf("failed to create file: %w", err)
 	}
 	defer file.Close()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	written, err := io.Copy(file, resp.Body)
+	if err != nil {
 		os.Remove(filePath)
-		return "", fmt.Errorf("failed to write file: %w", err)
+		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return filePath, nil
+	return &sourceDownload{
+		Path:          filePath,
+		URL:           fileURL,
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		ContentLength: written,
+	}, nil
 }
 
-// getFileNameFromURL extracts filename from URL or uses app name
+// scopedClient returns client, or a copy of it that drops the given request headers when
+// a redirect leaves the host they were sent to
+func scopedClient(client *http.Client, headers map[string]string) *http.Client {
+	if len(headers) == 0 {
+		return client
+	}
+	scoped := *client
+	scoped.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			for key := range headers {
+				req.Header.Del(key)
+			}
+		}
+		return nil
+	}
+	return &scoped
+}
+
+// getFileNameFromURL extracts filename from URL or uses app name. Names without a known
+// extension are kept as they are; the file type is then detected from the content.
 func getFileNameFromURL(fileURL, appName string) string {
 	parsedURL, err := url.Parse(fileURL)
 	if err == nil && parsedURL.Path != "" {
 		fileName := filepath.Base(parsedURL.Path)
-		if fileName != "" && fileName != "/" {
+		if fileName != "" && fileName != "/" && fileName != "." {
 			return fileName
 		}
 	}
 
-	ext := getExtensionFromURL(fileURL)
-	return fmt.Sprintf("%s%s", appName, ext)
+	return fmt.Sprintf("%s%s", appName, getExtensionFromURL(fileURL))
 }
 
-// getExtensionFromURL tries to detect file extension from URL
+// getExtensionFromURL tries to detect file extension from URL, returning "" when unknown
 func getExtensionFromURL(fileURL string) string {
 	parsedURL, err := url.Parse(fileURL)
-	if err == nil {
-		path := strings.ToLower(parsedURL.Path)
-		for _, ext := range constants.SupportedFileExtensions {
-			if strings.HasSuffix(path, ext) {
-				return ext
-			}
-		}
+	if err != nil {
+		return ""
 	}
-	return constants.ExtDefault
+	return extensionFromName(parsedURL.Path)
 }
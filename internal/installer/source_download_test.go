@@ -0,0 +1,117 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDownloadFileWithSourceOptions(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("Authorization")+r.Header.Get("X-Api-Key"))
+		switch r.URL.Path {
+		case "/broken/tool.tar.gz":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case "/mirror/tool.tar.gz", "/origin/tool.tar.gz":
+			w.Write([]byte("payload " + r.URL.Path))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("mirrors are tried in order without the source headers", func(t *testing.T) {
+		t.Setenv("ANVIL_TEST_TOKEN", "secret")
+		writeSettings(t, "source_options:\n  tool:\n    headers:\n      Authorization: Bearer ${ANVIL_TEST_TOKEN}\n    mirrors:\n      - "+
+			server.URL+"/broken/{file}\n      - "+server.URL+"/mirror/{file}\n")
+		requests = nil
+
+		path, err := downloadFile(server.URL+"/origin/tool.tar.gz", "tool")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != "payload /mirror/tool.tar.gz" {
+			t.Errorf("Expected the file from the second mirror, got %q", data)
+		}
+		expected := []string{"/broken/tool.tar.gz ", "/mirror/tool.tar.gz "}
+		if strings.Join(requests, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected requests %q, got %q", expected, requests)
+		}
+	})
+
+	t.Run("source is used with its headers when every mirror fails", func(t *testing.T) {
+		t.Setenv("ANVIL_TEST_TOKEN", "secret")
+		writeSettings(t, "source_options:\n  tool:\n    headers:\n      Authorization: Bearer ${ANVIL_TEST_TOKEN}\n    mirrors:\n      - "+server.URL+"/broken/{file}\n")
+		requests = nil
+
+		path, err := downloadFile(server.URL+"/origin/tool.tar.gz", "tool")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "payload /origin/tool.tar.gz" {
+			t.Errorf("Expected the file from the source, got %q", data)
+		}
+		expected := []string{"/broken/tool.tar.gz ", "/origin/tool.tar.gz Bearer secret"}
+		if strings.Join(requests, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected requests %q, got %q", expected, requests)
+		}
+	})
+
+	t.Run("headers are dropped on redirects to other hosts", func(t *testing.T) {
+		t.Setenv("ANVIL_TEST_TOKEN", "secret")
+		redirect := httptest.NewServer(http.RedirectHandler(server.URL+"/origin/tool.tar.gz", http.StatusFound))
+		defer redirect.Close()
+		writeSettings(t, "source_options:\n  tool:\n    headers:\n      X-Api-Key: ${ANVIL_TEST_TOKEN}\n")
+		requests = nil
+
+		if _, err := downloadFile(redirect.URL+"/tool.tar.gz", "tool"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(requests) != 1 || requests[0] != "/origin/tool.tar.gz " {
+			t.Errorf("Expected the redirected request without headers, got %q", requests)
+		}
+	})
+
+	t.Run("every failure is reported", func(t *testing.T) {
+		writeSettings(t, "source_options:\n  tool:\n    mirrors:\n      - "+server.URL+"/broken/{file}\n")
+
+		_, err := downloadFile(server.URL+"/missing/tool.tar.gz", "tool")
+		if err == nil || !strings.Contains(err.Error(), "all 2 download URLs failed") || !strings.Contains(err.Error(), "HTTP error 404") {
+			t.Errorf("Expected both failures to be reported, got %v", err)
+		}
+	})
+
+	t.Run("unset header variables stop the download", func(t *testing.T) {
+		os.Unsetenv("ANVIL_TEST_TOKEN")
+		writeSettings(t, "source_options:\n  tool:\n    headers:\n      Authorization: Bearer ${ANVIL_TEST_TOKEN}\n")
+		requests = nil
+
+		_, err := downloadFile(server.URL+"/origin/tool.tar.gz", "tool")
+		if err == nil || !strings.Contains(err.Error(), "ANVIL_TEST_TOKEN") {
+			t.Errorf("Expected an unset variable error, got %v", err)
+		}
+		if len(requests) != 0 {
+			t.Errorf("Expected no requests, got %q", requests)
+		}
+	})
+}
@@ -0,0 +1,355 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+)
+
+// headerSize is how much of a file is read to detect its type; tar headers need 262 bytes
+const headerSize = 512
+
+// Magic bytes of the file types anvil installs
+var (
+	zipMagic     = []byte("PK\x03\x04")
+	gzipMagic    = []byte{0x1f, 0x8b}
+	bzip2Magic   = []byte("BZh")
+	xzMagic      = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	zstdMagic    = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	debMagic     = []byte("!<arch>\ndebian-binary")
+	rpmMagic     = []byte{0xed, 0xab, 0xee, 0xdb}
+	xarMagic     = []byte("xar!") // .pkg installers
+	elfMagic     = []byte{0x7f, 'E', 'L', 'F'}
+	shebangMagic = []byte("#!")
+	machOMagics  = [][]byte{
+		{0xfe, 0xed, 0xfa, 0xce}, {0xfe, 0xed, 0xfa, 0xcf}, // big endian 32/64-bit
+		{0xce, 0xfa, 0xed, 0xfe}, {0xcf, 0xfa, 0xed, 0xfe}, // little endian 32/64-bit
+		{0xca, 0xfe, 0xba, 0xbe}, // universal binary
+	}
+	dmgTrailerMagic = []byte("koly")
+)
+
+// tarExtractFlags are the tar flags that extract each tar archive type
+var tarExtractFlags = map[string][]string{
+	constants.ExtTar:    {"-xf"},
+	constants.ExtTarGz:  {"-xzf"},
+	constants.ExtTarBz2: {"-xjf"},
+	constants.ExtTarXz:  {"-xJf"},
+	constants.ExtTarZst: {"--zstd", "-xf"},
+}
+
+// extensionFromName returns the supported extension a file name ends with, or ""
+func extensionFromName(name string) string {
+	lowerName := strings.ToLower(name)
+	for _, ext := range constants.SupportedFileExtensions {
+		if strings.HasSuffix(lowerName, strings.ToLower(ext)) {
+			return ext
+		}
+	}
+	for alias, ext := range constants.FileExtensionAliases {
+		if strings.HasSuffix(lowerName, alias) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// sourceFileType returns the type of a downloaded file: its supported extension, or
+// constants.FileTypeBinary for executables. Files without a known extension, such as
+// downloads from URLs without one, are identified by their magic bytes.
+func sourceFileType(filePath string) (string, error) {
+	if ext := extensionFromName(filepath.Base(filePath)); ext != "" {
+		return ext, nil
+	}
+	return detectFileType(filePath)
+}
+
+// detectFileType identifies a file by its magic bytes. bzip2, xz and zstd streams are
+// assumed to hold tar archives, as that is how software is distributed with them.
+func detectFileType(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", filepath.Base(filePath), err)
+	}
+	defer file.Close()
+
+	header, err := readHeader(file)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, zipMagic):
+		return constants.ExtZIP, nil
+	case bytes.HasPrefix(header, gzipMagic):
+		if gzipHoldsTar(file) {
+			return constants.ExtTarGz, nil
+		}
+		return constants.ExtGz, nil
+	case bytes.HasPrefix(header, bzip2Magic):
+		return constants.ExtTarBz2, nil
+	case bytes.HasPrefix(header, xzMagic):
+		return constants.ExtTarXz, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		return constants.ExtTarZst, nil
+	case bytes.HasPrefix(header, debMagic):
+		return constants.ExtDEB, nil
+	case bytes.HasPrefix(header, rpmMagic):
+		return constants.ExtRPM, nil
+	case bytes.HasPrefix(header, xarMagic):
+		return constants.ExtPKG, nil
+	case isTarHeader(header):
+		return constants.ExtTar, nil
+	case bytes.HasPrefix(header, elfMagic) && len(header) > 10 && header[8] == 'A' && header[9] == 'I':
+		return constants.ExtAppImage, nil
+	case executableOS(header) != "":
+		return constants.FileTypeBinary, nil
+	case hasDMGTrailer(file):
+		return constants.ExtDMG, nil
+	}
+	return "", fmt.Errorf("unrecognized file type for %s", filepath.Base(filePath))
+}
+
+// readHeader reads up to headerSize bytes from the start of a file
+func readHeader(file *os.File) ([]byte, error) {
+	header := make([]byte, headerSize)
+	n, err := file.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(file.Name()), err)
+	}
+	return header[:n], nil
+}
+
+// isTarHeader reports whether a header is a POSIX or GNU tar header
+func isTarHeader(header []byte) bool {
+	return len(header) >= 262 && string(header[257:262]) == "ustar"
+}
+
+// gzipHoldsTar reports whether a gzip file decompresses to a tar archive
+func gzipHoldsTar(file *os.File) bool {
+	reader, err := gzip.NewReader(io.NewSectionReader(file, 0, 1<<62))
+	if err != nil {
+		return false
+	}
+	defer reader.Close()
+
+	header := make([]byte, headerSize)
+	n, _ := io.ReadFull(reader, header)
+	return isTarHeader(header[:n])
+}
+
+// hasDMGTrailer reports whether a file ends with the trailer of a disk image
+func hasDMGTrailer(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil || info.Size() < headerSize {
+		return false
+	}
+	trailer := make([]byte, len(dmgTrailerMagic))
+	if _, err := file.ReadAt(trailer, info.Size()-headerSize); err != nil {
+		return false
+	}
+	return bytes.Equal(trailer, dmgTrailerMagic)
+}
+
+// executableOS returns "linux" for ELF executables, "darwin" for Mach-O executables,
+// "any" for scripts and "" for anything else
+func executableOS(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, elfMagic):
+		return "linux"
+	case bytes.HasPrefix(header, shebangMagic):
+		return "any"
+	}
+	for _, magic := range machOMagics {
+		if bytes.HasPrefix(header, magic) {
+			return "darwin"
+		}
+	}
+	return ""
+}
+
+// fileExecutableOS returns executableOS for the file at filePath
+func fileExecutableOS(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	header, err := readHeader(file)
+	if err != nil {
+		return ""
+	}
+	return executableOS(header)
+}
+
+// findSingleBinary returns the only executable in an extracted archive, ignoring docs and
+// other data files, or "" when there are none or several (an application directory)
+func findSingleBinary(dir string) string {
+	var binaries []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		switch fileExecutableOS(path) {
+		case "":
+			return nil
+		case "any":
+			// Scripts are only executables when marked as such
+			if info.Mode().Perm()&0111 == 0 {
+				return nil
+			}
+		}
+		binaries = append(binaries, path)
+		return nil
+	})
+
+	if len(binaries) != 1 {
+		return ""
+	}
+	return binaries[0]
+}
+
+// installTarArchive extracts a tar archive of any supported compression and installs its contents
+func installTarArchive(filePath, fileType, appName string) error {
+	extractDir, err := ensureExtractDirectory(filePath, appName)
+	if err != nil {
+		return err
+	}
+
+	args := append(append([]string{}, tarExtractFlags[fileType]...), filePath, "-C", extractDir)
+	if err := runCommandWithSpinner(
+		"Extracting archive",
+		"Failed to extract archive",
+		"tar", args...,
+	); err != nil {
+		return err
+	}
+
+	return handleExtractedContents(extractDir, appName)
+}
+
+// installGz decompresses a gzip file that is not a tar archive, usually a single
+// executable, and installs the result
+func installGz(filePath, appName string) error {
+	extractDir, err := ensureExtractDirectory(filePath, appName)
+	if err != nil {
+		return err
+	}
+
+	source, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(filePath), err)
+	}
+	defer source.Close()
+
+	reader, err := gzip.NewReader(source)
+	if err != nil {
+		return fmt.Errorf("failed to read gzip file: %w", err)
+	}
+	defer reader.Close()
+
+	decompressedPath := filepath.Join(extractDir, appName)
+	destination, err := os.Create(decompressedPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", decompressedPath, err)
+	}
+	if _, err := io.Copy(destination, reader); err != nil {
+		destination.Close()
+		return fmt.Errorf("failed to decompress %s: %w", filepath.Base(filePath), err)
+	}
+	if err := destination.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", decompressedPath, err)
+	}
+
+	fileType, err := detectFileType(decompressedPath)
+	if err != nil {
+		return err
+	}
+	if fileType == constants.ExtGz {
+		return fmt.Errorf("nested gzip files are not supported")
+	}
+	return installFileOfType(decompressedPath, fileType, appName)
+}
+
+// installBinary installs an executable as <prefix>/opt/<app>/<binaryName> and links it
+// into <prefix>/bin
+func installBinary(filePath, appName, binaryName string) error {
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Installing %s", binaryName))
+	spinner.Start()
+
+	if binaryOS := fileExecutableOS(filePath); binaryOS != "any" && binaryOS != runtime.GOOS {
+		spinner.Error(fmt.Sprintf("%s cannot run on this system", binaryName))
+		return fmt.Errorf("%s is a %s executable and cannot run on %s", filepath.Base(filePath), binaryOS, runtime.GOOS)
+	}
+
+	options := installOptions()
+	appDir, err := prepareAppDirectory(options, appName)
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Failed to install %s", binaryName))
+		return err
+	}
+
+	recordFile(appName, appDir)
+
+	destPath := filepath.Join(appDir, binaryName)
+	if err := copyExecutable(filePath, destPath); err != nil {
+		spinner.Error(fmt.Sprintf("Failed to install %s", binaryName))
+		return err
+	}
+	if _, err := linkExecutables(appName, appDir, []string{destPath}, options.BinDir()); err != nil {
+		spinner.Error(fmt.Sprintf("Failed to link %s", binaryName))
+		return err
+	}
+
+	spinner.Success(fmt.Sprintf("Installed %s to %s", binaryName, filepath.Join(options.BinDir(), binaryName)))
+	return nil
+}
+
+// copyExecutable copies a file and marks the copy as executable
+func copyExecutable(src, dst string) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := destination.Close(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return os.Chmod(dst, 0755)
+}
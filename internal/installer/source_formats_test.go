@@ -0,0 +1,330 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/constants"
+)
+
+// fakeELF is enough of an ELF executable for type detection
+var fakeELF = append(append([]byte{}, elfMagic...), bytes.Repeat([]byte{0}, 60)...)
+
+// fakeMachO is enough of a Mach-O executable for type detection
+var fakeMachO = append(append([]byte{}, machOMagics[3]...), bytes.Repeat([]byte{0}, 60)...)
+
+// writeFixture writes a fixture file into dir and returns its path
+func writeFixture(t *testing.T, dir, name string, content []byte, mode os.FileMode) string {
+	t.Helper()
+	filePath := filepath.Join(dir, name)
+	if err := os.WriteFile(filePath, content, mode); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+// tarFixture returns a tar archive holding a README and an executable named binaryName
+func tarFixture(t *testing.T, binaryName string, binary []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	files := []struct {
+		name    string
+		mode    int64
+		content []byte
+	}{
+		{"tool/README.md", 0644, []byte("# tool\n")},
+		{"tool/" + binaryName, 0755, binary},
+	}
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: file.mode, Size: int64(len(file.content)), Format: tar.FormatUSTAR}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write(file.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// gzipFixture compresses content with gzip
+func gzipFixture(t *testing.T, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipFixture returns a zip archive holding an executable named binaryName
+func zipFixture(t *testing.T, binaryName string, binary []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	header := &zip.FileHeader{Name: binaryName, Method: zip.Deflate}
+	header.SetMode(0755)
+	file, err := writer.CreateHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write(binary); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// compressedTarFixture compresses a tar fixture with the tar command, skipping the test
+// when the compressor is not installed
+func compressedTarFixture(t *testing.T, dir, name, compressor, flag string) string {
+	t.Helper()
+	if _, err := exec.LookPath(compressor); err != nil {
+		t.Skipf("%s is not installed", compressor)
+	}
+	srcDir := filepath.Join(dir, "src-"+compressor)
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFixture(t, srcDir, "tool", fakeELF, 0755)
+
+	archivePath := filepath.Join(dir, name)
+	if output, err := exec.Command("tar", flag, "-cf", archivePath, "-C", srcDir, "tool").CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create %s: %v\n%s", name, err, output)
+	}
+	return archivePath
+}
+
+func TestExtensionFromName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"tool_1.0.0_linux_amd64.tar.gz", constants.ExtTarGz},
+		{"tool.TGZ", constants.ExtTarGz},
+		{"tool-x86_64-unknown-linux-musl.tar.xz", constants.ExtTarXz},
+		{"tool.txz", constants.ExtTarXz},
+		{"tool.tar.zst", constants.ExtTarZst},
+		{"tool.tar", constants.ExtTar},
+		{"tool-linux-amd64.gz", constants.ExtGz},
+		{"Tool-x86_64.AppImage", constants.ExtAppImage},
+		{"Tool.dmg", constants.ExtDMG},
+		{"tool-linux-amd64", ""},
+		{"checksums.txt", ""},
+	}
+
+	for _, tt := range tests {
+		if got := extensionFromName(tt.name); got != tt.expected {
+			t.Errorf("extensionFromName(%q) = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestDetectFileType(t *testing.T) {
+	dir := t.TempDir()
+	tarball := tarFixture(t, "tool", fakeELF)
+
+	appImage := append([]byte{}, fakeELF...)
+	copy(appImage[8:], "AI\x02")
+
+	tests := []struct {
+		name     string
+		content  []byte
+		mode     os.FileMode
+		expected string
+	}{
+		{"zip", zipFixture(t, "tool", fakeELF), 0644, constants.ExtZIP},
+		{"targz", gzipFixture(t, tarball), 0644, constants.ExtTarGz},
+		{"gz", gzipFixture(t, fakeELF), 0644, constants.ExtGz},
+		{"tar", tarball, 0644, constants.ExtTar},
+		{"elf", fakeELF, 0755, constants.FileTypeBinary},
+		{"macho", fakeMachO, 0755, constants.FileTypeBinary},
+		{"script", []byte("#!/bin/sh\necho tool\n"), 0755, constants.FileTypeBinary},
+		{"appimage", appImage, 0755, constants.ExtAppImage},
+		{"deb", []byte("!<arch>\ndebian-binary   1342943816  0     0     100644  4         `\n"), 0644, constants.ExtDEB},
+	}
+
+	for _, tt := range tests {
+		filePath := writeFixture(t, dir, tt.name, tt.content, tt.mode)
+		got, err := detectFileType(filePath)
+		if err != nil {
+			t.Errorf("detectFileType(%s) unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("detectFileType(%s) = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+
+	if _, err := detectFileType(writeFixture(t, dir, "notes", []byte("plain text"), 0644)); err == nil {
+		t.Error("Expected an error for an unrecognized file")
+	}
+}
+
+func TestDetectCompressedTarTypes(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		compressor, flag string
+		expected         string
+	}{
+		{"xz", "-J", constants.ExtTarXz},
+		{"zstd", "--zstd", constants.ExtTarZst},
+		{"bzip2", "-j", constants.ExtTarBz2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.compressor, func(t *testing.T) {
+			archivePath := compressedTarFixture(t, dir, "tool-"+tt.compressor, tt.compressor, tt.flag)
+			got, err := sourceFileType(archivePath)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindSingleBinary(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "README.md", []byte("# tool\n"), 0644)
+	writeFixture(t, dir, "install.sh", []byte("#!/bin/sh\n"), 0644) // not executable
+	binary := writeFixture(t, dir, "tool", fakeELF, 0755)
+
+	if got := findSingleBinary(dir); got != binary {
+		t.Errorf("Expected %s, got %q", binary, got)
+	}
+
+	writeFixture(t, dir, "tool-helper", fakeELF, 0755)
+	if got := findSingleBinary(dir); got != "" {
+		t.Errorf("Expected no single binary when there are several, got %q", got)
+	}
+}
+
+func TestInstallSingleBinarySources(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fixtures are Linux executables")
+	}
+
+	tests := []struct {
+		name    string
+		fixture func(t *testing.T, dir string) string
+	}{
+		{"tar.gz", func(t *testing.T, dir string) string {
+			return writeFixture(t, dir, "tool_linux_amd64.tar.gz", gzipFixture(t, tarFixture(t, "tool", fakeELF)), 0644)
+		}},
+		{"tar.xz", func(t *testing.T, dir string) string {
+			return compressedTarFixture(t, dir, "tool-x86_64-unknown-linux-musl.tar.xz", "xz", "-J")
+		}},
+		{"tar.zst", func(t *testing.T, dir string) string {
+			return compressedTarFixture(t, dir, "tool-linux-amd64.tar.zst", "zstd", "--zstd")
+		}},
+		{"zip", func(t *testing.T, dir string) string {
+			if _, err := exec.LookPath("unzip"); err != nil {
+				t.Skip("unzip is not installed")
+			}
+			return writeFixture(t, dir, "tool_linux_amd64.zip", zipFixture(t, "tool", fakeELF), 0644)
+		}},
+		{"gz", func(t *testing.T, dir string) string {
+			return writeFixture(t, dir, "tool-linux-amd64.gz", gzipFixture(t, fakeELF), 0644)
+		}},
+		{"gz without extension", func(t *testing.T, dir string) string {
+			return writeFixture(t, dir, "download", gzipFixture(t, fakeELF), 0644)
+		}},
+		{"binary", func(t *testing.T, dir string) string {
+			return writeFixture(t, dir, "tool-linux-amd64", fakeELF, 0644)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			filePath := tt.fixture(t, t.TempDir())
+			if err := installDownloadedFile(filePath, "tool"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			installed := filepath.Join(home, ".local", "bin", "tool")
+			info, err := os.Stat(installed)
+			if err != nil {
+				t.Fatalf("Expected %s to be installed: %v", installed, err)
+			}
+			if info.Mode().Perm()&0111 == 0 {
+				t.Errorf("Expected %s to be executable, got %v", installed, info.Mode())
+			}
+			content, _ := os.ReadFile(installed)
+			if !bytes.Equal(content, fakeELF) {
+				t.Errorf("Installed binary does not match the fixture")
+			}
+		})
+	}
+}
+
+func TestInstallBinaryRejectsOtherOS(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fixture is a macOS executable")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	filePath := writeFixture(t, t.TempDir(), "tool-darwin-arm64", fakeMachO, 0755)
+	if err := installDownloadedFile(filePath, "tool"); err == nil {
+		t.Fatal("Expected a macOS executable to be rejected on Linux")
+	}
+	if _, err := os.Stat(filepath.Join(home, ".local", "bin", "tool")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be installed")
+	}
+}
+
+func TestGetFileNameFromURLWithoutExtension(t *testing.T) {
+	tests := []struct {
+		url, appName string
+		expected     string
+	}{
+		{"https://example.com/releases/tool-linux-amd64", "tool", "tool-linux-amd64"},
+		{"https://example.com/download/tool.tar.xz?token=abc", "tool", "tool.tar.xz"},
+	}
+
+	for _, tt := range tests {
+		if got := getFileNameFromURL(tt.url, tt.appName); got != tt.expected {
+			t.Errorf("getFileNameFromURL(%q) = %q, expected %q", tt.url, got, tt.expected)
+		}
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/system"
 	"github.com/0xjuanma/anvil/internal/utils"
 )
 
@@ -37,11 +39,47 @@ func (e *ExtractionSucceededError) Error() string {
 	return fmt.Sprintf("extraction succeeded but installation failed: %s", e.Reason)
 }
 
+// SourceSkipError indicates that an app has per-platform sources, but none for this
+// platform. The app is skipped instead of failing or falling back to brew.
+type SourceSkipError struct {
+	AppName   string
+	Platform  string
+	Platforms []string // Platforms the app has sources for
+}
+
+func (e *SourceSkipError) Error() string {
+	return fmt.Sprintf("no source for %s on %s (sources are configured for %s)", e.AppName, e.Platform, strings.Join(e.Platforms, ", "))
+}
+
+// IsSourceSkip reports whether err means the app has no source for this platform
+func IsSourceSkip(err error) bool {
+	_, ok := err.(*SourceSkipError)
+	return ok
+}
+
+// handleExtractedContents installs the extracted contents of an archive for the current OS
+func handleExtractedContents(extractDir, appName string) error {
+	if system.IsMacOS() {
+		err := handleExtractedContentsMacOS(extractDir, appName)
+		// Check if extraction succeeded but installation failed
+		if extractErr, ok := err.(*ExtractionSucceededError); ok {
+			// Return the error but it will be handled gracefully by the caller
+			return extractErr
+		}
+		return err
+	}
+	return handleExtractedContentsLinux(extractDir, appName)
+}
+
 // handleExtractedContentsMacOS handles extracted contents on macOS
 // Returns ExtractionSucceededError if extraction succeeded but moving to Applications failed
 func handleExtractedContentsMacOS(extractDir, appName string) error {
 	appPath := findAppInDirectory(extractDir, appName)
 	if appPath == "" {
+		// Command line tools ship as a single executable instead of an app bundle
+		if findSingleBinary(extractDir) != "" {
+			return installToPrefix(extractDir, appName)
+		}
 		// Extraction succeeded but we can't find the .app
 		return &ExtractionSucceededError{
 			ExtractDir: extractDir,
@@ -63,6 +101,13 @@ func handleExtractedContentsMacOS(extractDir, appName string) error {
 	appNameFromPath := filepath.Base(appPath)
 	destPath := filepath.Join(applicationsDir, appNameFromPath)
 
+	if err := removeIfRecorded(appName, destPath); err != nil {
+		return &ExtractionSucceededError{
+			ExtractDir: extractDir,
+			AppName:    appName,
+			Reason:     err.Error(),
+		}
+	}
 	if err := copyAppToApplications(appPath, destPath); err != nil {
 		// Extraction succeeded but copying to Applications failed
 		return &ExtractionSucceededError{
@@ -71,32 +116,43 @@ func handleExtractedContentsMacOS(extractDir, appName string) error {
 			Reason:     fmt.Sprintf("failed to copy application to Applications: %v", err),
 		}
 	}
+	recordFile(appName, destPath)
 
 	return nil
 }
 
-// handleExtractedContentsLinux handles extracted contents on Linux
+// handleExtractedContentsLinux installs extracted contents under the user install prefix,
+// which needs no root access, or copies them to the applications directory when
+// install.mode is "applications"
 func handleExtractedContentsLinux(extractDir, appName string) error {
-	entries, err := os.ReadDir(extractDir)
-	if err != nil {
-		return fmt.Errorf("failed to read extract directory: %w", err)
+	if installOptions().Mode == config.InstallModeApplications {
+		return copyToLinuxApplications(extractDir, appName)
 	}
+	return installToPrefix(extractDir, appName)
+}
 
-	if len(entries) == 1 && entries[0].IsDir() {
-		appDir := filepath.Join(extractDir, entries[0].Name())
-		destDir, err := ensureLinuxApplicationsDirectory(entries[0].Name())
-		if err != nil {
-			return err
-		}
-		return utils.CopyDirectorySimple(appDir, destDir)
+// copyToLinuxApplications copies extracted contents to ~/.local/share/applications, named
+// after the archive's single top-level directory if it has one, without linking executables
+func copyToLinuxApplications(extractDir, appName string) error {
+	sourceDir, dirName := extractDir, appName
+	if entries, err := os.ReadDir(extractDir); err != nil {
+		return fmt.Errorf("failed to read extract directory: %w", err)
+	} else if len(entries) == 1 && entries[0].IsDir() {
+		sourceDir, dirName = filepath.Join(extractDir, entries[0].Name()), entries[0].Name()
 	}
 
-	destDir, err := ensureLinuxApplicationsDirectory(appName)
+	destDir, err := ensureLinuxApplicationsDirectory(dirName)
 	if err != nil {
 		return err
 	}
-
-	return utils.CopyDirectorySimple(extractDir, destDir)
+	if err := removeIfRecorded(appName, destDir); err != nil {
+		return err
+	}
+	if err := utils.CopyDirectorySimple(sourceDir, destDir); err != nil {
+		return err
+	}
+	recordFile(appName, destDir)
+	return nil
 }
 
 // extractMountPath extracts the mount path from hdiutil output
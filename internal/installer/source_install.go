@@ -19,7 +19,6 @@ package installer
 import (
 	"fmt"
 	"path/filepath"
-	"strings"
 
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/0xjuanma/anvil/internal/system"
@@ -29,42 +28,47 @@ import (
 
 // installDownloadedFile installs the downloaded file based on its type and OS
 func installDownloadedFile(filePath, appName string) error {
+	fileType, err := sourceFileType(filePath)
+	if err != nil {
+		return fmt.Errorf("unsupported file %s: %w", filepath.Base(filePath), err)
+	}
+	return installFileOfType(filePath, fileType, appName)
+}
+
+// installFileOfType installs a file whose type is already known on the current OS
+func installFileOfType(filePath, fileType, appName string) error {
 	if system.IsMacOS() {
-		return installOnMacOS(filePath, appName)
+		return installOnMacOS(filePath, fileType, appName)
 	} else if system.IsLinux() {
-		return installOnLinux(filePath, appName)
+		return installOnLinux(filePath, fileType, appName)
 	}
 	return fmt.Errorf("unsupported operating system")
 }
 
 // installOnMacOS handles installation on macOS
-func installOnMacOS(filePath, appName string) error {
-	ext := strings.ToLower(filepath.Ext(filePath))
-
-	switch ext {
+func installOnMacOS(filePath, fileType, appName string) error {
+	switch fileType {
 	case constants.ExtDMG:
 		return installDMG(filePath, appName)
 	case constants.ExtPKG:
 		return installPKG(filePath)
 	case constants.ExtZIP:
 		return installZIP(filePath, appName)
+	case constants.ExtTar, constants.ExtTarGz, constants.ExtTarBz2, constants.ExtTarXz, constants.ExtTarZst:
+		return installTarArchive(filePath, fileType, appName)
+	case constants.ExtGz:
+		return installGz(filePath, appName)
+	case constants.FileTypeBinary:
+		return installBinary(filePath, appName, appName)
 	default:
-		return fmt.Errorf("unsupported file type: %s (supported: %s, %s, %s)", ext, constants.ExtDMG, constants.ExtPKG, constants.ExtZIP)
+		return fmt.Errorf("unsupported file type: %s (supported: %s, %s, %s, %s, %s, %s, %s, %s, %s and executables)", fileType,
+			constants.ExtDMG, constants.ExtPKG, constants.ExtZIP, constants.ExtTar, constants.ExtTarGz, constants.ExtTarBz2, constants.ExtTarXz, constants.ExtTarZst, constants.ExtGz)
 	}
 }
 
 // installOnLinux handles installation on Linux
-func installOnLinux(filePath, appName string) error {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	baseName := strings.ToLower(filepath.Base(filePath))
-
-	if strings.HasSuffix(baseName, constants.ExtTarGz) {
-		return installTarGz(filePath, appName)
-	} else if strings.HasSuffix(baseName, constants.ExtTarBz2) {
-		return installTarBz2(filePath, appName)
-	}
-
-	switch ext {
+func installOnLinux(filePath, fileType, appName string) error {
+	switch fileType {
 	case constants.ExtDEB:
 		return installDEB(filePath)
 	case constants.ExtRPM:
@@ -73,8 +77,15 @@ func installOnLinux(filePath, appName string) error {
 		return installAppImage(filePath, appName)
 	case constants.ExtZIP:
 		return installZIP(filePath, appName)
+	case constants.ExtTar, constants.ExtTarGz, constants.ExtTarBz2, constants.ExtTarXz, constants.ExtTarZst:
+		return installTarArchive(filePath, fileType, appName)
+	case constants.ExtGz:
+		return installGz(filePath, appName)
+	case constants.FileTypeBinary:
+		return installBinary(filePath, appName, appName)
 	default:
-		return fmt.Errorf("unsupported file type: %s (supported: %s, %s, %s, %s, %s, %s)", ext, constants.ExtDEB, constants.ExtRPM, constants.ExtAppImage, constants.ExtZIP, constants.ExtTarGz, constants.ExtTarBz2)
+		return fmt.Errorf("unsupported file type: %s (supported: %s, %s, %s, %s, %s, %s, %s, %s, %s, %s and executables)", fileType,
+			constants.ExtDEB, constants.ExtRPM, constants.ExtAppImage, constants.ExtZIP, constants.ExtTar, constants.ExtTarGz, constants.ExtTarBz2, constants.ExtTarXz, constants.ExtTarZst, constants.ExtGz)
 	}
 }
 
@@ -112,9 +123,13 @@ func installDMG(filePath, appName string) error {
 	appNameFromPath := filepath.Base(appPath)
 	destPath := filepath.Join(applicationsDir, appNameFromPath)
 
+	if err := removeIfRecorded(appName, destPath); err != nil {
+		return err
+	}
 	if err := utils.CopyDirectorySimple(appPath, destPath); err != nil {
 		return fmt.Errorf("failed to copy application: %w", err)
 	}
+	recordFile(appName, destPath)
 
 	spinner = charm.NewDotsSpinner("Installing to Applications")
 	spinner.Success("Application installed")
@@ -145,16 +160,7 @@ func installZIP(filePath, appName string) error {
 		return err
 	}
 
-	if system.IsMacOS() {
-		err := handleExtractedContentsMacOS(extractDir, appName)
-		// Check if extraction succeeded but installation failed
-		if extractErr, ok := err.(*ExtractionSucceededError); ok {
-			// Return the error but it will be handled gracefully by the caller
-			return extractErr
-		}
-		return err
-	}
-	return handleExtractedContentsLinux(extractDir, appName)
+	return handleExtractedContents(extractDir, appName)
 }
 
 // installDEB installs a .deb package
@@ -199,7 +205,8 @@ func installRPM(filePath string) error {
 	)
 }
 
-// installAppImage makes AppImage executable and optionally installs it
+// installAppImage copies an AppImage to ~/Applications, makes it executable and adds it
+// to desktop launchers
 func installAppImage(filePath, appName string) error {
 	appImageDir, err := ensureApplicationsDirectory()
 	if err != nil {
@@ -210,38 +217,16 @@ func installAppImage(filePath, appName string) error {
 	if err := utils.CopyFileSimple(filePath, destPath); err != nil {
 		return fmt.Errorf("failed to copy AppImage: %w", err)
 	}
+	recordFile(appName, destPath)
 
-	return runCommandWithSpinner(
+	if err := runCommandWithSpinner(
 		"Setting up AppImage",
 		"Failed to make AppImage executable",
 		"chmod", "+x", destPath,
-	)
-}
-
-// installTarGz extracts and installs .tar.gz archive
-func installTarGz(filePath, appName string) error {
-	return installTarArchive(filePath, appName, "tar", "-xzf")
-}
-
-// installTarBz2 extracts and installs .tar.bz2 archive
-func installTarBz2(filePath, appName string) error {
-	return installTarArchive(filePath, appName, "tar", "-xjf")
-}
-
-// installTarArchive extracts tar archive and handles contents
-func installTarArchive(filePath, appName, command, flags string) error {
-	extractDir, err := ensureExtractDirectory(filePath, appName)
-	if err != nil {
-		return err
-	}
-
-	if err := runCommandWithSpinner(
-		"Extracting archive",
-		"Failed to extract archive",
-		command, flags, filePath, "-C", extractDir,
 	); err != nil {
 		return err
 	}
 
-	return handleExtractedContentsLinux(extractDir, appName)
+	addAppImageToDesktop(destPath, appName)
+	return nil
 }
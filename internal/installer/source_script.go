@@ -0,0 +1,398 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/system"
+	"github.com/0xjuanma/anvil/internal/terminal/charm"
+	"github.com/0xjuanma/palantir"
+)
+
+// How a remote script is handed to its interpreter
+const (
+	scriptFromStdin = iota // curl URL | sh
+	scriptAsCommand        // sh -c "$(curl URL)"
+	scriptAsFile           // bash <(curl URL)
+)
+
+// maxScriptNesting bounds how deep commands handed to shells are searched for downloads
+const maxScriptNesting = 4
+
+// scriptPreviewLines is how much of a script is shown when no pager is available
+const scriptPreviewLines = 40
+
+// Programs remote install scripts are downloaded with and run by
+var (
+	scriptDownloaders  = map[string]bool{"curl": true, "wget": true}
+	scriptInterpreters = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true}
+)
+
+var (
+	// scriptTrustOverride is the --trust-scripts policy, which takes precedence over settings
+	scriptTrustOverride string
+	// scriptPromptMu keeps concurrent installs from previewing scripts at the same time
+	scriptPromptMu sync.Mutex
+)
+
+// remoteScript is a command source that downloads a script and runs it with a shell
+type remoteScript struct {
+	URL   string
+	Shell []string // Interpreter and the arguments before the script, e.g. [sudo bash -s --]
+	Args  []string // Arguments after the script, for scripts passed with -c or as a file
+	Mode  int
+}
+
+// SetScriptTrust sets the trust policy for remote install scripts for this run,
+// overriding install.trust_scripts from settings
+func SetScriptTrust(policy string) error {
+	if !config.IsScriptTrustPolicy(policy) {
+		return fmt.Errorf("unknown script trust policy '%s' (expected %s, %s or %s)",
+			policy, config.ScriptTrustPrompt, config.ScriptTrustPinned, config.ScriptTrustAll)
+	}
+	scriptTrustOverride = policy
+	return nil
+}
+
+// scriptTrustPolicy returns the trust policy in effect for remote install scripts
+func scriptTrustPolicy(options config.AnvilInstallOptions) string {
+	if scriptTrustOverride != "" {
+		return scriptTrustOverride
+	}
+	if options.TrustScripts != "" {
+		return options.TrustScripts
+	}
+	return config.ScriptTrustPrompt
+}
+
+// parseRemoteScript recognizes commands that download a script and run it with a shell:
+// "curl URL | sh", "sh -c "$(curl URL)"" and "bash <(curl URL)", optionally run with
+// sudo and followed by script arguments. Other commands return nil.
+func parseRemoteScript(tokens []shellToken) *remoteScript {
+	stages, ok := splitPipeline(tokens)
+	if !ok {
+		return nil
+	}
+
+	if len(stages) == 2 {
+		url, shell := downloadURL(stages[0]), stages[1]
+		at := interpreterIndex(shell)
+		if url == "" || at < 0 || hasExpansion(shell) || containsWord(shell[at+1:], "-c") {
+			return nil
+		}
+		return &remoteScript{URL: url, Shell: tokenWords(shell), Mode: scriptFromStdin}
+	}
+	if len(stages) != 1 {
+		return nil
+	}
+
+	stage := stages[0]
+	at := interpreterIndex(stage)
+	if at < 0 || at+1 >= len(stage) || hasExpansion(stage[:at+1]) {
+		return nil
+	}
+	if stage[at+1].Word == "-c" && at+2 < len(stage) {
+		url := substitutedDownloadURL(stage[at+2], "$(")
+		if url == "" || hasExpansion(stage[at+3:]) {
+			return nil
+		}
+		return &remoteScript{URL: url, Shell: tokenWords(stage[:at+2]), Args: tokenWords(stage[at+3:]), Mode: scriptAsCommand}
+	}
+	if url := substitutedDownloadURL(stage[at+1], "<("); url != "" && !hasExpansion(stage[at+2:]) {
+		return &remoteScript{URL: url, Shell: tokenWords(stage[:at+1]), Args: tokenWords(stage[at+2:]), Mode: scriptAsFile}
+	}
+	return nil
+}
+
+// runsDownloader reports whether a command runs curl or wget anywhere, including inside
+// substitutions and strings handed to a shell such as sh -c "curl URL | sh"
+func runsDownloader(tokens []shellToken, depth int) bool {
+	if depth > maxScriptNesting {
+		return true
+	}
+	for _, token := range tokens {
+		if token.Operator != "" {
+			continue
+		}
+		if scriptDownloaders[commandName(token)] {
+			return true
+		}
+		nested := append([]string{}, token.Substitutions...)
+		if strings.ContainsAny(token.Word, " \t\n;|&") {
+			nested = append(nested, token.Word)
+		}
+		for _, command := range nested {
+			inner, err := splitShellWords(command)
+			if err != nil || runsDownloader(inner, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkUnreviewedDownload refuses commands that download something anvil cannot fetch
+// and verify itself, such as "curl URL | python3", unless the trust policy is 'all'
+func checkUnreviewedDownload(appName string, tokens []shellToken) error {
+	if !runsDownloader(tokens, 0) {
+		return nil
+	}
+	policy := scriptTrustPolicy(installOptions())
+	if policy == config.ScriptTrustAll {
+		palantir.GetGlobalOutputHandler().PrintWarning("Running a command for %s that downloads code anvil cannot review", appName)
+		return nil
+	}
+	return fmt.Errorf("the command for %s downloads code anvil cannot review or pin under the script trust policy '%s'; write it as 'curl -fsSL URL | sh' so the script can be verified, or run with --trust-scripts %s", appName, policy, config.ScriptTrustAll)
+}
+
+// remoteScriptPlan describes the install script an app's source runs for dry runs, or
+// returns "" when the source does not run one
+func remoteScriptPlan(appName string) string {
+	source, exists, err := SourceURL(appName)
+	if err != nil || !exists || !isShellCommand(source) {
+		return ""
+	}
+	tokens, err := splitShellWords(strings.TrimSpace(source))
+	if err != nil {
+		return ""
+	}
+	options := installOptions()
+	script := parseRemoteScript(tokens)
+	if script == nil {
+		if runsDownloader(tokens, 0) {
+			return fmt.Sprintf("unreviewed download, trust policy %s", scriptTrustPolicy(options))
+		}
+		return ""
+	}
+
+	if options.ScriptSHA256[appName] != "" {
+		return fmt.Sprintf("script %s, sha256 pinned", script.URL)
+	}
+	return fmt.Sprintf("script %s, trust policy %s", script.URL, scriptTrustPolicy(options))
+}
+
+// downloadURL returns the URL a curl or wget command downloads, or "" for other commands
+func downloadURL(stage []shellToken) string {
+	if len(stage) == 0 || !scriptDownloaders[commandName(stage[0])] || hasExpansion(stage) {
+		return ""
+	}
+	for _, token := range stage[1:] {
+		if strings.HasPrefix(token.Word, "https://") || strings.HasPrefix(token.Word, "http://") {
+			return token.Word
+		}
+	}
+	return ""
+}
+
+// substitutedDownloadURL returns the URL of a word that is nothing but a substitution,
+// opened with open, of a curl or wget command
+func substitutedDownloadURL(token shellToken, open string) string {
+	if len(token.Substitutions) != 1 || token.Word != open+token.Substitutions[0]+")" {
+		return ""
+	}
+	inner, err := splitShellWords(token.Substitutions[0])
+	if err != nil || !isSimpleCommand(inner) {
+		return ""
+	}
+	return downloadURL(inner)
+}
+
+// interpreterIndex returns the index of the shell a command runs, skipping a leading
+// sudo and its options, or -1 when the command is not a shell
+func interpreterIndex(stage []shellToken) int {
+	i := 0
+	if i < len(stage) && commandName(stage[i]) == "sudo" {
+		for i++; i < len(stage) && strings.HasPrefix(stage[i].Word, "-"); i++ {
+		}
+	}
+	if i < len(stage) && scriptInterpreters[commandName(stage[i])] {
+		return i
+	}
+	return -1
+}
+
+// hasExpansion reports whether any word needs a shell to expand
+func hasExpansion(tokens []shellToken) bool {
+	for _, token := range tokens {
+		if token.Expands {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether a word appears among tokens
+func containsWord(tokens []shellToken, word string) bool {
+	for _, token := range tokens {
+		if token.Word == word {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenWords returns the words of tokens
+func tokenWords(tokens []shellToken) []string {
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Word
+	}
+	return words
+}
+
+// installFromRemoteScript downloads an install script, checks it against the trust
+// policy and runs it the way the configured command would have
+func installFromRemoteScript(appName string, script *remoteScript) error {
+	spinner := charm.NewDotsSpinner(fmt.Sprintf("Downloading install script for %s", appName))
+	spinner.Start()
+
+	scriptPath, err := downloadFile(script.URL, appName)
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Failed to download install script for %s", appName))
+		return fmt.Errorf("failed to download install script for %s: %w", appName, err)
+	}
+	content, err := os.ReadFile(scriptPath)
+	if err != nil {
+		spinner.Error(fmt.Sprintf("Failed to read install script for %s", appName))
+		return fmt.Errorf("failed to read install script for %s: %w", appName, err)
+	}
+	spinner.Success(fmt.Sprintf("Downloaded install script for %s", appName))
+
+	if err := verifyScript(appName, script.URL, scriptPath, content); err != nil {
+		return err
+	}
+
+	cmd := script.command(scriptPath, string(content))
+	if script.Mode == scriptFromStdin {
+		file, err := os.Open(scriptPath)
+		if err != nil {
+			return fmt.Errorf("failed to open install script for %s: %w", appName, err)
+		}
+		defer file.Close()
+		cmd.Stdin = file
+	} else {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("install script for %s failed: %w", appName, err)
+	}
+	palantir.GetGlobalOutputHandler().PrintSuccess(fmt.Sprintf("Install script for %s completed", appName))
+	return nil
+}
+
+// command builds the command that runs the downloaded script
+func (s *remoteScript) command(scriptPath, content string) *exec.Cmd {
+	args := append([]string{}, s.Shell[1:]...)
+	switch s.Mode {
+	case scriptAsCommand:
+		args = append(append(args, content), s.Args...)
+	case scriptAsFile:
+		args = append(append(args, scriptPath), s.Args...)
+	}
+	return exec.Command(s.Shell[0], args...)
+}
+
+// verifyScript decides whether a downloaded script may run. A sha256 pinned in settings
+// must match. Unpinned scripts run under the 'all' policy, are refused under 'pinned',
+// and are previewed and confirmed under 'prompt', which needs an interactive terminal.
+func verifyScript(appName, url, scriptPath string, content []byte) error {
+	o := palantir.GetGlobalOutputHandler()
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	options := installOptions()
+
+	if pinned := options.ScriptSHA256[appName]; pinned != "" {
+		if !strings.EqualFold(pinned, digest) {
+			return fmt.Errorf("install script for %s does not match its pinned sha256\n  expected: %s\n  got:      %s\nUpdate install.script_sha256.%s once you have reviewed the new script", appName, pinned, digest, appName)
+		}
+		o.PrintInfo("Install script for %s matches its pinned sha256", appName)
+		return nil
+	}
+
+	pinHint := fmt.Sprintf("pin it with install.script_sha256.%s: %s", appName, digest)
+	switch scriptTrustPolicy(options) {
+	case config.ScriptTrustAll:
+		o.PrintWarning("Running unpinned install script for %s from %s (sha256 %s)", appName, url, digest)
+		return nil
+	case config.ScriptTrustPinned:
+		return fmt.Errorf("install script for %s is not pinned and the script trust policy is '%s'; %s", appName, config.ScriptTrustPinned, pinHint)
+	}
+
+	if !stdinIsTerminal() {
+		return fmt.Errorf("install script for %s needs review but the session is not interactive; %s, or run with --trust-scripts", appName, pinHint)
+	}
+
+	scriptPromptMu.Lock()
+	defer scriptPromptMu.Unlock()
+
+	o.PrintHeader(fmt.Sprintf("Install script for %s", appName))
+	o.PrintInfo("URL:    %s", url)
+	o.PrintInfo("SHA256: %s", digest)
+	o.PrintInfo("Size:   %d bytes, %d lines", len(content), bytes.Count(content, []byte("\n")))
+	previewScript(scriptPath, content)
+
+	if !o.Confirm(fmt.Sprintf("Run this script to install %s?", appName)) {
+		return fmt.Errorf("install script for %s was not approved; %s to skip the review next time", appName, pinHint)
+	}
+	return nil
+}
+
+// previewScript shows a script in $PAGER or less, or prints its first lines when
+// neither is available
+func previewScript(scriptPath string, content []byte) {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 && system.CommandExists("less") {
+		pager = []string{"less"}
+	}
+	if len(pager) > 0 {
+		cmd := exec.Command(pager[0], append(pager[1:], scriptPath)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err == nil {
+			return
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 0; scanner.Scan(); line++ {
+		if line == scriptPreviewLines {
+			fmt.Println("...")
+			break
+		}
+		fmt.Println(scanner.Text())
+	}
+}
+
+// stdinIsTerminal reports whether standard input is an interactive terminal
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
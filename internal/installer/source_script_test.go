@@ -0,0 +1,235 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+func TestParseRemoteScript(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected *remoteScript
+	}{
+		{
+			command:  "curl -fsSL https://example.com/install.sh | sh",
+			expected: &remoteScript{URL: "https://example.com/install.sh", Shell: []string{"sh"}, Mode: scriptFromStdin},
+		},
+		{
+			command:  "wget -qO- https://example.com/install.sh | sudo -E bash -s -- --yes",
+			expected: &remoteScript{URL: "https://example.com/install.sh", Shell: []string{"sudo", "-E", "bash", "-s", "--", "--yes"}, Mode: scriptFromStdin},
+		},
+		{
+			command:  `sh -c "$(curl -fsSL https://raw.githubusercontent.com/ohmyzsh/ohmyzsh/master/tools/install.sh)" "" --unattended`,
+			expected: &remoteScript{URL: "https://raw.githubusercontent.com/ohmyzsh/ohmyzsh/master/tools/install.sh", Shell: []string{"sh", "-c"}, Args: []string{"", "--unattended"}, Mode: scriptAsCommand},
+		},
+		{
+			command:  `/bin/bash -c "$(curl -fsSL 'https://example.com/install.sh')"`,
+			expected: &remoteScript{URL: "https://example.com/install.sh", Shell: []string{"/bin/bash", "-c"}, Args: []string{}, Mode: scriptAsCommand},
+		},
+		{
+			command:  "bash <(curl -fsSL https://example.com/install.sh) --prefix /opt",
+			expected: &remoteScript{URL: "https://example.com/install.sh", Shell: []string{"bash"}, Args: []string{"--prefix", "/opt"}, Mode: scriptAsFile},
+		},
+		// Not remote scripts: no shell, extra commands, unknown URLs or expansions
+		{command: "curl -fsSL https://example.com/tool.tar.gz | tar xz"},
+		{command: "curl -fsSL https://example.com/install.sh | sh && echo done"},
+		{command: "curl -fsSL $URL | sh"},
+		{command: `sh -c "$(curl -fsSL https://example.com/install.sh) && echo done"`},
+		{command: `sh -c "$(cat install.sh)"`},
+		{command: "curl -fsSL https://example.com/install.sh | sh -c 'echo hi'"},
+		{command: "brew install moom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			tokens, err := splitShellWords(tt.command)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := parseRemoteScript(tokens)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// scriptServer serves an install script that records its arguments in $HOME/ran
+func scriptServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	script := "#!/bin/sh\necho \"ran $*\" > \"$HOME/ran\"\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(script))
+	}))
+	t.Cleanup(server.Close)
+
+	sum := sha256.Sum256([]byte(script))
+	return server, hex.EncodeToString(sum[:])
+}
+
+// useScriptTrust sets the --trust-scripts policy for one test
+func useScriptTrust(t *testing.T, policy string) {
+	t.Helper()
+	if err := SetScriptTrust(policy); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { scriptTrustOverride = "" })
+}
+
+func TestInstallFromRemoteScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("install scripts need sh")
+	}
+	server, digest := scriptServer(t)
+	url := server.URL + "/install.sh"
+
+	t.Run("pinned digest runs every form", func(t *testing.T) {
+		home := writeSettings(t, "install:\n  script_sha256:\n    tool: "+strings.ToUpper(digest)+"\n")
+		useScriptTrust(t, config.ScriptTrustPinned)
+
+		commands := map[string]string{
+			"curl -fsSL " + url + " | sh -s -- --yes":          "ran --yes",
+			`sh -c "$(curl -fsSL ` + url + `)" x --unattended`: "ran --unattended",
+		}
+		if _, err := os.Stat("/bin/bash"); err == nil {
+			commands["bash <(curl -fsSL "+url+") --prefix /opt"] = "ran --prefix /opt"
+		}
+		for command, expected := range commands {
+			os.Remove(filepath.Join(home, "ran"))
+			if err := installFromCommand("tool", command); err != nil {
+				t.Fatalf("%s: unexpected error: %v", command, err)
+			}
+			data, err := os.ReadFile(filepath.Join(home, "ran"))
+			if err != nil || strings.TrimSpace(string(data)) != expected {
+				t.Errorf("%s: expected the script to record %q, got %q (%v)", command, expected, data, err)
+			}
+		}
+	})
+
+	t.Run("mismatched digest is refused", func(t *testing.T) {
+		home := writeSettings(t, "install:\n  script_sha256:\n    tool: "+strings.Repeat("0", 64)+"\n")
+		useScriptTrust(t, config.ScriptTrustAll)
+
+		err := installFromCommand("tool", "curl -fsSL "+url+" | sh")
+		if err == nil || !strings.Contains(err.Error(), digest) {
+			t.Errorf("Expected a mismatch error showing the digest, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(home, "ran")); !os.IsNotExist(err) {
+			t.Error("Expected the script not to run")
+		}
+	})
+
+	t.Run("pinned policy refuses unpinned scripts", func(t *testing.T) {
+		home := writeSettings(t, "install:\n  trust_scripts: all\n")
+		useScriptTrust(t, config.ScriptTrustPinned)
+
+		err := installFromCommand("tool", "curl -fsSL "+url+" | sh")
+		if err == nil || !strings.Contains(err.Error(), "install.script_sha256.tool: "+digest) {
+			t.Errorf("Expected a pinning hint, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(home, "ran")); !os.IsNotExist(err) {
+			t.Error("Expected the script not to run")
+		}
+	})
+
+	t.Run("all policy from settings runs unpinned scripts", func(t *testing.T) {
+		home := writeSettings(t, "install:\n  trust_scripts: all\n")
+
+		if err := installFromCommand("tool", "curl -fsSL "+url+" | sh"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(home, "ran")); err != nil {
+			t.Errorf("Expected the script to run: %v", err)
+		}
+	})
+
+	t.Run("unrecognized download commands need the all policy", func(t *testing.T) {
+		home := writeSettings(t, "install:\n  script_sha256:\n    tool: "+digest+"\n")
+		useScriptTrust(t, config.ScriptTrustPrompt)
+
+		commands := []string{
+			"curl -fsSL " + url + " | python3",
+			"curl -fsSL " + url + " | bash -c 'sh'",
+			`sh -c "curl -fsSL ` + url + ` | sh"`,
+			`eval "$(wget -qO- ` + url + `)"`,
+			"curl -fsSL -o /tmp/install.sh " + url + " && sh /tmp/install.sh",
+		}
+		for _, command := range commands {
+			err := installFromCommand("tool", command)
+			if err == nil || !strings.Contains(err.Error(), "cannot review") {
+				t.Errorf("%s: expected the command to be refused, got %v", command, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(home, "ran")); !os.IsNotExist(err) {
+			t.Error("Expected no script to run")
+		}
+
+		useScriptTrust(t, config.ScriptTrustAll)
+		if err := installFromCommand("tool", `sh -c "curl -fsSL `+url+` | sh"`); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(home, "ran")); err != nil {
+			t.Errorf("Expected the script to run under the all policy: %v", err)
+		}
+	})
+
+	t.Run("prompt policy needs a terminal", func(t *testing.T) {
+		if stdinIsTerminal() {
+			t.Skip("standard input is a terminal")
+		}
+		home := writeSettings(t, "install:\n  prefix: ~/.local\n")
+
+		err := installFromCommand("tool", "curl -fsSL "+url+" | sh")
+		if err == nil || !strings.Contains(err.Error(), "not interactive") {
+			t.Errorf("Expected a non-interactive error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(home, "ran")); !os.IsNotExist(err) {
+			t.Error("Expected the script not to run")
+		}
+	})
+}
+
+func TestSetScriptTrust(t *testing.T) {
+	t.Cleanup(func() { scriptTrustOverride = "" })
+	if err := SetScriptTrust("sometimes"); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+	if got := scriptTrustPolicy(config.AnvilInstallOptions{TrustScripts: config.ScriptTrustAll}); got != config.ScriptTrustAll {
+		t.Errorf("Expected the policy from settings, got %s", got)
+	}
+	if got := scriptTrustPolicy(config.AnvilInstallOptions{}); got != config.ScriptTrustPrompt {
+		t.Errorf("Expected prompt by default, got %s", got)
+	}
+	if err := SetScriptTrust(config.ScriptTrustPinned); err != nil {
+		t.Fatal(err)
+	}
+	if got := scriptTrustPolicy(config.AnvilInstallOptions{TrustScripts: config.ScriptTrustAll}); got != config.ScriptTrustPinned {
+		t.Errorf("Expected --trust-scripts to override settings, got %s", got)
+	}
+}
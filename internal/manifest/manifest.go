@@ -0,0 +1,300 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package manifest records the files anvil places on disk when it installs an app
+// from a source, so the app can later be uninstalled or replaced cleanly.
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/constants"
+	"github.com/0xjuanma/anvil/internal/system"
+	"github.com/0xjuanma/anvil/internal/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest lists what a source install of an app created
+type Manifest struct {
+	App           string   `yaml:"app"`
+	Source        string   `yaml:"source"`                   // Source from settings.yaml the app was installed from
+	URL           string   `yaml:"url,omitempty"`            // URL the installed file was downloaded from
+	Version       string   `yaml:"version,omitempty"`        // Release tag or version in the file name, when known
+	ETag          string   `yaml:"etag,omitempty"`           // ETag of the download, used to detect newer uploads
+	LastModified  string   `yaml:"last_modified,omitempty"`  // Last-Modified of the download
+	ContentLength int64    `yaml:"content_length,omitempty"` // Size of the download in bytes
+	InstalledAt   string   `yaml:"installed_at"`             // RFC3339 time of the install
+	Files         []string `yaml:"files,omitempty"`          // Files and directories created, removed recursively
+	Symlinks      []string `yaml:"symlinks,omitempty"`       // Links created, removed only while they are links
+}
+
+// New returns an empty manifest for an install of appName from source
+func New(appName, source string) *Manifest {
+	return &Manifest{
+		App:         appName,
+		Source:      source,
+		InstalledAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// Dir returns the directory manifests are stored in
+func Dir() string {
+	return filepath.Join(config.AnvilConfigDirectory(), constants.MANIFESTS_DIR)
+}
+
+// Path returns the manifest file of an app. Callers must check the name with ValidateName.
+func Path(appName string) string {
+	return filepath.Join(Dir(), appName+".yaml")
+}
+
+// ValidateName rejects app names that would place a manifest outside the manifests
+// directory, such as "../settings"
+func ValidateName(appName string) error {
+	if appName == "" || appName == "." || appName == ".." || strings.Contains(appName, "..") ||
+		strings.ContainsAny(appName, `/\`) || filepath.IsAbs(appName) {
+		return fmt.Errorf("invalid app name '%s' for an install manifest", appName)
+	}
+	return nil
+}
+
+// Exists reports whether an app has a manifest
+func Exists(appName string) bool {
+	if ValidateName(appName) != nil {
+		return false
+	}
+	_, err := os.Stat(Path(appName))
+	return err == nil
+}
+
+// Load reads the manifest of an app. The error wraps os.ErrNotExist when the app has none.
+func Load(appName string) (*Manifest, error) {
+	if err := ValidateName(appName); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(Path(appName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest of %s: %w", appName, err)
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %s: %w", appName, err)
+	}
+	if m.App == "" {
+		m.App = appName
+	}
+	return &m, nil
+}
+
+// List reads every manifest, sorted by app name
+func List() ([]*Manifest, error) {
+	entries, err := os.ReadDir(Dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests: %w", err)
+	}
+
+	var manifests []*Manifest
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yaml" {
+			continue
+		}
+		m, err := Load(strings.TrimSuffix(entry.Name(), ".yaml"))
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, m)
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].App < manifests[j].App })
+	return manifests, nil
+}
+
+// Save writes the manifest to the manifests directory
+func (m *Manifest) Save() error {
+	if err := utils.EnsureDirectory(Dir()); err != nil {
+		return fmt.Errorf("failed to create manifests directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest of %s: %w", m.App, err)
+	}
+	if err := ValidateName(m.App); err != nil {
+		return err
+	}
+	if err := os.WriteFile(Path(m.App), data, constants.FilePerm); err != nil {
+		return fmt.Errorf("failed to write manifest of %s: %w", m.App, err)
+	}
+	return nil
+}
+
+// Delete removes the manifest file of an app, leaving the installed files alone
+func Delete(appName string) error {
+	if err := ValidateName(appName); err != nil {
+		return err
+	}
+	if err := os.Remove(Path(appName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove manifest of %s: %w", appName, err)
+	}
+	return nil
+}
+
+// AddFile records a created file or directory
+func (m *Manifest) AddFile(path string) {
+	m.Files = appendUnique(m.Files, path)
+}
+
+// AddSymlink records a created link
+func (m *Manifest) AddSymlink(path string) {
+	m.Symlinks = appendUnique(m.Symlinks, path)
+}
+
+// Paths returns every recorded path, links first
+func (m *Manifest) Paths() []string {
+	return append(append([]string{}, m.Symlinks...), m.Files...)
+}
+
+// Missing returns the recorded paths that no longer exist
+func (m *Manifest) Missing() []string {
+	var missing []string
+	for _, path := range m.Paths() {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			missing = append(missing, path)
+		}
+	}
+	return missing
+}
+
+// StalePaths returns the paths recorded in m that a newer install no longer created
+func (m *Manifest) StalePaths(newer *Manifest) []string {
+	current := make(map[string]bool)
+	for _, path := range newer.Paths() {
+		current[path] = true
+	}
+
+	var stale []string
+	for _, path := range m.Paths() {
+		if !current[path] {
+			stale = append(stale, path)
+		}
+	}
+	return stale
+}
+
+// RemoveInstalled removes the recorded links and files. Links that were replaced by
+// something else are left alone. Paths that are already gone are not an error.
+func (m *Manifest) RemoveInstalled() error {
+	var failed []string
+	for _, link := range m.Symlinks {
+		if err := removeSymlink(link); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	for _, file := range m.Files {
+		if err := removeFile(file); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to remove %d path(s) of %s: %s", len(failed), m.App, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// RemovePaths removes paths recorded in m, such as those returned by StalePaths
+func (m *Manifest) RemovePaths(paths []string) error {
+	links := make(map[string]bool)
+	for _, link := range m.Symlinks {
+		links[link] = true
+	}
+
+	subset := &Manifest{App: m.App}
+	for _, path := range paths {
+		if links[path] {
+			subset.Symlinks = append(subset.Symlinks, path)
+		} else {
+			subset.Files = append(subset.Files, path)
+		}
+	}
+	return subset.RemoveInstalled()
+}
+
+// removeSymlink removes a link if it is still one
+func removeSymlink(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// removeFile removes a file or directory tree, refusing paths that are not safe to remove
+func removeFile(path string) error {
+	if !isSafeToRemove(path) {
+		return fmt.Errorf("%s: refusing to remove", path)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// isSafeToRemove guards against manifests that would remove the filesystem root, the
+// home directory or one of its ancestors
+func isSafeToRemove(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	cleaned := filepath.Clean(path)
+	if cleaned == string(filepath.Separator) {
+		return false
+	}
+	if homeDir, err := system.HomeDir(); err == nil {
+		home := filepath.Clean(homeDir)
+		if cleaned == home || strings.HasPrefix(home, cleaned+string(filepath.Separator)) {
+			return false
+		}
+	}
+	return true
+}
+
+// appendUnique appends value to values unless it is already there
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifestSaveLoadList(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if manifests, err := List(); err != nil || len(manifests) != 0 {
+		t.Fatalf("Expected no manifests before any install, got %v, %v", manifests, err)
+	}
+
+	for _, app := range []string{"zed", "lazygit"} {
+		m := New(app, "github:acme/"+app)
+		m.Version = "v1.0.0"
+		m.AddFile(filepath.Join(home, ".local", "opt", app))
+		m.AddFile(filepath.Join(home, ".local", "opt", app))
+		m.AddSymlink(filepath.Join(home, ".local", "bin", app))
+		if err := m.Save(); err != nil {
+			t.Fatalf("Failed to save manifest: %v", err)
+		}
+	}
+
+	if !Exists("lazygit") || Exists("moom") {
+		t.Error("Expected Exists to report saved manifests only")
+	}
+
+	loaded, err := Load("lazygit")
+	if err != nil {
+		t.Fatalf("Failed to load manifest: %v", err)
+	}
+	if loaded.Source != "github:acme/lazygit" || loaded.Version != "v1.0.0" || len(loaded.Files) != 1 || len(loaded.Symlinks) != 1 {
+		t.Errorf("Unexpected manifest: %+v", loaded)
+	}
+
+	manifests, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 2 || manifests[0].App != "lazygit" || manifests[1].App != "zed" {
+		t.Errorf("Expected manifests sorted by app, got %+v", manifests)
+	}
+
+	if err := Delete("lazygit"); err != nil || Exists("lazygit") {
+		t.Errorf("Expected the manifest to be deleted, got %v", err)
+	}
+}
+
+func TestManifestMissingAndStalePaths(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "tool")
+	if err := os.WriteFile(present, []byte("tool"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manifest{App: "tool", Files: []string{present, filepath.Join(dir, "gone")}, Symlinks: []string{filepath.Join(dir, "bin", "tool")}}
+	expected := []string{filepath.Join(dir, "bin", "tool"), filepath.Join(dir, "gone")}
+	if got := m.Missing(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected missing %v, got %v", expected, got)
+	}
+
+	newer := &Manifest{App: "tool", Files: []string{present}}
+	if got := m.StalePaths(newer); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected stale %v, got %v", expected, got)
+	}
+}
+
+func TestManifestRemoveInstalled(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	appDir := filepath.Join(home, ".local", "opt", "tool")
+	binDir := filepath.Join(home, ".local", "bin")
+	for _, dir := range []string{appDir, binDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(appDir, "tool"), []byte("tool"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(appDir, "tool"), filepath.Join(binDir, "tool")); err != nil {
+		t.Fatal(err)
+	}
+	// A link the user replaced with their own file is kept
+	if err := os.WriteFile(filepath.Join(binDir, "tool-helper"), []byte("mine"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manifest{
+		App:      "tool",
+		Files:    []string{appDir, filepath.Join(home, "already-gone")},
+		Symlinks: []string{filepath.Join(binDir, "tool"), filepath.Join(binDir, "tool-helper")},
+	}
+	if err := m.RemoveInstalled(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, path := range []string{appDir, filepath.Join(binDir, "tool")} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(binDir, "tool-helper")); err != nil {
+		t.Errorf("Expected a file that replaced a link to be kept: %v", err)
+	}
+}
+
+func TestManifestRemoveInstalledRefusesUnsafePaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	for _, path := range []string{"/", home, filepath.Dir(home), "relative/path"} {
+		m := &Manifest{App: "tool", Files: []string{path}}
+		if err := m.RemoveInstalled(); err == nil {
+			t.Errorf("Expected removing %q to be refused", path)
+		}
+	}
+	if _, err := os.Stat(home); err != nil {
+		t.Fatalf("Home directory was removed: %v", err)
+	}
+}
+
+func TestManifestRejectsPathNames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, name := range []string{"", ".", "..", "../settings", "a/b", `a\b`, "/tmp/x"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+		if _, err := Load(name); err == nil {
+			t.Errorf("Expected Load(%q) to fail", name)
+		}
+		if err := Delete(name); err == nil {
+			t.Errorf("Expected Delete(%q) to fail", name)
+		}
+		if Exists(name) {
+			t.Errorf("Expected Exists(%q) to be false", name)
+		}
+	}
+	if err := (&Manifest{App: "../settings"}).Save(); err == nil {
+		t.Error("Expected Save to refuse a path name")
+	}
+	if err := ValidateName("postgresql@16"); err != nil {
+		t.Errorf("Unexpected error for a valid name: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package network builds the HTTP client anvil downloads with, applying the proxy and
+// CA bundle from settings, and resolves per-source headers and mirrors.
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+// mirrorFilePlaceholder is replaced by the source's file name in mirror URLs
+const mirrorFilePlaceholder = "{file}"
+
+// Settings returns the network section of settings.yaml, or the defaults when settings
+// cannot be loaded
+func Settings() config.AnvilNetworkOptions {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return config.AnvilNetworkOptions{}
+	}
+	return cfg.Network
+}
+
+// Client returns an HTTP client using the proxy and CA bundle from settings
+func Client() (*http.Client, error) {
+	return NewClient(Settings())
+}
+
+// NewClient returns an HTTP client for the given network options. Without a proxy the
+// standard proxy environment variables apply; a CA bundle adds to the system roots.
+func NewClient(options config.AnvilNetworkOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if options.Proxy != "" {
+		proxyURL, err := url.Parse(options.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid network.proxy '%s' (expected a URL such as http://proxy.example.com:3128)", options.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if bundle := options.CABundlePath(); bundle != "" {
+		pem, err := os.ReadFile(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read network.ca_bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("network.ca_bundle %s contains no PEM certificates", bundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// Environment returns the variables that make child processes such as curl, git and
+// install scripts use the proxy and CA bundle from settings
+func Environment(options config.AnvilNetworkOptions) []string {
+	var env []string
+	if options.Proxy != "" {
+		for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "https_proxy", "http_proxy"} {
+			env = append(env, name+"="+options.Proxy)
+		}
+	}
+	if bundle := options.CABundlePath(); bundle != "" {
+		for _, name := range []string{"SSL_CERT_FILE", "CURL_CA_BUNDLE", "GIT_SSL_CAINFO"} {
+			env = append(env, name+"="+bundle)
+		}
+	}
+	return env
+}
+
+// ResolveHeaders expands the environment variables in header values. Values that
+// reference no variable are refused so secrets are never kept in settings, and so are
+// references to unset variables.
+func ResolveHeaders(headers map[string]string) (map[string]string, error) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]string, len(headers))
+	for _, name := range names {
+		value := headers[name]
+		if !config.HasEnvReference(value) {
+			return nil, fmt.Errorf("header '%s' must reference an environment variable such as ${TOKEN}", name)
+		}
+
+		var missing []string
+		expanded := os.Expand(value, func(variable string) string {
+			v, ok := os.LookupEnv(variable)
+			if !ok || v == "" {
+				missing = append(missing, variable)
+			}
+			return v
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("environment variable %s for header '%s' is not set", strings.Join(missing, ", "), name)
+		}
+		resolved[name] = expanded
+	}
+	return resolved, nil
+}
+
+// CandidateURLs returns the URLs to download a file from: the mirrors in order, with
+// {file} replaced by fileName, followed by sourceURL itself
+func CandidateURLs(sourceURL, fileName string, mirrors []string) []string {
+	urls := make([]string, 0, len(mirrors)+1)
+	for _, mirror := range mirrors {
+		if mirror = strings.TrimSpace(mirror); mirror != "" {
+			urls = append(urls, strings.ReplaceAll(mirror, mirrorFilePlaceholder, fileName))
+		}
+	}
+	return append(urls, sourceURL)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright © 2022 Juanma Roca juanmaxroca@gmail.com
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xjuanma/anvil/internal/config"
+)
+
+func TestResolveHeaders(t *testing.T) {
+	t.Setenv("ANVIL_TEST_TOKEN", "secret")
+	t.Setenv("ANVIL_TEST_EMPTY", "")
+
+	resolved, err := ResolveHeaders(map[string]string{
+		"Authorization": "Bearer ${ANVIL_TEST_TOKEN}",
+		"X-Token":       "$ANVIL_TEST_TOKEN",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{"Authorization": "Bearer secret", "X-Token": "secret"}
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("Expected %v, got %v", expected, resolved)
+	}
+
+	errors := map[string]string{
+		"Bearer abc123":              "must reference an environment variable",
+		"Bearer ${ANVIL_TEST_UNSET}": "ANVIL_TEST_UNSET for header 'Authorization' is not set",
+		"Bearer ${ANVIL_TEST_EMPTY}": "ANVIL_TEST_EMPTY",
+	}
+	for value, message := range errors {
+		_, err := ResolveHeaders(map[string]string{"Authorization": value})
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%q: expected an error containing %q, got %v", value, message, err)
+		}
+	}
+}
+
+func TestCandidateURLs(t *testing.T) {
+	got := CandidateURLs("https://example.com/v1/tool.tar.gz", "tool.tar.gz", []string{
+		"https://mirror.internal/{file}",
+		" ",
+		"https://cache.internal/tools/latest.tar.gz",
+	})
+	expected := []string{
+		"https://mirror.internal/tool.tar.gz",
+		"https://cache.internal/tools/latest.tar.gz",
+		"https://example.com/v1/tool.tar.gz",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	t.Run("unknown authorities are refused by default", func(t *testing.T) {
+		client, err := NewClient(config.AnvilNetworkOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Get(server.URL); err == nil {
+			t.Error("Expected a certificate error")
+		}
+	})
+
+	t.Run("ca bundle is trusted", func(t *testing.T) {
+		bundle := filepath.Join(t.TempDir(), "ca.pem")
+		cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		if err := os.WriteFile(bundle, cert, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := NewClient(config.AnvilNetworkOptions{CABundle: bundle})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("invalid settings are reported", func(t *testing.T) {
+		notPEM := filepath.Join(t.TempDir(), "ca.pem")
+		if err := os.WriteFile(notPEM, []byte("not a certificate"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, options := range []config.AnvilNetworkOptions{
+			{Proxy: "proxy.internal:3128"},
+			{CABundle: notPEM},
+			{CABundle: filepath.Join(t.TempDir(), "missing.pem")},
+		} {
+			if _, err := NewClient(options); err == nil {
+				t.Errorf("Expected an error for %+v", options)
+			}
+		}
+	})
+
+	t.Run("proxy is used", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+			w.Write([]byte("via proxy"))
+		}))
+		defer proxy.Close()
+
+		client, err := NewClient(config.AnvilNetworkOptions{Proxy: proxy.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get("http://artifacts.internal/tool.tar.gz")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if proxied != "http://artifacts.internal/tool.tar.gz" {
+			t.Errorf("Expected the request to go through the proxy, got %q", proxied)
+		}
+	})
+}
+
+func TestEnvironment(t *testing.T) {
+	if env := Environment(config.AnvilNetworkOptions{}); len(env) != 0 {
+		t.Errorf("Expected no variables without settings, got %q", env)
+	}
+
+	env := strings.Join(Environment(config.AnvilNetworkOptions{Proxy: "http://proxy:3128", CABundle: "/etc/ca.pem"}), " ")
+	for _, expected := range []string{"HTTPS_PROXY=http://proxy:3128", "http_proxy=http://proxy:3128", "SSL_CERT_FILE=/etc/ca.pem", "GIT_SSL_CAINFO=/etc/ca.pem"} {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected %s in %q", expected, env)
+		}
+	}
+}
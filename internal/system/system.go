@@ -73,6 +73,45 @@ func RunCommandWithTimeout(ctx context.Context, command string, args ...string)
 	return result, nil
 }
 
+// RedactEnv returns environment variables in "KEY=value" form with their values hidden
+// as "KEY=***", since they may hold tokens
+func RedactEnv(env []string) []string {
+	redacted := make([]string, len(env))
+	for i, variable := range env {
+		key, _, _ := strings.Cut(variable, "=")
+		redacted[i] = key + "=***"
+	}
+	return redacted
+}
+
+// RunCommandWithEnv executes a system command with a default timeout and extra
+// environment variables in "KEY=value" form, added to the current environment.
+// The values are redacted from the command recorded in the result.
+func RunCommandWithEnv(env []string, command string, args ...string) (*CommandResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Env = append(os.Environ(), env...)
+
+	output, err := cmd.CombinedOutput()
+
+	result := &CommandResult{
+		Command: strings.Join(append(RedactEnv(env), append([]string{command}, args...)...), " "),
+		Output:  string(output),
+		Success: err == nil,
+	}
+
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitError.ExitCode()
+		}
+		result.Error = err.Error()
+	}
+
+	return result, nil
+}
+
 // CommandExists checks if a command exists in the system PATH
 func CommandExists(command string) bool {
 	_, err := exec.LookPath(command)
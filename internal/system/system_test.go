@@ -171,3 +171,17 @@ func TestRunCommand(t *testing.T) {
 		}
 	})
 }
+
+// TestRunCommandWithEnv validates extra environment variables reach the command
+func TestRunCommandWithEnv(t *testing.T) {
+	result, err := RunCommandWithEnv([]string{"ANVIL_TEST_VALUE=from-env"}, "sh", "-c", "echo $ANVIL_TEST_VALUE")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !result.Success || !strings.Contains(result.Output, "from-env") {
+		t.Errorf("Expected output to contain 'from-env', got: %s", result.Output)
+	}
+	if !strings.HasPrefix(result.Command, "ANVIL_TEST_VALUE=*** sh") {
+		t.Errorf("Expected command to show the environment with its values redacted, got: %s", result.Command)
+	}
+}
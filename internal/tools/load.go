@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/0xjuanma/anvil/internal/brew"
 	"github.com/0xjuanma/anvil/internal/config"
 	"github.com/0xjuanma/anvil/internal/constants"
 	"github.com/0xjuanma/anvil/internal/errors"
@@ -61,5 +62,29 @@ func LoadAndPrepareAppData() (utils.AppData, error) {
 		data.InstalledApps = installedApps
 	}
 
+	// Describe apps from the offline Homebrew index
+	data.Descriptions = appDescriptions(data)
+
 	return data, nil
 }
+
+// appDescriptions looks up the Homebrew index description of every grouped and tracked app
+func appDescriptions(data utils.AppData) map[string]string {
+	index := brew.CurrentIndex()
+	descriptions := make(map[string]string)
+	describe := func(app string) {
+		if description := index.Description(app); description != "" {
+			descriptions[app] = description
+		}
+	}
+
+	for _, apps := range data.Groups {
+		for _, app := range apps {
+			describe(app)
+		}
+	}
+	for _, app := range data.InstalledApps {
+		describe(app)
+	}
+	return descriptions
+}
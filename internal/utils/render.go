@@ -30,6 +30,8 @@ type AppTreeNode struct {
 	IsGroup  bool
 	Apps     []string
 	Children []*AppTreeNode
+
+	Descriptions map[string]string // App descriptions shown next to app names
 }
 
 // AppData holds all application data for rendering
@@ -38,8 +40,12 @@ type AppData struct {
 	BuiltInGroupNames []string
 	CustomGroupNames  []string
 	InstalledApps     []string
+	Descriptions      map[string]string // App descriptions from the Homebrew index
 }
 
+// maxDescriptionLength keeps tree lines short enough to fit the rendered box
+const maxDescriptionLength = 60
+
 // RenderListView renders applications in a flat list format
 func RenderListView(data AppData) string {
 	var content strings.Builder
@@ -96,9 +102,10 @@ func RenderTreeView(data AppData) string {
 		for _, groupName := range data.BuiltInGroupNames {
 			if tools, exists := data.Groups[groupName]; exists {
 				groupNode := &AppTreeNode{
-					Name:    groupName,
-					IsGroup: true,
-					Apps:    tools,
+					Name:         groupName,
+					IsGroup:      true,
+					Apps:         tools,
+					Descriptions: data.Descriptions,
 				}
 				builtInNode.Children = append(builtInNode.Children, groupNode)
 			}
@@ -119,9 +126,10 @@ func RenderTreeView(data AppData) string {
 
 		for _, groupName := range data.CustomGroupNames {
 			groupNode := &AppTreeNode{
-				Name:    groupName,
-				IsGroup: true,
-				Apps:    data.Groups[groupName],
+				Name:         groupName,
+				IsGroup:      true,
+				Apps:         data.Groups[groupName],
+				Descriptions: data.Descriptions,
 			}
 			customNode.Children = append(customNode.Children, groupNode)
 		}
@@ -139,8 +147,9 @@ func RenderTreeView(data AppData) string {
 
 		for _, appName := range data.InstalledApps {
 			appNode := &AppTreeNode{
-				Name:    appName,
-				IsGroup: false,
+				Name:         appName,
+				IsGroup:      false,
+				Descriptions: data.Descriptions,
 			}
 			individualNode.Children = append(individualNode.Children, appNode)
 		}
@@ -177,7 +186,7 @@ func buildTreeString(builder *strings.Builder, node *AppTreeNode, prefix string,
 			coloredName = fmt.Sprintf("%s%s%s%s", palantir.ColorBold, palantir.ColorCyan, node.Name, palantir.ColorReset)
 		} else {
 			// Individual apps in green
-			coloredName = fmt.Sprintf("%s%s%s", palantir.ColorGreen, node.Name, palantir.ColorReset)
+			coloredName = fmt.Sprintf("%s%s%s%s", palantir.ColorGreen, node.Name, palantir.ColorReset, describeApp(node.Name, node.Descriptions))
 		}
 		builder.WriteString(fmt.Sprintf("%s%s%s\n", prefix, treeChar, coloredName))
 	}
@@ -203,7 +212,7 @@ func buildTreeString(builder *strings.Builder, node *AppTreeNode, prefix string,
 			}
 
 			// Color individual apps in green
-			coloredApp := fmt.Sprintf("%s%s%s", palantir.ColorGreen, app, palantir.ColorReset)
+			coloredApp := fmt.Sprintf("%s%s%s%s", palantir.ColorGreen, app, palantir.ColorReset, describeApp(app, node.Descriptions))
 			builder.WriteString(fmt.Sprintf("%s%s%s\n", appPrefix, appTreeChar, coloredApp))
 		}
 	}
@@ -229,3 +238,15 @@ func buildTreeString(builder *strings.Builder, node *AppTreeNode, prefix string,
 		}
 	}
 }
+
+// describeApp returns the " - description" suffix for an app in the tree view, if its description is known
+func describeApp(appName string, descriptions map[string]string) string {
+	description := descriptions[appName]
+	if description == "" {
+		return ""
+	}
+	if len(description) > maxDescriptionLength {
+		description = strings.TrimSpace(description[:maxDescriptionLength-3]) + "..."
+	}
+	return " - " + description
+}
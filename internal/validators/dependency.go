@@ -19,10 +19,12 @@ package validators
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/0xjuanma/anvil/internal/brew"
 	"github.com/0xjuanma/anvil/internal/config"
+	"github.com/0xjuanma/anvil/internal/manifest"
 	"github.com/0xjuanma/anvil/internal/system"
 	"github.com/0xjuanma/palantir"
 )
@@ -219,3 +221,303 @@ func (v *RequiredToolsValidator) Fix(ctx context.Context, cfg *config.AnvilConfi
 
 	return nil
 }
+
+// TapsValidator checks that the Homebrew taps needed by settings are tapped
+type TapsValidator struct{}
+
+func (v *TapsValidator) Name() string     { return "homebrew-taps" }
+func (v *TapsValidator) Category() string { return "dependencies" }
+func (v *TapsValidator) Description() string {
+	return "Verify configured and tap-qualified app taps are tapped"
+}
+func (v *TapsValidator) CanFix() bool { return true }
+
+func (v *TapsValidator) Validate(ctx context.Context, cfg *config.AnvilConfig) *ValidationResult {
+	taps := config.RequiredTaps(cfg, config.ConfiguredTools(cfg))
+	if len(taps) == 0 {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   PASS,
+			Message:  "No Homebrew taps required",
+			AutoFix:  false,
+		}
+	}
+
+	if !brew.IsBrewInstalled() {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   SKIP,
+			Message:  "Homebrew is not installed",
+			Details:  []string{fmt.Sprintf("%d tap(s) required", len(taps))},
+			AutoFix:  false,
+		}
+	}
+
+	tapped, err := brew.TappedRepositories()
+	if err != nil {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   FAIL,
+			Message:  "Could not list Homebrew taps",
+			Details:  []string{err.Error()},
+			FixHint:  "Try running 'brew doctor' to diagnose issues",
+			AutoFix:  false,
+		}
+	}
+
+	missing := config.MissingTaps(taps, tapped)
+	if len(missing) > 0 {
+		names := make([]string, len(missing))
+		for i, tap := range missing {
+			names[i] = tap.Name
+		}
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   WARN,
+			Message:  fmt.Sprintf("Missing Homebrew taps: %s", strings.Join(names, ", ")),
+			Details:  []string{fmt.Sprintf("Tapped: %d/%d", len(taps)-len(missing), len(taps))},
+			FixHint:  "Missing taps will be tapped automatically",
+			AutoFix:  true,
+		}
+	}
+
+	names := make([]string, len(taps))
+	for i, tap := range taps {
+		names[i] = tap.Name
+	}
+	return &ValidationResult{
+		Name:     v.Name(),
+		Category: v.Category(),
+		Status:   PASS,
+		Message:  fmt.Sprintf("All required taps tapped (%d/%d)", len(taps), len(taps)),
+		Details:  names,
+		AutoFix:  false,
+	}
+}
+
+func (v *TapsValidator) Fix(ctx context.Context, cfg *config.AnvilConfig) error {
+	o := palantir.GetGlobalOutputHandler()
+
+	tapped, err := brew.TappedRepositories()
+	if err != nil {
+		return err
+	}
+
+	var tapErrors []string
+	for _, tap := range config.MissingTaps(config.RequiredTaps(cfg, config.ConfiguredTools(cfg)), tapped) {
+		if err := brew.Tap(tap.Name, tap.URL); err != nil {
+			tapErrors = append(tapErrors, fmt.Sprintf("%s: %v", tap.Name, err))
+			continue
+		}
+		o.PrintSuccess(fmt.Sprintf("Tapped %s", tap.Name))
+	}
+
+	if len(tapErrors) > 0 {
+		return fmt.Errorf("failed to tap some repositories: %s", strings.Join(tapErrors, "; "))
+	}
+
+	return nil
+}
+
+// ServicesValidator checks that the brew services declared in settings are running
+type ServicesValidator struct{}
+
+func (v *ServicesValidator) Name() string     { return "brew-services" }
+func (v *ServicesValidator) Category() string { return "dependencies" }
+func (v *ServicesValidator) Description() string {
+	return "Verify declared Homebrew services are running"
+}
+func (v *ServicesValidator) CanFix() bool { return true }
+
+func (v *ServicesValidator) Validate(ctx context.Context, cfg *config.AnvilConfig) *ValidationResult {
+	declared := declaredServices(cfg)
+	if len(declared) == 0 {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   PASS,
+			Message:  "No services declared",
+			AutoFix:  false,
+		}
+	}
+
+	if !brew.IsBrewInstalled() {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   SKIP,
+			Message:  "Homebrew is not installed",
+			Details:  []string{fmt.Sprintf("%d service(s) declared", len(declared))},
+			AutoFix:  false,
+		}
+	}
+
+	services, err := brew.Services()
+	if err != nil {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   FAIL,
+			Message:  "Could not list Homebrew services",
+			Details:  []string{err.Error()},
+			FixHint:  "Try running 'brew services list' to diagnose issues",
+			AutoFix:  false,
+		}
+	}
+
+	var stopped, missing []string
+	for _, app := range declared {
+		service, ok := brew.FindService(services, app)
+		switch {
+		case !ok:
+			missing = append(missing, app)
+		case !service.IsRunning():
+			stopped = append(stopped, fmt.Sprintf("%s (%s)", app, service.Status))
+		}
+	}
+
+	if len(stopped) > 0 || len(missing) > 0 {
+		var details []string
+		if len(stopped) > 0 {
+			details = append(details, fmt.Sprintf("Not running: %s", strings.Join(stopped, ", ")))
+		}
+		if len(missing) > 0 {
+			details = append(details, fmt.Sprintf("Not installed: %s (install them with 'anvil install')", strings.Join(missing, ", ")))
+		}
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   WARN,
+			Message:  fmt.Sprintf("%d of %d declared service(s) not running", len(stopped)+len(missing), len(declared)),
+			Details:  details,
+			FixHint:  "Installed services will be started automatically",
+			AutoFix:  len(stopped) > 0,
+		}
+	}
+
+	return &ValidationResult{
+		Name:     v.Name(),
+		Category: v.Category(),
+		Status:   PASS,
+		Message:  fmt.Sprintf("All declared services running (%d/%d)", len(declared), len(declared)),
+		Details:  declared,
+		AutoFix:  false,
+	}
+}
+
+func (v *ServicesValidator) Fix(ctx context.Context, cfg *config.AnvilConfig) error {
+	o := palantir.GetGlobalOutputHandler()
+
+	services, err := brew.Services()
+	if err != nil {
+		return err
+	}
+
+	var startErrors []string
+	for _, app := range declaredServices(cfg) {
+		service, ok := brew.FindService(services, app)
+		if !ok || service.IsRunning() {
+			continue
+		}
+		if err := brew.RunServiceAction(brew.ServiceStart, app); err != nil {
+			startErrors = append(startErrors, fmt.Sprintf("%s: %v", app, err))
+			continue
+		}
+		o.PrintSuccess(fmt.Sprintf("Started %s", app))
+	}
+
+	if len(startErrors) > 0 {
+		return fmt.Errorf("failed to start some services: %s", strings.Join(startErrors, "; "))
+	}
+
+	return nil
+}
+
+// declaredServices returns the apps whose service should be running, sorted by name
+func declaredServices(cfg *config.AnvilConfig) []string {
+	var apps []string
+	for app, action := range cfg.Services {
+		if action == brew.ServiceStart || action == brew.ServiceRestart {
+			apps = append(apps, app)
+		}
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+// SourceManifestsValidator checks that the files recorded for apps installed from sources still exist
+type SourceManifestsValidator struct{}
+
+func (v *SourceManifestsValidator) Name() string     { return "source-manifests" }
+func (v *SourceManifestsValidator) Category() string { return "dependencies" }
+func (v *SourceManifestsValidator) Description() string {
+	return "Verify files of apps installed from sources still exist"
+}
+func (v *SourceManifestsValidator) CanFix() bool { return false }
+
+func (v *SourceManifestsValidator) Validate(ctx context.Context, cfg *config.AnvilConfig) *ValidationResult {
+	manifests, err := manifest.List()
+	if err != nil {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   FAIL,
+			Message:  "Could not read install manifests",
+			Details:  []string{err.Error()},
+			FixHint:  fmt.Sprintf("Check the files in %s", manifest.Dir()),
+			AutoFix:  false,
+		}
+	}
+	if len(manifests) == 0 {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   PASS,
+			Message:  "No apps installed from sources",
+			AutoFix:  false,
+		}
+	}
+
+	var details, broken []string
+	for _, m := range manifests {
+		missing := m.Missing()
+		if len(missing) == 0 {
+			continue
+		}
+		broken = append(broken, m.App)
+		details = append(details, fmt.Sprintf("%s: missing %s", m.App, strings.Join(missing, ", ")))
+	}
+
+	if len(broken) > 0 {
+		return &ValidationResult{
+			Name:     v.Name(),
+			Category: v.Category(),
+			Status:   WARN,
+			Message:  fmt.Sprintf("%d of %d source install(s) have missing files", len(broken), len(manifests)),
+			Details:  details,
+			FixHint:  fmt.Sprintf("Reinstall with 'anvil install %s --upgrade' or remove with 'anvil uninstall %s'", broken[0], broken[0]),
+			AutoFix:  false,
+		}
+	}
+
+	apps := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		apps = append(apps, m.App)
+	}
+	return &ValidationResult{
+		Name:     v.Name(),
+		Category: v.Category(),
+		Status:   PASS,
+		Message:  fmt.Sprintf("All source installs intact (%d/%d)", len(manifests), len(manifests)),
+		Details:  apps,
+		AutoFix:  false,
+	}
+}
+
+func (v *SourceManifestsValidator) Fix(ctx context.Context, cfg *config.AnvilConfig) error {
+	return fmt.Errorf("automatic repair not supported, reinstall with 'anvil install <app> --upgrade'")
+}
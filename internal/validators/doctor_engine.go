@@ -175,10 +175,14 @@ func (d *DoctorEngine) registerDefaultValidators() {
 	d.registry.Register(&InitRunValidator{})
 	d.registry.Register(&SettingsFileValidator{})
 	d.registry.Register(&DirectoryStructureValidator{})
+	d.registry.Register(&LocalBinPathValidator{})
 
 	// Dependency validators
 	d.registry.Register(&BrewValidator{})
 	d.registry.Register(&RequiredToolsValidator{})
+	d.registry.Register(&TapsValidator{})
+	d.registry.Register(&ServicesValidator{})
+	d.registry.Register(&SourceManifestsValidator{})
 
 	// Configuration validators
 	d.registry.Register(&GitConfigValidator{})
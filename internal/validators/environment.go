@@ -130,7 +130,7 @@ func (v *SettingsFileValidator) Validate(ctx context.Context, cfg *config.AnvilC
 			Status:   FAIL,
 			Message:  "Settings file is not valid YAML",
 			Details:  []string{err.Error()},
-			FixHint:  "Check YAML syntax in " + configPath,
+			FixHint:  "Run 'anvil config validate' for line-numbered diagnostics",
 			AutoFix:  false,
 		}
 	}
@@ -240,3 +240,48 @@ func (v *DirectoryStructureValidator) Fix(ctx context.Context, cfg *config.Anvil
 
 	return nil
 }
+
+// LocalBinPathValidator checks that the directory holding links to installed executables is on PATH
+type LocalBinPathValidator struct{}
+
+func (v *LocalBinPathValidator) Name() string     { return "local-bin-path" }
+func (v *LocalBinPathValidator) Category() string { return "environment" }
+func (v *LocalBinPathValidator) Description() string {
+	return "Verify the user bin directory for source installs is on PATH"
+}
+func (v *LocalBinPathValidator) CanFix() bool { return false }
+
+func (v *LocalBinPathValidator) Validate(ctx context.Context, cfg *config.AnvilConfig) *ValidationResult {
+	var options config.AnvilInstallOptions
+	if cfg != nil {
+		options = cfg.Install
+	}
+	binDir := filepath.Clean(options.BinDir())
+
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry != "" && filepath.Clean(entry) == binDir {
+			return &ValidationResult{
+				Name:     v.Name(),
+				Category: v.Category(),
+				Status:   PASS,
+				Message:  "User bin directory is on PATH",
+				Details:  []string{"Directory: " + binDir},
+				AutoFix:  false,
+			}
+		}
+	}
+
+	return &ValidationResult{
+		Name:     v.Name(),
+		Category: v.Category(),
+		Status:   WARN,
+		Message:  "User bin directory is not on PATH",
+		Details:  []string{"Executables installed from sources are linked into " + binDir},
+		FixHint:  fmt.Sprintf("Add 'export PATH=\"%s:$PATH\"' to your shell profile", binDir),
+		AutoFix:  false,
+	}
+}
+
+func (v *LocalBinPathValidator) Fix(ctx context.Context, cfg *config.AnvilConfig) error {
+	return fmt.Errorf("automatic PATH changes not supported, update your shell profile manually")
+}